@@ -0,0 +1,101 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Set on the delete of Archive, whose copy is already made
+const archivedKey = "orm:archived"
+
+// MigrateArchive creates or completes the archive tables of models, named after their table with a _deleted suffix
+// and holding the same columns plus At, the time the row was archived.
+// Indexes are created as well, those named explicitly must allow for the second table.
+func (db *DB) MigrateArchive(models ...interface{}) error {
+	for _, model := range models {
+		table := archiveTable(db.NewScope(model).TableName())
+		if err := db.Table(table).AutoMigrate(model, &struct{ At time.Time }{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Archive moves record to its archive table, see MigrateArchive, in a transaction.
+// Soft deleted records are archived as well.
+func (db *DB) Archive(record interface{}) error {
+	return db.Transaction(db.context(), func(tx *TX) error {
+		return tx.Archive(record)
+	})
+}
+
+// Restore moves record, whose primary key is set, back from its archive table in a transaction, and loads it
+func (db *DB) Restore(record interface{}) error {
+	return db.Transaction(db.context(), func(tx *TX) error {
+		return tx.Restore(record)
+	})
+}
+
+// Archive copies record, whose primary key is set, to its archive table with At set to now, then deletes it.
+// It fails with gorm.ErrRecordNotFound when the record does not exist, or belongs to another tenant.
+func (tx *TX) Archive(record interface{}) error {
+	scope := tx.DB.Unscoped().NewScope(record)
+	if scope.PrimaryKeyZero() {
+		return errors.New("orm: archiving a record needs its primary key")
+	}
+	// The copy is a raw statement, which the tenant callbacks do not scope
+	if scopeTenantDelete(scope); scope.HasError() {
+		return scope.DB().Error
+	}
+	archived, err := archiveRows(scope)
+	if err != nil {
+		return err
+	}
+	if archived == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	deleted := tx.DB.Unscoped().Set(archivedKey, true).Delete(record)
+	if deleted.Error != nil {
+		return deleted.Error
+	}
+	if deleted.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Restore copies record, whose primary key is set, back from its archive table, deletes the archived row
+// and loads the record. It fails with gorm.ErrRecordNotFound when the record is not archived.
+func (tx *TX) Restore(record interface{}) error {
+	scope := tx.DB.Unscoped().NewScope(record)
+	if scope.PrimaryKeyZero() {
+		return errors.New("orm: restoring a record needs its primary key")
+	}
+	archive := scope.Quote(archiveTable(scope.TableName()))
+
+	var conditions []string
+	var args []interface{}
+	for _, field := range scope.PrimaryFields() {
+		conditions = append(conditions, fmt.Sprintf("%s.%s = ?", archive, scope.Quote(field.DBName)))
+		args = append(args, field.Field.Interface())
+	}
+	where := strings.Join(conditions, " AND ")
+	list := strings.Join(archiveColumns(scope), ", ")
+
+	restored := tx.DB.Exec(fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s",
+		scope.QuotedTableName(), list, list, archive, where), args...)
+	if restored.Error != nil {
+		return restored.Error
+	}
+	if restored.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	if err := tx.DB.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", archive, where), args...).Error; err != nil {
+		return err
+	}
+	return tx.DB.Unscoped().First(record).Error
+}
@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type archiveDB struct {
+	ID   int
+	Name string
+}
+
+func TestDB_Archive(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&archiveDB{}).Error)
+	assert.NoError(t, db.MigrateArchive(&archiveDB{}))
+
+	assert.NoError(t, db.Create(&archiveDB{ID: 1, Name: "a"}).Error)
+	assert.NoError(t, db.Create(&archiveDB{ID: 2, Name: "b"}).Error)
+	assert.NoError(t, db.Archive(&archiveDB{ID: 1}))
+	assert.Error(t, db.Archive(&archiveDB{}))
+	assert.True(t, gorm.IsRecordNotFoundError(db.Archive(&archiveDB{ID: 3})))
+
+	var rows []archiveDB
+	assert.NoError(t, db.Find(&rows).Error)
+	assert.Equal(t, []archiveDB{{ID: 2, Name: "b"}}, rows)
+	var archived []struct {
+		ID   int
+		Name string
+		At   time.Time
+	}
+	assert.NoError(t, db.Table("archive_db_deleted").Find(&archived).Error)
+	if assert.Len(t, archived, 1) {
+		assert.Equal(t, "a", archived[0].Name)
+		assert.False(t, archived[0].At.IsZero())
+	}
+
+	restored := &archiveDB{ID: 1}
+	assert.NoError(t, db.Restore(restored))
+	assert.Equal(t, "a", restored.Name)
+	assert.True(t, gorm.IsRecordNotFoundError(db.Restore(&archiveDB{ID: 1})))
+	assert.NoError(t, db.Table("archive_db_deleted").Find(&archived).Error)
+	assert.Empty(t, archived)
+
+	// The move is atomic: a failing delete leaves nothing archived
+	assert.Error(t, db.ReadOnly().Archive(&archiveDB{ID: 2}))
+	assert.NoError(t, db.Table("archive_db_deleted").Find(&archived).Error)
+	assert.Empty(t, archived)
+	assert.NoError(t, db.Order("id").Find(&rows).Error)
+	assert.Len(t, rows, 2)
+}
+
+type tenantArchiveDB struct {
+	ID   int
+	Name string
+	TenantModel
+}
+
+func TestDB_Archive_tenant(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&tenantArchiveDB{}).Error)
+	assert.NoError(t, db.MigrateArchive(&tenantArchiveDB{}))
+	ctx := context.Background()
+	assert.NoError(t, db.ForTenant(ctx, "a").Create(&tenantArchiveDB{ID: 1, Name: "a"}).Error)
+
+	assert.True(t, gorm.IsRecordNotFoundError(db.ForTenant(ctx, "b").Archive(&tenantArchiveDB{ID: 1})))
+	assert.Equal(t, ErrCrossTenant, db.ForTenant(ctx, "b").Archive(&tenantArchiveDB{ID: 1, TenantModel: TenantModel{TenantID: "a"}}))
+	var count int
+	assert.NoError(t, db.Table("tenant_archive_db_deleted").Count(&count).Error)
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, db.ForTenant(ctx, "a").Archive(&tenantArchiveDB{ID: 1}))
+	assert.NoError(t, db.Table("tenant_archive_db_deleted").Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,163 @@
+package orm
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+// StringArray is a list of strings stored as a text[] array on postgres and as a JSON array in a text column elsewhere.
+// nil is stored as NULL.
+// The conversion happens when records are written, arguments of Where take the JSON form; use pq.Array on postgres.
+type StringArray []string
+
+// Value implements driver.Valuer with the portable JSON form
+func (a StringArray) Value() (driver.Value, error) {
+	return jsonArrayValue(a == nil, a)
+}
+
+// DialectValue implements bulk_insert.DialectValuer, native arrays on postgres
+func (a StringArray) DialectValue(dialect string) (driver.Value, error) {
+	if dialect == "postgres" {
+		return pq.StringArray(a).Value()
+	}
+	return a.Value()
+}
+
+// Scan implements sql.Scanner, reading both forms
+func (a *StringArray) Scan(src interface{}) error {
+	return scanArray(src, (*pq.StringArray)(a), (*[]string)(a))
+}
+
+// GormDataType sets the column type of StringArray fields
+func (StringArray) GormDataType(dialect gorm.Dialect) string {
+	return arrayDataType(dialect, "text[]")
+}
+
+// Int64Array is a list of integers stored as a bigint[] array on postgres and as a JSON array in a text column elsewhere.
+// nil is stored as NULL.
+// The conversion happens when records are written, arguments of Where take the JSON form; use pq.Array on postgres.
+type Int64Array []int64
+
+// Value implements driver.Valuer with the portable JSON form
+func (a Int64Array) Value() (driver.Value, error) {
+	return jsonArrayValue(a == nil, a)
+}
+
+// DialectValue implements bulk_insert.DialectValuer, native arrays on postgres
+func (a Int64Array) DialectValue(dialect string) (driver.Value, error) {
+	if dialect == "postgres" {
+		return pq.Int64Array(a).Value()
+	}
+	return a.Value()
+}
+
+// Scan implements sql.Scanner, reading both forms
+func (a *Int64Array) Scan(src interface{}) error {
+	return scanArray(src, (*pq.Int64Array)(a), (*[]int64)(a))
+}
+
+// GormDataType sets the column type of Int64Array fields
+func (Int64Array) GormDataType(dialect gorm.Dialect) string {
+	return arrayDataType(dialect, "bigint[]")
+}
+
+func jsonArrayValue(isNil bool, a interface{}) (driver.Value, error) {
+	if isNil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan src, a postgres array literal into native or a JSON array into decoded
+func scanArray(src interface{}, native interface{ Scan(interface{}) error }, decoded interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		reflect.ValueOf(decoded).Elem().Set(reflect.Zero(reflect.TypeOf(decoded).Elem()))
+		return nil
+	case string:
+		data = []byte(src)
+	case []byte:
+		data = src
+	default:
+		return fmt.Errorf("orm: can not scan %T into an array", src)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return native.Scan(data)
+	}
+	return json.Unmarshal(data, decoded)
+}
+
+func arrayDataType(dialect gorm.Dialect, postgres string) string {
+	switch dialect.GetName() {
+	case "postgres":
+		return postgres
+	case "mssql":
+		return "nvarchar(max)"
+	case "clickhouse":
+		return "String"
+	}
+	return "text"
+}
+
+// Valuer of a DialectValuer bound to a dialect
+type dialectValue struct {
+	valuer  bulk_insert.DialectValuer
+	dialect string
+}
+
+func (v dialectValue) Value() (driver.Value, error) {
+	return v.valuer.DialectValue(v.dialect)
+}
+
+const dialectFieldsKey = "orm:dialect_fields"
+
+// Register the callbacks writing bulk_insert.DialectValuer fields with their value for the dialect.
+// The fields are swapped for bound valuers while gorm builds the statement and restored after.
+func registerDialectValues(db *gorm.DB) {
+	bind := func(scope *gorm.Scope) {
+		dialect := scope.Dialect().GetName()
+		swapped := make(map[*gorm.Field]reflect.Value)
+		for _, field := range scope.Fields() {
+			valuer, ok := field.Field.Interface().(bulk_insert.DialectValuer)
+			if !ok || (field.Field.Kind() == reflect.Ptr && field.Field.IsNil()) {
+				continue
+			}
+			swapped[field] = field.Field
+			field.Field = reflect.ValueOf(dialectValue{valuer: valuer, dialect: dialect})
+		}
+		if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+			for column, value := range attrs.(map[string]interface{}) {
+				if valuer, ok := value.(bulk_insert.DialectValuer); ok {
+					attrs.(map[string]interface{})[column] = dialectValue{valuer: valuer, dialect: dialect}
+				}
+			}
+		}
+		scope.InstanceSet(dialectFieldsKey, swapped)
+	}
+	restore := func(scope *gorm.Scope) {
+		if swapped, ok := scope.InstanceGet(dialectFieldsKey); ok {
+			for field, value := range swapped.(map[*gorm.Field]reflect.Value) {
+				field.Field = value
+			}
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("orm:dialect_values", bind)
+	callback.Create().After("gorm:create").Register("orm:restore_dialect_values", restore)
+	callback.Update().Before("gorm:update").Register("orm:dialect_values", bind)
+	callback.Update().After("gorm:update").Register("orm:restore_dialect_values", restore)
+}
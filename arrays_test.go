@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type arrayDB struct {
+	ID     int
+	Tags   StringArray
+	Scores Int64Array
+}
+
+func TestStringArray(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&arrayDB{}).Error)
+
+	record := arrayDB{ID: 1, Tags: StringArray{"a", "b,c"}, Scores: Int64Array{1, 2}}
+	assert.NoError(t, db.Create(&record).Error)
+	assert.Equal(t, StringArray{"a", "b,c"}, record.Tags)
+	assert.NoError(t, db.BulkCreate([]arrayDB{{ID: 2, Tags: StringArray{"d"}}, {ID: 3}}))
+
+	var stored []arrayDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, []arrayDB{
+		{ID: 1, Tags: StringArray{"a", "b,c"}, Scores: Int64Array{1, 2}},
+		{ID: 2, Tags: StringArray{"d"}},
+		{ID: 3},
+	}, stored)
+
+	assert.NoError(t, db.Model(&arrayDB{ID: 3}).Updates(map[string]interface{}{"tags": StringArray{"e"}}).Error)
+	var updated arrayDB
+	assert.NoError(t, db.Where("tags = ?", StringArray{"e"}).First(&updated).Error)
+	assert.Equal(t, 3, updated.ID)
+
+	// Postgres literals are read as well
+	var tags StringArray
+	assert.NoError(t, tags.Scan([]byte(`{a,"b,c"}`)))
+	assert.Equal(t, StringArray{"a", "b,c"}, tags)
+	var scores Int64Array
+	assert.NoError(t, scores.Scan("{3,4}"))
+	assert.Equal(t, Int64Array{3, 4}, scores)
+	assert.NoError(t, scores.Scan(nil))
+	assert.Nil(t, scores)
+}
+
+func TestStringArray_DialectValue(t *testing.T) {
+	value, err := StringArray{"a", "b"}.DialectValue("postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a","b"}`, value)
+	value, err = StringArray{"a", "b"}.DialectValue("mysql")
+	assert.NoError(t, err)
+	assert.Equal(t, `["a","b"]`, value)
+	value, err = Int64Array{1, 2}.DialectValue("postgres")
+	assert.NoError(t, err)
+	assert.Equal(t, "{1,2}", value)
+	value, err = StringArray(nil).DialectValue("sqlite3")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+}
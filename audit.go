@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+)
+
+// AuditModel records who created and last updated a row, from the actor of the context of the statement, see WithActor
+type AuditModel struct {
+	CreatedBy string `gorm:"size:64;index"`
+	UpdatedBy string `gorm:"size:64;index"`
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying the ID of the user or service performing the writes run with it
+func WithActor(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, actorKey{}, id)
+}
+
+// ActorFromContext returns the actor ctx carries, see WithActor
+func ActorFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(actorKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Register the callbacks filling the audit columns from the actor of the context
+func registerAudit(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("orm:audit", auditCreate)
+	callback.Update().Before("gorm:update").Register("orm:audit", auditUpdate)
+}
+
+// Set CreatedBy and UpdatedBy to the actor, unless given
+func auditCreate(scope *gorm.Scope) {
+	actor, ok := ActorFromContext(scopeContext(scope))
+	if !ok || scope.HasError() {
+		return
+	}
+	for _, name := range []string{"CreatedBy", "UpdatedBy"} {
+		if field, ok := scope.FieldByName(name); ok && field.IsBlank {
+			scope.SetColumn(field, actor)
+		}
+	}
+}
+
+// Set UpdatedBy to the actor, except for UpdateColumn which leaves UpdatedAt alone as well
+func auditUpdate(scope *gorm.Scope) {
+	actor, ok := ActorFromContext(scopeContext(scope))
+	if !ok || scope.HasError() {
+		return
+	}
+	if _, ok := scope.Get("gorm:update_column"); ok {
+		return
+	}
+	if field, ok := scope.FieldByName("UpdatedBy"); ok {
+		scope.SetColumn(field, actor)
+	}
+}
@@ -0,0 +1,43 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditDB struct {
+	ID   int
+	Name string
+	AuditModel
+}
+
+func TestAuditModel(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&auditDB{}).Error)
+
+	alice := db.WithContext(WithActor(context.Background(), "alice"))
+	record := &auditDB{ID: 1, Name: "a"}
+	assert.NoError(t, alice.Create(record).Error)
+	assert.Equal(t, AuditModel{CreatedBy: "alice", UpdatedBy: "alice"}, record.AuditModel)
+
+	bob := WithActor(context.Background(), "bob")
+	assert.NoError(t, db.Transaction(bob, func(tx *TX) error {
+		return tx.Model(record).Update("name", "b").Error
+	}))
+	assert.NoError(t, db.WithContext(context.Background()).Create(&auditDB{ID: 2, Name: "anonymous"}).Error)
+
+	var stored []auditDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, []auditDB{
+		{ID: 1, Name: "b", AuditModel: AuditModel{CreatedBy: "alice", UpdatedBy: "bob"}},
+		{ID: 2, Name: "anonymous"},
+	}, stored)
+
+	_, ok := ActorFromContext(context.Background())
+	assert.False(t, ok)
+}
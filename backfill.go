@@ -0,0 +1,137 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Progress of a backfill, recorded in the schema_backfills table
+type backfillProgress struct {
+	Name      string    `gorm:"column:name;primary_key;size:255"`
+	LastKey   string    `gorm:"column:last_key;size:255"`
+	Rows      int64     `gorm:"column:rows"`
+	Done      bool      `gorm:"column:done"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+const backfillTable = "schema_backfills"
+
+type backfillConfig struct {
+	name     string
+	throttle time.Duration
+	args     []interface{}
+}
+
+type BackfillOpt func(*backfillConfig)
+
+// BackfillNameOpt names the backfill, its progress being recorded under that name.
+// It is the table followed by the condition by default, see Backfill.
+func BackfillNameOpt(name string) BackfillOpt {
+	return func(c *backfillConfig) {
+		c.name = name
+	}
+}
+
+// BackfillThrottleOpt waits d between batches, to leave room for the rest of the load
+func BackfillThrottleOpt(d time.Duration) BackfillOpt {
+	return func(c *backfillConfig) {
+		c.throttle = d
+	}
+}
+
+// BackfillArgsOpt passes args to the placeholders of the condition
+func BackfillArgsOpt(args ...interface{}) BackfillOpt {
+	return func(c *backfillConfig) {
+		c.args = args
+	}
+}
+
+// Backfill calls fn with the rows of the table of model matching where, an empty condition matching every row,
+// batchSize rows at a time in order of primary key. batch is a pointer to a slice of the type of model.
+// Every batch runs in its own transaction, which records the last key reached in the schema_backfills table,
+// so that a backfill interrupted, by a crash or ctx, resumes after the last batch committed when run again.
+// A backfill that completed is not run again under the same name, see BackfillNameOpt.
+// Rows are walked by key, fn may update them, even so that they no longer match.
+func (db *DB) Backfill(ctx context.Context, model interface{}, batchSize int, where string, fn func(tx *TX, batch interface{}) error, opts ...BackfillOpt) error {
+	scope := db.NewScope(model)
+	primary := scope.PrimaryField()
+	if primary == nil || len(scope.PrimaryFields()) > 1 {
+		return fmt.Errorf("orm: backfilling %s needs a single primary key", scope.TableName())
+	}
+	if batchSize <= 0 {
+		return fmt.Errorf("orm: backfill batch size must be positive, got %d", batchSize)
+	}
+	cfg := backfillConfig{name: scope.TableName() + " " + where}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := db.DB.Table(backfillTable).AutoMigrate(&backfillProgress{}).Error; err != nil {
+		return err
+	}
+	progress := backfillProgress{Name: cfg.name}
+	found := db.DB.Table(backfillTable).Where("name = ?", cfg.name).Find(&progress)
+	if found.RecordNotFound() {
+		if err := db.DB.Table(backfillTable).Create(&progress).Error; err != nil {
+			return err
+		}
+	} else if found.Error != nil {
+		return found.Error
+	}
+	if progress.Done {
+		return nil
+	}
+
+	column := scope.Quote(primary.DBName)
+	sliceType := reflect.SliceOf(reflect.TypeOf(model).Elem())
+	for started := false; ; started = true {
+		if started && cfg.throttle > 0 {
+			select {
+			case <-time.After(cfg.throttle):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var count int
+		err := db.Transaction(ctx, func(tx *TX) error {
+			query := tx.DB.Order(column).Limit(batchSize)
+			if progress.LastKey != "" {
+				query = query.Where(column+" > ?", progress.LastKey)
+			}
+			if where != "" {
+				query = query.Where(where, cfg.args...)
+			}
+			batch := reflect.New(sliceType)
+			if err := query.Find(batch.Interface()).Error; err != nil {
+				return err
+			}
+			if count = batch.Elem().Len(); count == 0 {
+				return tx.DB.Table(backfillTable).Where("name = ?", cfg.name).Update("done", true).Error
+			}
+			if err := fn(tx, batch.Interface()); err != nil {
+				return err
+			}
+
+			last := tx.NewScope(batch.Elem().Index(count - 1).Addr().Interface()).PrimaryKeyValue()
+			return tx.DB.Table(backfillTable).Where("name = ?", cfg.name).Updates(map[string]interface{}{
+				"last_key": fmt.Sprint(last),
+				"rows":     progress.Rows + int64(count),
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if err := db.DB.Table(backfillTable).Where("name = ?", cfg.name).Find(&progress).Error; err != nil {
+			return err
+		}
+	}
+}
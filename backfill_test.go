@@ -0,0 +1,71 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type backfillDB struct {
+	ID     int
+	Legacy bool
+	Name   string
+	Slug   string
+}
+
+func TestDB_Backfill(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&backfillDB{}).Error)
+	records := make([]backfillDB, 25)
+	for i := range records {
+		records[i] = backfillDB{ID: i + 1, Name: "n", Legacy: i%5 != 0}
+	}
+	assert.NoError(t, db.BulkCreate(records))
+
+	ctx := context.Background()
+	var firsts []int
+	crash := errors.New("crash")
+	fill := func(tx *TX, batch interface{}) error {
+		rows := *batch.(*[]backfillDB)
+		firsts = append(firsts, rows[0].ID)
+		if len(firsts) == 2 {
+			return crash
+		}
+		for _, row := range rows {
+			if err := tx.Model(&row).UpdateColumn("slug", "s").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := db.Backfill(ctx, &backfillDB{}, 8, "legacy = ?", fill, BackfillArgsOpt(true))
+	assert.Equal(t, crash, err)
+	assert.NoError(t, db.Backfill(ctx, &backfillDB{}, 8, "legacy = ?", fill, BackfillArgsOpt(true)))
+	// The second batch ran again after the crash, the first did not
+	assert.Equal(t, []int{2, 12, 12, 22}, firsts)
+
+	var count int
+	assert.NoError(t, db.Model(&backfillDB{}).Where("slug = ?", "s").Count(&count).Error)
+	assert.Equal(t, 20, count)
+	var progress backfillProgress
+	assert.NoError(t, db.DB.Table(backfillTable).First(&progress).Error)
+	assert.Equal(t, backfillProgress{Name: "backfill_db legacy = ?", LastKey: "25", Rows: 20, Done: true, UpdatedAt: progress.UpdatedAt}, progress)
+
+	// Done under that name
+	assert.NoError(t, db.Backfill(ctx, &backfillDB{}, 8, "legacy = ?", fill, BackfillArgsOpt(true)))
+	assert.Len(t, firsts, 4)
+
+	var batches int
+	err = db.Backfill(ctx, &backfillDB{}, 10, "", func(tx *TX, batch interface{}) error {
+		batches++
+		return nil
+	}, BackfillNameOpt("all"), BackfillThrottleOpt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, batches)
+	assert.Error(t, db.Backfill(ctx, &backfillDB{}, 0, "", nil))
+}
@@ -0,0 +1,167 @@
+package bulk_insert
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Insert the has-one and has-many records of the inserted parent elems, then the join rows of many2many associations
+func (b *Builder) insertAssociations(ctx context.Context, db *gorm.DB, elems []reflect.Value) error {
+	if len(elems) == 0 {
+		return nil
+	}
+
+	// Associated records are inserted into their own tables with every column
+	child := *b
+	child.table = ""
+	child.excludeColumns, child.includeColumns = nil, nil
+	child.upsert, child.conflictColumns, child.updateColumns, child.ignoreColumns = false, nil, nil, nil
+	child.dedupeBy, child.dedupeLastWins = nil, false
+	child.transform, child.nullBlankColumns = nil, nil
+	child.progress = nil
+
+	for _, field := range db.NewScope(elems[0].Interface()).GetModelStruct().StructFields {
+		rel := field.Relationship
+		if rel == nil || field.IsIgnored {
+			continue
+		}
+
+		var err error
+		switch rel.Kind {
+		case "has_one", "has_many":
+			err = child.insertChildren(ctx, db, elems, field)
+		case "many_to_many":
+			err = child.insertJoinRows(ctx, db, elems, field)
+		}
+		if err != nil {
+			return fmt.Errorf("association %s: %s", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func (b *Builder) insertChildren(ctx context.Context, db *gorm.DB, parents []reflect.Value, field *gorm.StructField) error {
+	rel := field.Relationship
+	childType := field.Struct.Type
+	for childType.Kind() == reflect.Slice || childType.Kind() == reflect.Ptr {
+		childType = childType.Elem()
+	}
+
+	// Children are collected as pointers, so that written back keys reach the parents
+	children := reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(childType)), 0, len(parents))
+	for _, parent := range parents {
+		parentScope := db.NewScope(parent.Addr().Interface())
+		values, err := foreignKeyValues(parentScope, rel.AssociationForeignFieldNames)
+		if err != nil {
+			return err
+		}
+
+		assocField, _ := parentScope.FieldByName(field.Name)
+		for _, child := range associated(assocField.Field) {
+			childScope := db.NewScope(child.Interface())
+			for i, name := range rel.ForeignFieldNames {
+				if err := childScope.SetColumn(name, values[i]); err != nil {
+					return err
+				}
+			}
+			if rel.PolymorphicType != "" {
+				if err := childScope.SetColumn(rel.PolymorphicType, rel.PolymorphicValue); err != nil {
+					return err
+				}
+			}
+			children = reflect.Append(children, child)
+		}
+	}
+
+	if children.Len() == 0 {
+		return nil
+	}
+	_, err := b.run(ctx, db, children.Interface())
+	return err
+}
+
+func (b *Builder) insertJoinRows(ctx context.Context, db *gorm.DB, parents []reflect.Value, field *gorm.StructField) error {
+	handler := field.Relationship.JoinTableHandler
+	if handler == nil {
+		return nil
+	}
+
+	var rows []map[string]interface{}
+	for _, parent := range parents {
+		parentScope := db.NewScope(parent.Addr().Interface())
+		sourceKeys := handler.SourceForeignKeys()
+		sourceValues, err := foreignKeyValues(parentScope, joinAssociationNames(sourceKeys))
+		if err != nil {
+			return err
+		}
+
+		assocField, _ := parentScope.FieldByName(field.Name)
+		for _, child := range associated(assocField.Field) {
+			destinationKeys := handler.DestinationForeignKeys()
+			destinationValues, err := foreignKeyValues(db.NewScope(child.Interface()), joinAssociationNames(destinationKeys))
+			if err != nil {
+				return err
+			}
+
+			row := map[string]interface{}{}
+			for i, key := range sourceKeys {
+				row[key.DBName] = sourceValues[i]
+			}
+			for i, key := range destinationKeys {
+				row[key.DBName] = destinationValues[i]
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+	join := &Builder{chunkSize: b.chunkSize, ignoreConflicts: b.ignoreConflicts, retryAttempts: b.retryAttempts, retryBackoff: b.retryBackoff}
+	return join.execMaps(ctx, db, handler.Table(db), rows)
+}
+
+// Values of the named key fields, which must not be blank
+func foreignKeyValues(scope *gorm.Scope, names []string) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		field, ok := scope.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no field %s", scope.GetModelStruct().ModelType, name)
+		}
+		if field.IsBlank {
+			return nil, fmt.Errorf("%s of %s is blank, its primary key must be known", name, scope.GetModelStruct().ModelType)
+		}
+		values = append(values, field.Field.Interface())
+	}
+	return values, nil
+}
+
+func joinAssociationNames(keys []gorm.JoinTableForeignKey) []string {
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		names = append(names, key.AssociationDBName)
+	}
+	return names
+}
+
+// Pointers to the records held by an association field, a struct, a pointer or a slice of them
+func associated(value reflect.Value) []reflect.Value {
+	var records []reflect.Value
+	switch value.Kind() {
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			records = append(records, associated(value.Index(i))...)
+		}
+	case reflect.Ptr:
+		if !value.IsNil() {
+			records = append(records, value)
+		}
+	case reflect.Struct:
+		records = append(records, value.Addr())
+	}
+	return records
+}
@@ -0,0 +1,96 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type assocChild struct {
+	ID       int
+	ParentID int
+	Name     string
+}
+
+type assocTag struct {
+	ID   int
+	Name string
+}
+
+type assocParent struct {
+	ID       int
+	Name     string
+	Children []assocChild `gorm:"foreignkey:ParentID"`
+	Tags     []*assocTag  `gorm:"many2many:assoc_parent_tags"`
+}
+
+func TestBuilder_Exec_associations(t *testing.T) {
+	db := openSQLite(t, &assocParent{}, &assocChild{}, &assocTag{})
+	defer db.Close()
+
+	tags := []*assocTag{{Name: "x"}, {Name: "y"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).Exec(db, tags))
+
+	parents := []assocParent{
+		{Name: "a", Children: []assocChild{{Name: "a1"}, {Name: "a2"}}, Tags: tags},
+		{Name: "b", Children: []assocChild{{Name: "b1"}}, Tags: tags[1:]},
+	}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true), AssociationsOpt(true), AtomicOpt(true)).Exec(db, parents))
+	assert.NotZero(t, parents[0].Children[1].ID)
+	assert.Equal(t, parents[1].ID, parents[1].Children[0].ParentID)
+
+	var stored assocParent
+	assert.NoError(t, db.Preload("Children").Preload("Tags").Where("name = ?", "a").Find(&stored).Error)
+	assert.Len(t, stored.Children, 2)
+	assert.Len(t, stored.Tags, 2)
+
+	var joins int
+	assert.NoError(t, db.Table("assoc_parent_tags").Count(&joins).Error)
+	assert.Equal(t, 3, joins)
+
+	// without primary keys children can not be linked
+	err := NewBuilder(AssociationsOpt(true)).Exec(db, []assocParent{{Name: "c", Children: []assocChild{{Name: "c1"}}}})
+	assert.Error(t, err)
+}
+
+func TestBuilder_Exec_associationsDedupe(t *testing.T) {
+	db := openSQLite(t, &assocParent{}, &assocChild{}, &assocTag{})
+	defer db.Close()
+
+	// Children are not deduped on the columns of their parents
+	parents := []assocParent{
+		{ID: 1, Name: "a", Children: []assocChild{{Name: "x"}}},
+		{ID: 2, Name: "b", Children: []assocChild{{Name: "x"}}},
+		{ID: 3, Name: "a", Children: []assocChild{{Name: "y"}}},
+	}
+	assert.NoError(t, NewBuilder(DedupeByOpt("name"), AssociationsOpt(true)).Exec(db, parents))
+
+	var names []string
+	assert.NoError(t, db.Model(&assocChild{}).Order("parent_id").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"x", "x"}, names)
+}
+
+func TestBuilder_Exec_associationsTransform(t *testing.T) {
+	db := openSQLite(t, &assocParent{}, &assocChild{}, &assocTag{})
+	defer db.Close()
+
+	// The transform of the parents is not applied to their children
+	var transformed []string
+	transform := func(i int, attrs map[string]interface{}) error {
+		transformed = append(transformed, attrs["name"].(string))
+		attrs["name"] = "parent " + attrs["name"].(string)
+		return nil
+	}
+	parents := []assocParent{
+		{ID: 1, Name: "a", Children: []assocChild{{Name: "a1"}, {Name: "a2"}}},
+		{ID: 2, Name: "b", Children: []assocChild{{Name: "b1"}}},
+	}
+	assert.NoError(t, NewBuilder(TransformOpt(transform), NullBlankColumnsOpt([]string{"name"}), AssociationsOpt(true)).Exec(db, parents))
+	for _, name := range transformed {
+		assert.Contains(t, []string{"a", "b"}, name)
+	}
+
+	var names []string
+	assert.NoError(t, db.Model(&assocChild{}).Order("name").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"a1", "a2", "b1"}, names)
+}
@@ -0,0 +1,112 @@
+package bulk_insert
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Statement is a generated SQL statement with its variables, as passed to the driver
+type Statement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// BuildSQL returns the INSERT statements Exec would run for objects, one per chunk, without executing them.
+// Duplicates are dropped with DedupeByOpt and objects routed to their tables with PartitionOpt as Exec does.
+// objects are left untouched, primary keys generated for them only appear in the statements.
+// COPY and LOAD DATA do not run SQL statements with values and are rejected.
+func (b *Builder) BuildSQL(db *gorm.DB, objects interface{}) ([]Statement, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	dialect := db.Dialect().GetName()
+	if (b.copy && dialect == "postgres") || (b.loadData && dialect == "mysql") {
+		return nil, errors.New("copy and load data can not be built as SQL statements")
+	}
+
+	value := reflect.ValueOf(objects)
+	if value.Kind() != reflect.Slice {
+		return nil, errors.New("objects must be a slice")
+	}
+	elems, err := indirectElems(value)
+	if err != nil {
+		return nil, err
+	}
+	elems = copyElems(elems)
+	if b.partition == nil {
+		return b.buildSQL(db, elems)
+	}
+
+	tables, partitions, _, err := b.partitionElems(elems)
+	if err != nil {
+		return nil, err
+	}
+	var statements []Statement
+	for _, table := range tables {
+		partition := *b
+		partition.partition = nil
+		partition.table = table
+		partElems, err := indirectElems(partitions[table])
+		if err != nil {
+			return nil, err
+		}
+		partStatements, err := partition.buildSQL(db, partElems)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, partStatements...)
+	}
+	return statements, nil
+}
+
+// Statements of elems, copies of the objects, into the table of b
+func (b *Builder) buildSQL(db *gorm.DB, elems []reflect.Value) ([]Statement, error) {
+	db = b.target(db)
+	elems, _, err := b.dedupeElems(elems)
+	if err != nil {
+		return nil, err
+	}
+
+	objectInterfaces := make([]interface{}, len(elems))
+	for i, elem := range assignIDs(db, elems) {
+		objectInterfaces[i] = elem.Interface()
+	}
+	if len(objectInterfaces) == 0 {
+		return nil, nil
+	}
+
+	attrs, err := b.row(db, 0, objectInterfaces[0])
+	if err != nil {
+		return nil, err
+	}
+
+	stmts := newStatements()
+	var statements []Statement
+	offset := 0
+	for _, chunk := range splitObjects(objectInterfaces, b.safeChunkSize(db, len(attrs), 0)) {
+		rows, err := b.extractRows(db, offset, chunk)
+		offset += len(chunk)
+		if err != nil {
+			return nil, err
+		}
+		scope := db.NewScope(chunk[0])
+		if err := b.buildInsert(stmts, scope, rows); err != nil {
+			return nil, err
+		}
+		statements = append(statements, Statement{SQL: rebind(db.Dialect(), scope.SQL), Vars: scope.SQLVars})
+	}
+	return statements, nil
+}
+
+// Copy elements into new addressable values, so they can be modified without affecting the caller
+func copyElems(elems []reflect.Value) []reflect.Value {
+	copied := make([]reflect.Value, len(elems))
+	for i, elem := range elems {
+		ptr := reflect.New(elem.Type())
+		ptr.Elem().Set(elem)
+		copied[i] = ptr.Elem()
+	}
+	return copied
+}
@@ -1,23 +1,62 @@
 package bulk_insert
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jinzhu/gorm"
 )
 
 type Builder struct {
-	chunkSize      int
-	replace        bool
-	excludeColumns []string
+	chunkSize        int
+	replace          bool
+	excludeColumns   []string
+	upsert           bool
+	conflictColumns  []string
+	updateColumns    []string
+	writeBack        bool
+	copy             bool
+	loadData         bool
+	archive          bool
+	atomic           bool
+	concurrency      int
+	stopOnError      bool
+	progress         func(inserted, total int)
+	retryAttempts    int
+	retryBackoff     time.Duration
+	runHooks         bool
+	includeColumns   []string
+	ignoreColumns    []string
+	ignoreConflicts  bool
+	bisect           bool
+	continueOnError  bool
+	table            string
+	associations     bool
+	nullBlankColumns []string
+	dedupeBy         []string
+	dedupeLastWins   bool
+	transform        func(i int, attrs map[string]interface{}) error
+	partition        func(obj interface{}) string
+}
+
+// Result reports the outcome of a bulk insert
+type Result struct {
+	// RowsAffected as reported by the database. Rows skipped by IgnoreConflictsOpt are not counted,
+	// MySQL counts rows updated by an upsert twice.
+	RowsAffected int64
+	// Objects dropped by DedupeByOpt
+	Duplicates int
 }
 
 type BuilderOpt func(*Builder)
 
+// ChunkSizeOpt sets the maximum number of records per statement.
+// Chunks are made smaller when needed to stay within the placeholder limit of the dialect.
 func ChunkSizeOpt(chunkSize int) BuilderOpt {
 	return func(c *Builder) {
 		c.chunkSize = chunkSize
@@ -30,12 +69,208 @@ func ReplaceOpt(replace bool) BuilderOpt {
 	}
 }
 
+// ExcludeColumnsOpt leaves columns out of the insert, given as struct field names or column names.
+// Column names are also matched after conversion by gorm's naming strategy, naming no field is an error.
 func ExcludeColumnsOpt(excludeColumns []string) BuilderOpt {
 	return func(c *Builder) {
 		c.excludeColumns = excludeColumns
 	}
 }
 
+// UpsertOpt turns the insert into an upsert.
+// [conflictColumns] Columns of the unique constraint to check, the primary key by default. Ignored by MySQL.
+// [updateColumns]   Columns to overwrite on conflict. All inserted columns except conflictColumns are used if empty.
+func UpsertOpt(conflictColumns []string, updateColumns []string) BuilderOpt {
+	return func(c *Builder) {
+		c.upsert = true
+		c.conflictColumns = conflictColumns
+		c.updateColumns = updateColumns
+	}
+}
+
+// WriteBackOpt writes generated primary keys and CreatedAt/UpdatedAt back into the slice elements.
+// Postgres reads the keys with RETURNING, MySQL and SQLite derive them from LastInsertId,
+// which requires consecutive auto-increment values (innodb_autoinc_lock_mode 0 or 1 on MySQL).
+// It can not be combined with IgnoreConflictsOpt or UpsertOpt.
+func WriteBackOpt(writeBack bool) BuilderOpt {
+	return func(c *Builder) {
+		c.writeBack = writeBack
+	}
+}
+
+// CopyOpt streams the records with COPY FROM STDIN when the dialect is Postgres.
+// All records are copied within a single transaction, other dialects keep using INSERT.
+func CopyOpt(copy bool) BuilderOpt {
+	return func(c *Builder) {
+		c.copy = copy
+	}
+}
+
+// LoadDataOpt loads every chunk as an in-memory CSV with LOAD DATA LOCAL INFILE when the dialect is MySQL.
+// The server has to enable local_infile, other dialects keep using INSERT.
+func LoadDataOpt(loadData bool) BuilderOpt {
+	return func(c *Builder) {
+		c.loadData = loadData
+	}
+}
+
+// ArchiveOpt makes ExecDelete copy the rows into the "<table>_deleted" archive table, stamping its At column, before deleting them
+func ArchiveOpt(archive bool) BuilderOpt {
+	return func(c *Builder) {
+		c.archive = archive
+	}
+}
+
+// AtomicOpt runs all chunks in one transaction, or in the transaction db already is, so a failing chunk rolls back the others
+func AtomicOpt(atomic bool) BuilderOpt {
+	return func(c *Builder) {
+		c.atomic = atomic
+	}
+}
+
+// ConcurrencyOpt executes chunks with the given number of workers.
+// Errors of all failed chunks are returned as gorm.Errors, unless stopOnError stops dispatching chunks after the first failure.
+func ConcurrencyOpt(concurrency int, stopOnError bool) BuilderOpt {
+	return func(c *Builder) {
+		c.concurrency = concurrency
+		c.stopOnError = stopOnError
+	}
+}
+
+// ProgressOpt registers a function called after each chunk is inserted with the number of records inserted so far.
+// Calls are serialized even when chunks are executed concurrently.
+func ProgressOpt(progress func(inserted, total int)) BuilderOpt {
+	return func(c *Builder) {
+		c.progress = progress
+	}
+}
+
+// RetryOpt retries chunks failing with a deadlock, lock wait timeout or serialization failure.
+// [attempts] Maximum number of executions per chunk including the first one.
+// [backoff]  Delay before the first retry, doubled for every following one.
+// Chunks are not retried inside a transaction since the database already aborted it.
+func RetryOpt(attempts int, backoff time.Duration) BuilderOpt {
+	return func(c *Builder) {
+		c.retryAttempts = attempts
+		c.retryBackoff = backoff
+	}
+}
+
+// RunHooksOpt calls the BeforeSave/BeforeCreate methods of every record before inserting
+// and AfterCreate/AfterSave once its chunk is inserted, like gorm does for Create.
+// An error returned by a hook aborts the insert.
+func RunHooksOpt(runHooks bool) BuilderOpt {
+	return func(c *Builder) {
+		c.runHooks = runHooks
+	}
+}
+
+// IncludeColumnsOpt restricts the insert to the given struct field or column names.
+// Every name has to match a field of the model.
+func IncludeColumnsOpt(includeColumns []string) BuilderOpt {
+	return func(c *Builder) {
+		c.includeColumns = includeColumns
+	}
+}
+
+// ConflictUpdateColumnsOpt turns the insert into an upsert overwriting exactly the given columns on conflict
+func ConflictUpdateColumnsOpt(updateColumns []string) BuilderOpt {
+	return func(c *Builder) {
+		c.upsert = true
+		c.updateColumns = updateColumns
+	}
+}
+
+// ConflictIgnoreColumnsOpt turns the insert into an upsert which preserves the given columns, e.g. created_at, on conflict
+func ConflictIgnoreColumnsOpt(ignoreColumns []string) BuilderOpt {
+	return func(c *Builder) {
+		c.upsert = true
+		c.ignoreColumns = ignoreColumns
+	}
+}
+
+// IgnoreConflictsOpt skips records violating a unique constraint with INSERT IGNORE on MySQL,
+// INSERT OR IGNORE on SQLite and ON CONFLICT DO NOTHING on Postgres. Use Run to learn how many rows were inserted.
+func IgnoreConflictsOpt(ignoreConflicts bool) BuilderOpt {
+	return func(c *Builder) {
+		c.ignoreConflicts = ignoreConflicts
+	}
+}
+
+// BisectOpt narrows a failed chunk down to the object at fault by inserting halves of it in
+// transactions which are rolled back, see BulkError. It is skipped inside a transaction, AtomicOpt included.
+func BisectOpt(bisect bool) BuilderOpt {
+	return func(c *Builder) {
+		c.bisect = bisect
+	}
+}
+
+// ContinueOnErrorOpt keeps inserting the remaining chunks after a chunk failed.
+// Every failed chunk is then reported in BulkErrors, so that only their objects need to be retried.
+func ContinueOnErrorOpt(continueOnError bool) BuilderOpt {
+	return func(c *Builder) {
+		c.continueOnError = continueOnError
+	}
+}
+
+// TableOpt targets table instead of the table name derived from the objects,
+// e.g. for partitions sharing a struct. It applies to updates and deletes as well.
+func TableOpt(table string) BuilderOpt {
+	return func(c *Builder) {
+		c.table = table
+	}
+}
+
+// AssociationsOpt also inserts has-one and has-many records held by the objects, and join rows of many2many ones.
+// Foreign keys are taken from the primary keys of the objects, which must be known after insert:
+// given, generated xid or written back with WriteBackOpt. Belongs-to associations are not inserted.
+func AssociationsOpt(associations bool) BuilderOpt {
+	return func(c *Builder) {
+		c.associations = associations
+	}
+}
+
+// NullBlankColumnsOpt inserts NULL instead of the zero value for blank fields of nullBlankColumns,
+// given as field or column names. Fields tagged with gorm:"null_blank" always are.
+func NullBlankColumnsOpt(nullBlankColumns []string) BuilderOpt {
+	return func(c *Builder) {
+		c.nullBlankColumns = nullBlankColumns
+	}
+}
+
+// DedupeByOpt drops the objects repeating the values of columns, given as field or column names,
+// before any SQL is generated. The first occurrence is kept unless DedupeLastWinsOpt is set.
+func DedupeByOpt(columns ...string) BuilderOpt {
+	return func(c *Builder) {
+		c.dedupeBy = columns
+	}
+}
+
+// DedupeLastWinsOpt keeps the last of duplicate objects instead of the first one
+func DedupeLastWinsOpt(lastWins bool) BuilderOpt {
+	return func(c *Builder) {
+		c.dedupeLastWins = lastWins
+	}
+}
+
+// TransformOpt calls transform with the index of every object and its extracted attributes before SQL is generated.
+// transform may change, add or delete attributes, every row must end up with the same columns.
+// Returning an error aborts the insert. It may be called more than once for an object.
+func TransformOpt(transform func(i int, attrs map[string]interface{}) error) BuilderOpt {
+	return func(c *Builder) {
+		c.transform = transform
+	}
+}
+
+// PartitionOpt routes every object to the table returned by partition, e.g. a monthly or hash partition.
+// The objects of each table are inserted with their own chunks, partitions being processed one after the other.
+// partition is given a pointer to the object.
+func PartitionOpt(partition func(obj interface{}) string) BuilderOpt {
+	return func(c *Builder) {
+		c.partition = partition
+	}
+}
+
 func NewBuilder(opts ...BuilderOpt) *Builder {
 	b := &Builder{
 		chunkSize: 2000,
@@ -47,125 +282,486 @@ func NewBuilder(opts ...BuilderOpt) *Builder {
 }
 
 func (b *Builder) Exec(db *gorm.DB, objects interface{}) error {
-	return BulkInsert(db, objects, b.chunkSize, b.replace, b.excludeColumns...)
+	return b.ExecContext(context.Background(), db, objects)
 }
 
-// Insert multiple records at once
-// [objects]        Must be a slice of struct
-// [chunkSize]      Number of records to insert at once.
-//                  Embedding a large number of variables at once will raise an error beyond the limit of prepared statement.
-//                  Larger size will normally lead the better performance, but 2000 to 3000 is reasonable.
-// [excludeColumns] Columns you want to exclude from insert. You can omit if there is no column you want to exclude.
-func BulkInsert(db *gorm.DB, objects interface{}, chunkSize int, replace bool, excludeColumns ...string) error {
+// ExecContext is like Exec but stops before the next chunk once ctx is done,
+// and passes ctx to the driver when it can be cancelled.
+func (b *Builder) ExecContext(ctx context.Context, db *gorm.DB, objects interface{}) error {
+	_, err := b.Run(ctx, db, objects)
+	return err
+}
+
+// Run inserts objects like ExecContext and reports the number of affected rows
+func (b *Builder) Run(ctx context.Context, db *gorm.DB, objects interface{}) (*Result, error) {
+	if err := b.validate(); err != nil {
+		return nil, err
+	}
+	if b.partition != nil {
+		return b.runPartitions(ctx, db, objects)
+	}
+	if b.associations && b.atomic {
+		// Parents and associated records are committed together
+		var result *Result
+		err := inTransaction(ctx, db, func(tx *gorm.DB) (err error) {
+			result, err = b.run(ctx, tx, objects)
+			return err
+		})
+		return result, err
+	}
+	return b.run(ctx, db, objects)
+}
+
+func (b *Builder) run(ctx context.Context, db *gorm.DB, objects interface{}) (*Result, error) {
+	base := db
+	db = b.target(db)
+
 	value := reflect.ValueOf(objects)
 	if value.Kind() != reflect.Slice {
-		return errors.New("objects must be a slice")
+		return nil, errors.New("objects must be a slice")
 	}
-	objectInterfaces := make([]interface{}, value.Len())
-	for i := 0; i < value.Len(); i++ {
-		objectInterfaces[i] = value.Index(i).Interface()
+	elems, err := indirectElems(value)
+	if err != nil {
+		return nil, err
+	}
+	if b.runHooks {
+		elems = addressable(elems)
+		if err := callHooks(db, elems, "BeforeSave", "BeforeCreate"); err != nil {
+			return nil, err
+		}
+	}
+	elems, duplicates, err := b.dedupeElems(elems)
+	if err != nil {
+		return nil, err
+	}
+	if b.writeBack {
+		setTimestamps(db, elems)
+	}
+	elems = assignIDs(db, elems)
+
+	objectInterfaces := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		objectInterfaces[i] = elem.Interface()
+	}
+
+	if b.copy && db.Dialect().GetName() == "postgres" {
+		rowsAffected, err := b.copyIn(ctx, db, objectInterfaces)
+		if err != nil {
+			return nil, err
+		}
+		if b.runHooks {
+			if err := callHooks(db, elems, "AfterCreate", "AfterSave"); err != nil {
+				return nil, err
+			}
+		}
+		if b.progress != nil {
+			b.progress(len(objectInterfaces), len(objectInterfaces))
+		}
+		if b.associations {
+			if err := b.insertAssociations(ctx, base, elems); err != nil {
+				return nil, err
+			}
+		}
+		return &Result{RowsAffected: rowsAffected, Duplicates: duplicates}, nil
+	}
+
+	insertObjSet := b.insertObjSet
+	chunkSize := b.chunkSize
+	if b.loadData && db.Dialect().GetName() == "mysql" {
+		insertObjSet = b.loadObjSet
+	} else if db.Dialect().GetName() == "clickhouse" {
+		insertObjSet = b.batchObjSet
+	} else if len(objectInterfaces) > 0 {
+		attrs, err := b.row(db, 0, objectInterfaces[0])
+		if err != nil {
+			return nil, err
+		}
+		chunkSize = b.safeChunkSize(db, len(attrs), 0)
 	}
 
 	// Split records with specified size not to exceed Database parameter limit
-	for _, objSet := range splitObjects(objectInterfaces, chunkSize) {
-		if err := insertObjSet(db, objSet, replace, excludeColumns...); err != nil {
-			return err
+	chunks := splitObjects(objectInterfaces, chunkSize)
+	sizes := make([]int, len(chunks))
+	for i := range chunks {
+		sizes[i] = len(chunks[i])
+	}
+
+	stmts := newStatements()
+	defer stmts.close()
+
+	insert := func(db *gorm.DB, offset, count int) (int64, error) {
+		chunkElems := elems[offset : offset+count]
+		rowsAffected, err := insertObjSet(ctx, db, stmts, offset, objectInterfaces[offset:offset+count], chunkElems)
+		if err != nil {
+			return 0, err
 		}
+		if b.runHooks {
+			return rowsAffected, callHooks(db, chunkElems, "AfterCreate", "AfterSave")
+		}
+		return rowsAffected, nil
+	}
+	// Bisecting only needs the plain INSERT, without write back nor hooks
+	probe := func(db *gorm.DB, offset, count int) error {
+		_, err := b.insertObjSet(ctx, db, stmts, offset, objectInterfaces[offset:offset+count], nil)
+		return err
+	}
+
+	rowsAffected, err := b.execChunks(ctx, db, sizes, insert, probe)
+	if err != nil {
+		return nil, err
+	}
+	if b.associations {
+		if err := b.insertAssociations(ctx, base, elems); err != nil {
+			return nil, err
+		}
+	}
+	return &Result{RowsAffected: rowsAffected, Duplicates: duplicates}, nil
+}
+
+// Scope db to the table given with TableOpt, scopes created from it use that table
+func (b *Builder) target(db *gorm.DB) *gorm.DB {
+	if b.table == "" {
+		return db
+	}
+	return db.Table(b.table)
+}
+
+// Reject combinations of options which contradict each other
+func (b *Builder) validate() error {
+	if b.replace && b.upsert {
+		return errors.New("replace and upsert can not be used together")
+	}
+	if b.ignoreConflicts && (b.replace || b.upsert) {
+		return errors.New("ignore conflicts can not be combined with replace or upsert")
+	}
+	// Skipped or updated rows get no generated key, those written back would be shifted
+	if b.writeBack && (b.ignoreConflicts || b.upsert) {
+		return errors.New("write back can not be combined with ignore conflicts or upsert")
+	}
+	if b.atomic && b.concurrency > 1 {
+		return errors.New("atomic and concurrency can not be used together")
+	}
+	if b.atomic && b.continueOnError {
+		return errors.New("atomic and continue on error can not be used together")
 	}
 	return nil
 }
 
-func insertObjSet(db *gorm.DB, objects []interface{}, replace bool, excludeColumns ...string) error {
-	if len(objects) == 0 {
-		return nil
+// Run insert for every chunk honoring atomicity, concurrency, retries and progress reporting.
+// insert and probe are given the range of objects of a chunk, failures are reported as *BulkError
+// and bisected with probe when BisectOpt is set.
+func (b *Builder) execChunks(ctx context.Context, db *gorm.DB, sizes []int, insert func(db *gorm.DB, offset, count int) (int64, error), probe func(db *gorm.DB, offset, count int) error) (int64, error) {
+	total := 0
+	offsets := make([]int, len(sizes))
+	for i, size := range sizes {
+		offsets[i] = total
+		total += size
 	}
 
-	firstAttrs, err := extractMapValue(objects[0], excludeColumns)
-	if err != nil {
-		return err
+	var mu sync.Mutex
+	inserted := 0
+	var rowsAffected int64
+	run := func(db *gorm.DB) error {
+		return b.runChunks(ctx, len(sizes), func(i int) error {
+			var chunkRows int64
+			err := b.retry(ctx, db, func() (err error) {
+				chunkRows, err = insert(db, offsets[i], sizes[i])
+				return err
+			})
+			if err != nil {
+				bulkErr := &BulkError{Chunk: i, Offset: offsets[i], Count: sizes[i], Row: -1, Err: err}
+				if b.bisect && probe != nil {
+					bulkErr.Row = b.locateFailure(ctx, db, offsets[i], sizes[i], probe)
+				}
+				return bulkErr
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			rowsAffected += chunkRows
+			if b.progress != nil {
+				inserted += sizes[i]
+				b.progress(inserted, total)
+			}
+			return nil
+		})
 	}
 
-	attrSize := len(firstAttrs)
+	var err error
+	if b.atomic {
+		err = inTransaction(ctx, db, run)
+	} else {
+		err = run(db)
+	}
+	if b.continueOnError {
+		err = collectBulkErrors(err)
+	}
+	return rowsAffected, err
+}
 
-	// Scope to eventually run SQL
-	mainScope := db.NewScope(objects[0])
-	// Store placeholders for embedding variables
-	placeholders := make([]string, 0, attrSize)
+// Insert multiple records at once
+// [objects]        Must be a slice of struct
+// [chunkSize]      Number of records to insert at once.
+//                  Embedding a large number of variables at once will raise an error beyond the limit of prepared statement.
+//                  Larger size will normally lead the better performance, but 2000 to 3000 is reasonable.
+// [excludeColumns] Struct field or column names you want to exclude from insert. You can omit if there is no column you want to exclude.
+func BulkInsert(db *gorm.DB, objects interface{}, chunkSize int, replace bool, excludeColumns ...string) error {
+	return BulkInsertContext(context.Background(), db, objects, chunkSize, replace, excludeColumns...)
+}
 
-	// Replace with database column name
-	dbColumns := make([]string, 0, attrSize)
-	for _, key := range sortedKeys(firstAttrs) {
-		dbColumns = append(dbColumns, mainScope.Quote(gorm.ToColumnName(key)))
+// BulkInsertContext is like BulkInsert but aborts remaining chunks once ctx is done
+func BulkInsertContext(ctx context.Context, db *gorm.DB, objects interface{}, chunkSize int, replace bool, excludeColumns ...string) error {
+	return NewBuilder(ChunkSizeOpt(chunkSize), ReplaceOpt(replace), ExcludeColumnsOpt(excludeColumns)).ExecContext(ctx, db, objects)
+}
+
+func (b *Builder) insertObjSet(ctx context.Context, db *gorm.DB, stmts *statements, offset int, objects []interface{}, elems []reflect.Value) (int64, error) {
+	if len(objects) == 0 {
+		return 0, nil
+	}
+
+	rows, err := b.extractRows(db, offset, objects)
+	if err != nil {
+		return 0, err
 	}
 
-	for _, obj := range objects {
-		objAttrs, err := extractMapValue(obj, excludeColumns)
+	// Scope to eventually run SQL
+	return b.insertRows(ctx, db, stmts, db.NewScope(objects[0]), rows, elems)
+}
+
+// Obtain the attributes of every object, offset being the index of the first one in the payload
+func (b *Builder) extractRows(db *gorm.DB, offset int, objects []interface{}) ([]map[string]interface{}, error) {
+	rows := make([]map[string]interface{}, 0, len(objects))
+	for i, obj := range objects {
+		objAttrs, err := b.row(db, offset+i, obj)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		rows = append(rows, objAttrs)
+	}
+	return rows, nil
+}
+
+// Generate and run a single INSERT of rows into the table of mainScope
+func (b *Builder) insertRows(ctx context.Context, db *gorm.DB, stmts *statements, mainScope *gorm.Scope, rows []map[string]interface{}, elems []reflect.Value) (int64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if err := b.buildInsert(stmts, mainScope, rows); err != nil {
+		return 0, err
+	}
+
+	if b.writeBack && elems != nil {
+		return writeBackIDs(ctx, db, mainScope, rows[0], elems)
+	}
+	return stmts.exec(ctx, db, mainScope.SQL, mainScope.SQLVars)
+}
+
+// Set the INSERT statement of rows and its variables on mainScope
+func (b *Builder) buildInsert(stmts *statements, mainScope *gorm.Scope, rows []map[string]interface{}) error {
+	keys := sortedKeys(rows[0])
+	attrSize := len(keys)
 
+	// Store placeholders for embedding variables, default expressions are written inline
+	placeholders := make([]string, 0, len(rows))
+	inline := false
+	for _, row := range rows {
 		// If object sizes are different, SQL statement loses consistency
-		if len(objAttrs) != attrSize {
+		if len(row) != attrSize {
 			return errors.New("attribute sizes are inconsistent")
 		}
 
-		scope := db.NewScope(obj)
-
 		// Append variables
 		variables := make([]string, 0, attrSize)
-		for _, key := range sortedKeys(objAttrs) {
-			scope.AddToVars(objAttrs[key])
+		for _, key := range keys {
+			value, ok := row[key]
+			if !ok {
+				return errors.New("attribute keys are inconsistent")
+			}
+			if expr, ok := value.(defaultExpr); ok {
+				variables = append(variables, string(expr))
+				inline = true
+				continue
+			}
+			mainScope.AddToVars(value)
 			variables = append(variables, "?")
 		}
+		placeholders = append(placeholders, "("+strings.Join(variables, ", ")+")")
+	}
 
-		valueQuery := "(" + strings.Join(variables, ", ") + ")"
-		placeholders = append(placeholders, valueQuery)
+	if inline {
+		query, err := b.insertStatement(mainScope, keys, placeholders)
+		if err != nil {
+			return err
+		}
+		mainScope.Raw(query)
+		return nil
+	}
 
-		// Also append variables to mainScope
-		mainScope.SQLVars = append(mainScope.SQLVars, scope.SQLVars...)
+	// Chunks of the same table, columns and length share their statement
+	cacheKey := fmt.Sprintf("%s:%s:%d", mainScope.TableName(), strings.Join(keys, ","), len(rows))
+	query, err := stmts.query(cacheKey, func() (string, error) {
+		return b.insertStatement(mainScope, keys, placeholders)
+	})
+	if err != nil {
+		return err
+	}
+	mainScope.Raw(query)
+	return nil
+}
+
+// Generate the INSERT statement of columns keys with a value tuple per row
+func (b *Builder) insertStatement(mainScope *gorm.Scope, keys []string, placeholders []string) (string, error) {
+	// Replace with database column name
+	dbColumns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		dbColumns = append(dbColumns, mainScope.Quote(gorm.ToColumnName(key)))
+	}
+
+	dialect := mainScope.Dialect().GetName()
+	if dialect == "mssql" && (b.replace || b.upsert || b.ignoreConflicts) {
+		query, err := b.mergeStatement(mainScope, keys, placeholders)
+		if err != nil {
+			return "", err
+		}
+		return identityInsert(mainScope, keys, query), nil
 	}
 
 	operation := "INSERT"
-	if replace {
+	if b.replace {
 		operation = "REPLACE"
 	}
 
-	mainScope.Raw(fmt.Sprintf("%s INTO %s (%s) VALUES %s",
+	var suffix string
+	if b.upsert {
+		var err error
+		suffix, err = upsertClause(mainScope, b.conflictColumns, b.updateColumns, b.ignoreColumns, keys)
+		if err != nil {
+			return "", err
+		}
+	}
+	if b.ignoreConflicts {
+		switch dialect {
+		case "mysql":
+			operation = "INSERT IGNORE"
+		case "sqlite3":
+			operation = "INSERT OR IGNORE"
+		case "postgres":
+			suffix = " ON CONFLICT DO NOTHING"
+		default:
+			return "", fmt.Errorf("ignoring conflicts is not supported by dialect %s", dialect)
+		}
+	}
+
+	query := fmt.Sprintf("%s INTO %s (%s) VALUES %s%s",
 		operation,
 		mainScope.QuotedTableName(),
 		strings.Join(dbColumns, ", "),
 		strings.Join(placeholders, ", "),
-	))
+		suffix,
+	)
+	if dialect == "mssql" {
+		query = identityInsert(mainScope, keys, query)
+	}
+	return query, nil
+}
 
-	return db.Exec(mainScope.SQL, mainScope.SQLVars...).Error
+// Obtain the attributes of the object at index i of the payload, passed through the transform
+func (b *Builder) row(db *gorm.DB, i int, obj interface{}) (map[string]interface{}, error) {
+	attrs, err := b.extract(obj)
+	if err == nil {
+		err = dialectValues(db.Dialect().GetName(), attrs)
+	}
+	if err != nil || b.transform == nil {
+		return attrs, err
+	}
+	if err := b.transform(i, attrs); err != nil {
+		return nil, err
+	}
+	return attrs, nil
 }
 
-// Obtain columns and values required for insert from interface
-func extractMapValue(value interface{}, excludeColumns []string) (map[string]interface{}, error) {
-	if reflect.ValueOf(value).Kind() != reflect.Struct {
+// Obtain the attributes of obj honoring the excluded and included columns
+func (b *Builder) extract(obj interface{}) (map[string]interface{}, error) {
+	attrs, err := extractMapValue(obj, b.excludeColumns, b.nullBlankColumns...)
+	if err != nil || len(b.includeColumns) == 0 {
+		return attrs, err
+	}
+
+	included := map[string]bool{}
+	for _, column := range b.includeColumns {
+		found := false
+		for _, field := range (&gorm.Scope{Value: obj}).GetModelStruct().StructFields {
+			if field.Name == column || field.DBName == column {
+				included[field.DBName] = true
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("included column %s is not a field of %T", column, obj)
+		}
+	}
+
+	for key := range attrs {
+		if !included[key] {
+			delete(attrs, key)
+		}
+	}
+	return attrs, nil
+}
+
+// Obtain columns and values required for insert from interface.
+// Blank fields named in nullBlankColumns, or tagged with gorm:"null_blank", are inserted as NULL.
+func extractMapValue(value interface{}, excludeColumns []string, nullBlankColumns ...string) (map[string]interface{}, error) {
+	indirect, err := indirectValue(reflect.ValueOf(value))
+	if err != nil {
+		return nil, err
+	}
+	if indirect.Kind() != reflect.Struct {
 		return nil, errors.New("value must be kind of Struct")
 	}
 
-	var attrs = map[string]interface{}{}
+	plan := planOf(indirect.Type())
+	for _, column := range excludeColumns {
+		if !plan.has(column) {
+			return nil, fmt.Errorf("excluded column %s is not a field of %s", column, indirect.Type())
+		}
+	}
+	if plan.needsAddr && !indirect.CanAddr() {
+		// Work on an addressable copy, so that pointer receiver Valuers can be called
+		copied := reflect.New(indirect.Type())
+		copied.Elem().Set(indirect)
+		indirect = copied.Elem()
+	}
 
-	for _, field := range (&gorm.Scope{Value: value}).Fields() {
-		// Exclude relational record because it's not directly contained in database columns
-		_, hasForeignKey := field.TagSettingsGet("FOREIGNKEY")
+	var attrs = make(map[string]interface{}, len(plan.fields))
 
-		if !containString(excludeColumns, field.Struct.Name) && field.StructField.Relationship == nil && !hasForeignKey &&
-			!field.IsIgnored && !(field.DBName == "id" && field.IsPrimaryKey) {
-			if (field.Struct.Name == "CreatedAt" || field.Struct.Name == "UpdatedAt") && field.IsBlank {
-				attrs[field.DBName] = time.Now()
-			} else if field.StructField.HasDefaultValue && field.IsBlank {
-				// If default value presents and field is empty, assign a default value
-				if val, ok := field.TagSettingsGet("DEFAULT"); ok {
-					attrs[field.DBName] = val
-				} else {
-					attrs[field.DBName] = field.Field.Interface()
-				}
-			} else {
-				attrs[field.DBName] = field.Field.Interface()
+	for _, f := range plan.fields {
+		if f.matchesAny(excludeColumns) {
+			continue
+		}
+		field := f.field(indirect)
+		blank := isBlank(field)
+
+		// A blank single numeric primary key is left to auto increment, composite and given keys are inserted
+		if f.isPrimaryKey && plan.primaryKeys == 1 && blank && !f.isString {
+			continue
+		}
+
+		nullBlank := f.nullBlank || f.matchesAny(nullBlankColumns)
+
+		if f.isTimestamp && blank {
+			attrs[f.dbName] = time.Now()
+		} else if nullBlank && blank {
+			attrs[f.dbName] = nil
+		} else if f.hasDefault && blank {
+			// If default value presents and field is empty, assign a default value
+			attrs[f.dbName] = f.defaultValue
+		} else {
+			val, err := f.value(field)
+			if err != nil {
+				return nil, err
 			}
+			attrs[f.dbName] = val
 		}
 	}
 	return attrs, nil
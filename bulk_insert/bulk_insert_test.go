@@ -2,13 +2,52 @@ package bulk_insert
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/stretchr/testify/assert"
 )
 
+// fakeCommon satisfies gorm.SQLCommon so dialect specific SQL can be built without a database,
+// executed statements are recorded.
+type fakeCommon struct {
+	queries []string
+}
+
+func (c *fakeCommon) Exec(query string, args ...interface{}) (sql.Result, error) {
+	c.queries = append(c.queries, query)
+	return driver.RowsAffected(0), nil
+}
+func (*fakeCommon) Prepare(query string) (*sql.Stmt, error)                    { return nil, nil }
+func (*fakeCommon) Query(query string, args ...interface{}) (*sql.Rows, error) { return nil, nil }
+func (*fakeCommon) QueryRow(query string, args ...interface{}) *sql.Row        { return nil }
+
+func openSQLite(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(models...).Error)
+	return db
+}
+
+func openFake(t *testing.T, dialect string) *gorm.DB {
+	db, _ := openRecorder(t, dialect)
+	return db
+}
+
+func openRecorder(t *testing.T, dialect string) (*gorm.DB, *fakeCommon) {
+	common := &fakeCommon{}
+	db, err := gorm.Open(dialect, common)
+	assert.NoError(t, err)
+	return db, common
+}
+
 type fakeRelationDB struct{}
 
 type fakeDB struct {
@@ -59,3 +98,265 @@ func Test_extractMapValue(t *testing.T) {
 	assert.NotContains(t, excludedKeys, "email")
 	assert.NotContains(t, excludedKeys, "created_at")
 }
+
+func TestBuilder_Exec_progress(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	var reported [][2]int
+	progress := func(inserted, total int) {
+		reported = append(reported, [2]int{inserted, total})
+	}
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	assert.NoError(t, NewBuilder(ChunkSizeOpt(2), ProgressOpt(progress)).Exec(db, objects))
+	assert.Equal(t, [][2]int{{2, 3}, {3, 3}}, reported)
+}
+
+func TestBuilder_extract(t *testing.T) {
+	value := fakeDB{Name: "name1", Email: "test1@test.com", Publish: true}
+
+	attrs, err := NewBuilder(IncludeColumnsOpt([]string{"Name", "email"})).extract(value)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "name1", "email": "test1@test.com"}, attrs)
+
+	_, err = NewBuilder(IncludeColumnsOpt([]string{"Unknown"})).extract(&value)
+	assert.Error(t, err)
+}
+
+type upperString string
+
+func (s *upperString) Value() (driver.Value, error) {
+	return strings.ToUpper(string(*s)), nil
+}
+
+type jsonAttrs struct {
+	Tags []string
+}
+
+func (a jsonAttrs) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+type EmbeddedBase struct {
+	Code upperString
+}
+
+type EmbeddedExtra struct {
+	Note string
+}
+
+type embeddedAddress struct {
+	City string
+}
+
+type embeddedDB struct {
+	ID int
+	EmbeddedBase
+	*EmbeddedExtra
+	Address embeddedAddress `gorm:"embedded;embedded_prefix:address_"`
+	Attrs   jsonAttrs
+	Secret  *upperString
+}
+
+func Test_extractMapValue_valuerAndEmbedded(t *testing.T) {
+	object := embeddedDB{
+		EmbeddedBase: EmbeddedBase{Code: "abc"},
+		Address:      embeddedAddress{City: "Paris"},
+		Attrs:        jsonAttrs{Tags: []string{"a"}},
+	}
+	attrs, err := extractMapValue(object, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"code":         "ABC",
+		"note":         "",
+		"address_city": "Paris",
+		"attrs":        []byte(`{"Tags":["a"]}`),
+		"secret":       nil,
+	}, attrs)
+
+	// nil embedded pointers of the caller are left untouched
+	attrs, err = extractMapValue(&object, []string{"Attrs"})
+	assert.NoError(t, err)
+	assert.Nil(t, object.EmbeddedExtra)
+	assert.NotContains(t, attrs, "attrs")
+}
+
+func TestBuilder_BuildSQL(t *testing.T) {
+	db := openFake(t, "postgres")
+	objects := []*xidDB{{ID: "a", Name: "first"}, {ID: "b", Name: "second"}, {Name: "third"}}
+
+	statements, err := NewBuilder(ChunkSizeOpt(2)).BuildSQL(db, objects)
+	assert.NoError(t, err)
+	if assert.Len(t, statements, 2) {
+		assert.Equal(t, `INSERT INTO "xid_dbs" ("id", "name") VALUES ($1, $2), ($3, $4)`, statements[0].SQL)
+		assert.Equal(t, []interface{}{"a", "first", "b", "second"}, statements[0].Vars)
+		assert.Equal(t, `INSERT INTO "xid_dbs" ("id", "name") VALUES ($1, $2)`, statements[1].SQL)
+		assert.Len(t, statements[1].Vars[0], 20)
+	}
+	// the generated ID is not written back
+	assert.Empty(t, objects[2].ID)
+
+	_, err = NewBuilder(CopyOpt(true)).BuildSQL(db, objects)
+	assert.Error(t, err)
+
+	// duplicates are dropped and partitions routed as Exec does
+	partition := func(obj interface{}) string {
+		return "xid_dbs_" + obj.(*xidDB).Name[:1]
+	}
+	objects = []*xidDB{{ID: "a", Name: "first"}, {ID: "b", Name: "second"}, {ID: "c", Name: "first"}, {ID: "d", Name: "fourth"}}
+	statements, err = NewBuilder(DedupeByOpt("name"), PartitionOpt(partition)).BuildSQL(db, objects)
+	assert.NoError(t, err)
+	if assert.Len(t, statements, 2) {
+		assert.Equal(t, `INSERT INTO "xid_dbs_f" ("id", "name") VALUES ($1, $2), ($3, $4)`, statements[0].SQL)
+		assert.Equal(t, []interface{}{"a", "first", "d", "fourth"}, statements[0].Vars)
+		assert.Equal(t, `INSERT INTO "xid_dbs_s" ("id", "name") VALUES ($1, $2)`, statements[1].SQL)
+	}
+}
+
+func TestBuilder_Exec_table(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	assert.NoError(t, db.Table("unique_2024_05").AutoMigrate(&uniqueDB{}).Error)
+
+	builder := NewBuilder(TableOpt("unique_2024_05"))
+	assert.NoError(t, builder.Exec(db, []uniqueDB{{Name: "a"}, {Name: "b"}}))
+
+	var count int
+	assert.NoError(t, db.Table("unique_2024_05").Count(&count).Error)
+	assert.Equal(t, 2, count)
+
+	statements, err := builder.BuildSQL(openFake(t, "mysql"), []uniqueDB{{Name: "a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO `unique_2024_05` (`name`) VALUES (?)", statements[0].SQL)
+}
+
+type nullBlankDB struct {
+	ID    int
+	Name  string
+	Email string `gorm:"null_blank"`
+	Age   int
+}
+
+func Test_extractMapValue_nullBlank(t *testing.T) {
+	attrs, err := extractMapValue(nullBlankDB{}, nil, "age")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "", "email": nil, "age": nil}, attrs)
+
+	attrs, err = extractMapValue(nullBlankDB{Email: "a@b.c", Age: 3}, nil, "Age")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "", "email": "a@b.c", "age": 3}, attrs)
+}
+
+type compositeDB struct {
+	TenantID int `gorm:"primary_key;auto_increment:false"`
+	UserID   int `gorm:"primary_key;auto_increment:false"`
+	Name     string
+}
+
+func Test_extractMapValue_primaryKeys(t *testing.T) {
+	attrs, err := extractMapValue(compositeDB{TenantID: 1, Name: "a"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"tenant_id": 1, "user_id": 0, "name": "a"}, attrs)
+
+	// a given single primary key is inserted, a blank one left to auto increment
+	attrs, err = extractMapValue(uniqueDB{ID: 5, Name: "a"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": 5, "name": "a"}, attrs)
+
+	db := openSQLite(t, &compositeDB{})
+	defer db.Close()
+	assert.NoError(t, NewBuilder().Exec(db, []compositeDB{{TenantID: 1, UserID: 1}, {TenantID: 1, UserID: 2}, {TenantID: 2, UserID: 1}}))
+
+	var count int
+	assert.NoError(t, db.Model(&compositeDB{}).Where("tenant_id = ?", 1).Count(&count).Error)
+	assert.Equal(t, 2, count)
+}
+
+type defaultExprDB struct {
+	ID      int
+	Name    string
+	Code    string    `gorm:"default:'none'"`
+	Status  string    `gorm:"default:active"`
+	Created time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+func TestBuilder_Exec_defaultExpressions(t *testing.T) {
+	statements, err := NewBuilder().BuildSQL(openFake(t, "postgres"), []defaultExprDB{{Name: "a"}, {Name: "b", Created: time.Unix(0, 0)}})
+	assert.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "default_expr_dbs" ("code", "created", "name", "status") VALUES ($1, CURRENT_TIMESTAMP, $2, $3), ($4, $5, $6, $7)`, statements[0].SQL)
+	assert.Equal(t, []interface{}{"none", "a", "active", "none", time.Unix(0, 0), "b", "active"}, statements[0].Vars)
+
+	db := openSQLite(t, &defaultExprDB{})
+	defer db.Close()
+	assert.NoError(t, NewBuilder().Exec(db, []defaultExprDB{{Name: "a"}}))
+
+	var stored defaultExprDB
+	assert.NoError(t, db.First(&stored).Error)
+	assert.Equal(t, "none", stored.Code)
+	assert.Equal(t, "active", stored.Status)
+	assert.False(t, stored.Created.IsZero())
+}
+
+func Benchmark_extractMapValue(b *testing.B) {
+	value := fakeDB{Name: "name", Email: "a@b.c", Message: sql.NullString{String: "message", Valid: true}, Publish: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := extractMapValue(value, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuilder_BuildSQL(b *testing.B) {
+	db, err := gorm.Open("mysql", &fakeCommon{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	objects := make([]fakeDB, 1000)
+	for i := range objects {
+		objects[i] = fakeDB{Name: "name", Email: "a@b.c", Publish: true}
+	}
+
+	builder := NewBuilder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.BuildSQL(db, objects); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Test_extractMapValue_excludeColumns(t *testing.T) {
+	attrs, err := extractMapValue(fakeDB{}, []string{"created_at", "UpdatedAt", "Message"})
+	assert.NoError(t, err)
+	assert.NotContains(t, attrs, "created_at")
+	assert.NotContains(t, attrs, "updated_at")
+	assert.NotContains(t, attrs, "message")
+
+	_, err = extractMapValue(fakeDB{}, []string{"missing"})
+	assert.EqualError(t, err, "excluded column missing is not a field of bulk_insert.fakeDB")
+}
+
+func TestBuilder_Exec_transform(t *testing.T) {
+	db, common := openRecorder(t, "mysql")
+	transform := func(i int, attrs map[string]interface{}) error {
+		if attrs["name"] == "" {
+			return fmt.Errorf("object %d has no name", i)
+		}
+		attrs["name"] = strings.ToUpper(attrs["name"].(string))
+		return nil
+	}
+
+	statements, err := NewBuilder(TransformOpt(transform), ChunkSizeOpt(1)).BuildSQL(db, []uniqueDB{{Name: "a"}, {Name: "b"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"A"}, statements[0].Vars)
+	assert.Equal(t, []interface{}{"B"}, statements[1].Vars)
+
+	err = NewBuilder(TransformOpt(transform), ChunkSizeOpt(1)).Exec(db, []uniqueDB{{Name: "a"}, {}})
+	assert.Contains(t, err.Error(), "object 1 has no name")
+	assert.Len(t, common.queries, 1)
+
+	assert.NoError(t, NewBuilder(TransformOpt(transform)).ExecMaps(db, "unique_dbs", []map[string]interface{}{{"name": "c"}}))
+}
@@ -0,0 +1,84 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Insert a chunk into ClickHouse as a batch: the driver buffers the rows of a prepared INSERT
+// and sends them as a single block when the transaction commits
+func (b *Builder) batchObjSet(ctx context.Context, db *gorm.DB, _ *statements, offset int, objects []interface{}, _ []reflect.Value) (rowsAffected int64, err error) {
+	if b.replace || b.upsert || b.writeBack || b.ignoreConflicts {
+		return 0, errors.New("clickhouse batches can not be combined with replace, upsert, write back or ignore conflicts")
+	}
+	if len(objects) == 0 {
+		return 0, nil
+	}
+
+	rows, err := b.extractRows(db, offset, objects)
+	if err != nil {
+		return 0, err
+	}
+	columns := sortedKeys(rows[0])
+
+	// Batches are only sent inside a transaction, start one unless the caller already did
+	tx := transaction(db)
+	if tx == nil {
+		common, ok := db.CommonDB().(txBeginner)
+		if !ok {
+			return 0, errors.New("clickhouse batches require a *sql.DB or *sql.Tx connection")
+		}
+		if tx, err = common.BeginTx(ctx, nil); err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+		}()
+	}
+
+	stmt, err := tx.PrepareContext(ctx, batchStatement(db.NewScope(objects[0]), columns))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, objAttrs := range rows {
+		if len(objAttrs) != len(columns) {
+			return 0, errors.New("attribute sizes are inconsistent")
+		}
+
+		values := make([]interface{}, 0, len(columns))
+		for _, key := range columns {
+			if _, ok := objAttrs[key].(defaultExpr); ok {
+				return 0, fmt.Errorf("default expression of column %s can not be batched, set the field", key)
+			}
+			values = append(values, objAttrs[key])
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+// Single row INSERT the batch is prepared with
+func batchStatement(scope *gorm.Scope, columns []string) string {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, scope.Quote(gorm.ToColumnName(column)))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		scope.QuotedTableName(),
+		strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+	)
+}
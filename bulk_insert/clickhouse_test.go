@@ -0,0 +1,55 @@
+package bulk_insert
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/cochainio/orm/dialects/clickhouse"
+)
+
+type eventDB struct {
+	ID   int
+	Name string
+}
+
+// sqlite speaks the batch INSERT of ClickHouse, it stands in for the server
+func openClickHouse(t *testing.T) *gorm.DB {
+	pool, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "clickhouse.db"))
+	assert.NoError(t, err)
+	_, err = pool.Exec("CREATE TABLE event_dbs (id integer, name text)")
+	assert.NoError(t, err)
+	db, err := gorm.Open("clickhouse", pool)
+	assert.NoError(t, err)
+	return db
+}
+
+func Test_batchStatement(t *testing.T) {
+	db := openFake(t, "clickhouse")
+	assert.Equal(t, "INSERT INTO `event_dbs` (`id`, `name`) VALUES (?, ?)", batchStatement(db.NewScope(&eventDB{}), []string{"id", "name"}))
+}
+
+func TestBuilder_Exec_clickhouse(t *testing.T) {
+	db := openClickHouse(t)
+	defer db.Close()
+
+	events := []eventDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}
+	result, err := NewBuilder(ChunkSizeOpt(2)).Run(context.Background(), db, events)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.RowsAffected)
+
+	// Inside a transaction of the caller, the batch is sent when it commits
+	tx := db.Begin()
+	assert.NoError(t, NewBuilder().Exec(tx, []eventDB{{ID: 4, Name: "d"}}))
+	assert.NoError(t, tx.Commit().Error)
+
+	var stored []eventDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, append(events, eventDB{ID: 4, Name: "d"}), stored)
+
+	assert.Error(t, NewBuilder(UpsertOpt([]string{"id"}, nil)).Exec(db, events))
+}
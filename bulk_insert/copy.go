@@ -0,0 +1,89 @@
+package bulk_insert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+// Connections able to start a transaction, like *sql.DB
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Stream objects to Postgres with the COPY protocol
+func (b *Builder) copyIn(ctx context.Context, db *gorm.DB, objects []interface{}) (rowsAffected int64, err error) {
+	if b.replace || b.upsert || b.writeBack || b.ignoreConflicts {
+		return 0, errors.New("copy can not be combined with replace, upsert, write back or ignore conflicts")
+	}
+	if len(objects) == 0 {
+		return 0, nil
+	}
+
+	rows, err := b.extractRows(db, 0, objects)
+	if err != nil {
+		return 0, err
+	}
+	columns := sortedKeys(rows[0])
+
+	// COPY is only allowed inside a transaction, start one unless the caller already did
+	tx := transaction(db)
+	if tx == nil {
+		common, ok := db.CommonDB().(txBeginner)
+		if !ok {
+			return 0, errors.New("copy requires a *sql.DB or *sql.Tx connection")
+		}
+		if tx, err = common.BeginTx(ctx, nil); err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback()
+			} else {
+				err = tx.Commit()
+			}
+		}()
+	}
+
+	stmt, err := tx.PrepareContext(ctx, copyInStatement(db.NewScope(objects[0]).TableName(), columns))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, objAttrs := range rows {
+		if len(objAttrs) != len(columns) {
+			return 0, errors.New("attribute sizes are inconsistent")
+		}
+
+		values := make([]interface{}, 0, len(columns))
+		for _, key := range columns {
+			if _, ok := objAttrs[key].(defaultExpr); ok {
+				return 0, fmt.Errorf("default expression of column %s can not be loaded, set the field", key)
+			}
+			values = append(values, objAttrs[key])
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, err
+		}
+	}
+
+	// Flush buffered rows, the result reports the number of copied rows
+	result, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func copyInStatement(table string, columns []string) string {
+	if i := strings.Index(table, "."); i >= 0 {
+		return pq.CopyInSchema(table[:i], table[i+1:], columns...)
+	}
+	return pq.CopyIn(table, columns...)
+}
@@ -0,0 +1,24 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_copyInStatement(t *testing.T) {
+	assert.Equal(t, `COPY "users" ("id", "name") FROM STDIN`, copyInStatement("users", []string{"id", "name"}))
+	assert.Equal(t, `COPY "audit"."users" ("id") FROM STDIN`, copyInStatement("audit.users", []string{"id"}))
+}
+
+func TestBuilder_Exec_copyFallback(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	// sqlite does not speak COPY, records are inserted as usual
+	assert.NoError(t, NewBuilder(CopyOpt(true)).Exec(db, []writeBackDB{{Name: "a"}, {Name: "b"}}))
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 2, count)
+}
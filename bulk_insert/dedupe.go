@@ -0,0 +1,118 @@
+package bulk_insert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Drop the elements sharing the values of the dedupeBy columns, keeping the first or with DedupeLastWinsOpt the last one.
+// Returns the kept elements in their original order and the number of dropped ones.
+func (b *Builder) dedupeElems(elems []reflect.Value) ([]reflect.Value, int, error) {
+	if len(b.dedupeBy) == 0 || len(elems) == 0 {
+		return elems, 0, nil
+	}
+
+	plan := planOf(elems[0].Type())
+	fields := make([]*fieldPlan, 0, len(b.dedupeBy))
+	for _, column := range b.dedupeBy {
+		var found *fieldPlan
+		for _, f := range plan.fields {
+			if f.matches(column) {
+				found = f
+				break
+			}
+		}
+		if found == nil {
+			return nil, 0, fmt.Errorf("dedupe column %s is not a field of %s", column, elems[0].Type())
+		}
+		fields = append(fields, found)
+	}
+
+	kept, err := b.dedupe(len(elems), func(i int) ([]interface{}, error) {
+		values := make([]interface{}, 0, len(fields))
+		for _, f := range fields {
+			if f.randomized {
+				values = append(values, f.field(elems[i]).Interface())
+				continue
+			}
+			value, err := f.value(f.field(elems[i]))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deduped := make([]reflect.Value, 0, len(kept))
+	for _, i := range kept {
+		deduped = append(deduped, elems[i])
+	}
+	return deduped, len(elems) - len(kept), nil
+}
+
+// Same as dedupeElems for rows of column values
+func (b *Builder) dedupeRows(rows []map[string]interface{}) ([]map[string]interface{}, int, error) {
+	if len(b.dedupeBy) == 0 {
+		return rows, 0, nil
+	}
+
+	kept, err := b.dedupe(len(rows), func(i int) ([]interface{}, error) {
+		values := make([]interface{}, 0, len(b.dedupeBy))
+		for _, column := range b.dedupeBy {
+			value, ok := rows[i][column]
+			if !ok {
+				return nil, fmt.Errorf("row %d is missing dedupe column %s", i, column)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	deduped := make([]map[string]interface{}, 0, len(kept))
+	for _, i := range kept {
+		deduped = append(deduped, rows[i])
+	}
+	return deduped, len(rows) - len(kept), nil
+}
+
+// Indexes of the count items to keep, items are identified by the values returned by key
+func (b *Builder) dedupe(count int, key func(i int) ([]interface{}, error)) ([]int, error) {
+	seen := make(map[string]int, count)
+	keep := make([]bool, count)
+	for i := 0; i < count; i++ {
+		values, err := key(i)
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, len(values))
+		for _, value := range values {
+			parts = append(parts, fmt.Sprintf("%#v", value))
+		}
+		k := strings.Join(parts, "\x00")
+
+		if previous, ok := seen[k]; ok {
+			if !b.dedupeLastWins {
+				continue
+			}
+			keep[previous] = false
+		}
+		seen[k] = i
+		keep[i] = true
+	}
+
+	var kept []int
+	for i, ok := range keep {
+		if ok {
+			kept = append(kept, i)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,68 @@
+package bulk_insert
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Run_dedupe(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "a"}, {Name: "c"}, {Name: "b"}}
+	result, err := NewBuilder(DedupeByOpt("name")).Run(context.Background(), db, objects)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Duplicates)
+	assert.Equal(t, int64(3), result.RowsAffected)
+
+	_, err = NewBuilder(DedupeByOpt("missing")).Run(context.Background(), db, objects)
+	assert.Error(t, err)
+}
+
+func TestBuilder_dedupeRows(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"name": "a", "age": 1},
+		{"name": "b", "age": 2},
+		{"name": "a", "age": 3},
+	}
+
+	kept, dropped, err := NewBuilder(DedupeByOpt("name")).dedupeRows(rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, []map[string]interface{}{rows[0], rows[1]}, kept)
+
+	kept, _, err = NewBuilder(DedupeByOpt("name"), DedupeLastWinsOpt(true)).dedupeRows(rows)
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{rows[1], rows[2]}, kept)
+}
+
+// nonceString sends a different value on every call, like encrypted types
+type nonceString string
+
+var nonces int
+
+func (s nonceString) Value() (driver.Value, error) {
+	nonces++
+	return fmt.Sprintf("%s-%d", s, nonces), nil
+}
+
+func (nonceString) RandomizedValue() {}
+
+type randomizedDB struct {
+	ID   int
+	Name nonceString
+}
+
+func TestBuilder_Run_dedupeRandomized(t *testing.T) {
+	db := openSQLite(t, &randomizedDB{})
+	defer db.Close()
+
+	objects := []randomizedDB{{Name: "a"}, {Name: "a"}, {Name: "b"}}
+	result, err := NewBuilder(DedupeByOpt("name")).Run(context.Background(), db, objects)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Duplicates)
+}
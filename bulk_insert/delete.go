@@ -0,0 +1,83 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ExecDelete hard deletes the records of model by primary key, one statement per chunk
+// [model] Struct or pointer to struct identifying the table
+// [ids]   Must be a slice of primary key values
+func (b *Builder) ExecDelete(db *gorm.DB, model interface{}, ids interface{}) error {
+	db = b.target(db)
+	value := reflect.ValueOf(ids)
+	if value.Kind() != reflect.Slice {
+		return errors.New("ids must be a slice")
+	}
+	idInterfaces := make([]interface{}, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		idInterfaces[i] = value.Index(i).Interface()
+	}
+
+	scope := db.NewScope(model)
+	if len(scope.PrimaryFields()) != 1 {
+		return errors.New("bulk delete requires exactly one primary key")
+	}
+
+	// The archive statement binds At in addition to the ids
+	for _, idSet := range splitObjects(idInterfaces, b.safeChunkSize(db, 1, 1)) {
+		var err error
+		if b.archive {
+			err = inTransaction(context.Background(), db, func(tx *gorm.DB) error {
+				return deleteIDSet(tx, tx.NewScope(model), idSet, true)
+			})
+		} else {
+			err = deleteIDSet(db, scope, idSet, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete multiple records at once, see Builder.ExecDelete
+func BulkDelete(db *gorm.DB, model interface{}, ids interface{}, chunkSize int) error {
+	return NewBuilder(ChunkSizeOpt(chunkSize)).ExecDelete(db, model, ids)
+}
+
+func deleteIDSet(db *gorm.DB, scope *gorm.Scope, ids []interface{}, archive bool) error {
+	condition := fmt.Sprintf("%s IN (%s)", scope.Quote(scope.PrimaryKey()), strings.Repeat("?, ", len(ids)-1)+"?")
+
+	if archive {
+		var columns []string
+		for _, field := range scope.Fields() {
+			_, hasForeignKey := field.TagSettingsGet("FOREIGNKEY")
+			if !field.IsIgnored && field.Relationship == nil && !hasForeignKey {
+				columns = append(columns, scope.Quote(field.DBName))
+			}
+		}
+		selected := strings.Join(columns, ", ")
+
+		query := fmt.Sprintf("INSERT INTO %s (%s, %s) SELECT %s, ? FROM %s WHERE %s",
+			scope.Quote(scope.TableName()+"_deleted"),
+			selected,
+			scope.Quote(gorm.ToColumnName("At")),
+			selected,
+			scope.QuotedTableName(),
+			condition,
+		)
+		if _, err := execSQL(context.Background(), db, query, append([]interface{}{gorm.NowFunc()}, ids...)); err != nil {
+			return err
+		}
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", scope.QuotedTableName(), condition)
+	_, err := execSQL(context.Background(), db, query, ids)
+	return err
+}
@@ -0,0 +1,51 @@
+package bulk_insert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type writeBackDBDeleted struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	At        time.Time
+}
+
+func (writeBackDBDeleted) TableName() string {
+	return "write_back_dbs_deleted"
+}
+
+func TestBuilder_ExecDelete(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{}, &writeBackDBDeleted{})
+	defer db.Close()
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).Exec(db, objects))
+
+	assert.NoError(t, NewBuilder(ChunkSizeOpt(1), ArchiveOpt(true)).ExecDelete(db, &writeBackDB{}, []int{objects[0].ID, objects[1].ID}))
+	assert.NoError(t, BulkDelete(db, writeBackDB{}, []int{objects[2].ID}, 10))
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+
+	var archived []writeBackDBDeleted
+	assert.NoError(t, db.Order("id").Find(&archived).Error)
+	assert.Len(t, archived, 2)
+	assert.Equal(t, "a", archived[0].Name)
+	assert.False(t, archived[0].At.IsZero())
+}
+
+func TestBuilder_ExecDelete_archiveColumn(t *testing.T) {
+	db, common := openRecorder(t, "postgres")
+
+	assert.NoError(t, deleteIDSet(db, db.NewScope(&writeBackDB{}), []interface{}{1}, true))
+	if assert.Len(t, common.queries, 2) {
+		assert.Contains(t, common.queries[0], `INSERT INTO "write_back_dbs_deleted" (`)
+		assert.Contains(t, common.queries[0], `"updated_at", "at") SELECT`)
+	}
+}
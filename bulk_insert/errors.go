@@ -0,0 +1,101 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// BulkError reports a failed chunk with the range of objects it covered.
+// Row is the index of the object at fault when BisectOpt could identify it, -1 otherwise.
+// With PartitionOpt, Chunk counts within the partition and the chunk covers the next Count objects of its partition,
+// Offset and Row still index the objects given to Run.
+type BulkError struct {
+	Chunk  int
+	Offset int
+	Count  int
+	Row    int
+	Err    error
+}
+
+func (e *BulkError) Error() string {
+	msg := fmt.Sprintf("chunk %d (objects %d to %d) failed", e.Chunk, e.Offset, e.Offset+e.Count-1)
+	if e.Row >= 0 {
+		msg += fmt.Sprintf(" at object %d", e.Row)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *BulkError) Unwrap() error {
+	return e.Err
+}
+
+// BulkErrors lists every failed chunk in order when ContinueOnErrorOpt is set
+type BulkErrors []*BulkError
+
+func (errs BulkErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Gather the chunk failures reported by runChunks into BulkErrors, other errors are returned as is
+func collectBulkErrors(err error) error {
+	var errs BulkErrors
+	switch e := err.(type) {
+	case *BulkError:
+		errs = BulkErrors{e}
+	case gorm.Errors:
+		for _, err := range e {
+			bulkErr, ok := err.(*BulkError)
+			if !ok {
+				return e
+			}
+			errs = append(errs, bulkErr)
+		}
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Chunk < errs[j].Chunk })
+	default:
+		return err
+	}
+	return errs
+}
+
+var errProbeRollback = errors.New("bulk_insert: probe rolled back")
+
+// Narrow down the object making the range of count objects at offset fail by inserting halves of it,
+// each attempt is rolled back. Returns -1 when no single object fails on its own, or within a transaction
+// which can not be rolled back partially.
+func (b *Builder) locateFailure(ctx context.Context, db *gorm.DB, offset, count int, probe func(db *gorm.DB, offset, count int) error) int {
+	if transaction(db) != nil {
+		return -1
+	}
+
+	fails := func(offset, count int) bool {
+		err := inTransaction(ctx, db, func(tx *gorm.DB) error {
+			if err := probe(tx, offset, count); err != nil {
+				return err
+			}
+			return errProbeRollback
+		})
+		return err != errProbeRollback
+	}
+
+	for count > 1 {
+		half := count / 2
+		if fails(offset, half) {
+			count = half
+		} else if fails(offset+half, count-half) {
+			offset, count = offset+half, count-half
+		} else {
+			// Objects only fail together, e.g. duplicates within the chunk
+			return -1
+		}
+	}
+	return offset
+}
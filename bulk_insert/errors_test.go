@@ -0,0 +1,59 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Exec_bulkError(t *testing.T) {
+	db := openSQLite(t, &uniqueDB{})
+	defer db.Close()
+
+	assert.NoError(t, NewBuilder().Exec(db, []uniqueDB{{Name: "d"}}))
+	objects := []uniqueDB{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+
+	err := NewBuilder(ChunkSizeOpt(2)).Exec(db, objects)
+	if assert.IsType(t, &BulkError{}, err) {
+		bulkErr := err.(*BulkError)
+		assert.Equal(t, 1, bulkErr.Chunk)
+		assert.Equal(t, 2, bulkErr.Offset)
+		assert.Equal(t, 2, bulkErr.Count)
+		assert.Equal(t, -1, bulkErr.Row)
+		assert.Contains(t, bulkErr.Error(), "chunk 1 (objects 2 to 3) failed: UNIQUE constraint failed")
+	}
+
+	err = NewBuilder(BisectOpt(true)).Exec(db, objects[2:])
+	if assert.IsType(t, &BulkError{}, err) {
+		assert.Equal(t, 1, err.(*BulkError).Row)
+	}
+
+	// bisecting does not leave any row behind
+	var count int
+	assert.NoError(t, db.Model(&uniqueDB{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+}
+
+func TestBuilder_Exec_continueOnError(t *testing.T) {
+	db := openSQLite(t, &uniqueDB{})
+	defer db.Close()
+
+	assert.NoError(t, NewBuilder().Exec(db, []uniqueDB{{Name: "b"}, {Name: "e"}}))
+	objects := []uniqueDB{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}, {Name: "f"}}
+
+	for _, opts := range [][]BuilderOpt{
+		{ChunkSizeOpt(2), ContinueOnErrorOpt(true)},
+		{ChunkSizeOpt(2), ContinueOnErrorOpt(true), ConcurrencyOpt(2, true)},
+	} {
+		err := NewBuilder(opts...).Exec(db, objects)
+		if assert.IsType(t, BulkErrors{}, err) {
+			errs := err.(BulkErrors)
+			assert.Len(t, errs, 2)
+			assert.Equal(t, 0, errs[0].Chunk)
+			assert.Equal(t, 4, errs[1].Offset)
+		}
+		assert.NoError(t, db.Where("name IN (?)", []string{"c", "d"}).Delete(&uniqueDB{}).Error)
+	}
+
+	assert.Error(t, NewBuilder(AtomicOpt(true), ContinueOnErrorOpt(true)).Exec(db, objects))
+}
@@ -0,0 +1,181 @@
+package bulk_insert
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+type execContexter interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type queryContexter interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Execute a generated statement and return the number of affected rows.
+// gorm is not able to pass a context to the driver, so statements only bypass it when ctx can be cancelled.
+func execSQL(ctx context.Context, db *gorm.DB, query string, vars []interface{}) (int64, error) {
+	if ctx.Done() == nil {
+		result := db.Exec(query, vars...)
+		return result.RowsAffected, result.Error
+	}
+
+	result, err := execResult(ctx, db, query, vars)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Execute a generated statement on the underlying connection
+func execResult(ctx context.Context, db *gorm.DB, query string, vars []interface{}) (sql.Result, error) {
+	query = rebind(db.Dialect(), query)
+
+	var result sql.Result
+	var err error
+	if common, ok := db.CommonDB().(execContexter); ok {
+		result, err = common.ExecContext(ctx, query, vars...)
+	} else {
+		result, err = db.CommonDB().Exec(query, vars...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Execute count chunks in order, or with a pool of workers when concurrency is configured
+func (b *Builder) runChunks(ctx context.Context, count int, exec func(i int) error) error {
+	if b.concurrency <= 1 {
+		var errs gorm.Errors
+		for i := 0; i < count; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := exec(i); err != nil {
+				if !b.continueOnError {
+					return err
+				}
+				errs = errs.Add(err)
+			}
+		}
+		switch len(errs) {
+		case 0:
+			return nil
+		case 1:
+			return errs[0]
+		default:
+			return errs
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		errs     gorm.Errors
+		wg       sync.WaitGroup
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+		indexes  = make(chan int)
+	)
+	for w := 0; w < b.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := exec(i); err != nil {
+					mu.Lock()
+					errs = errs.Add(err)
+					mu.Unlock()
+					if b.stopOnError && !b.continueOnError {
+						stopOnce.Do(func() { close(stop) })
+					}
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := 0; i < count; i++ {
+		select {
+		case indexes <- i:
+		case <-stop:
+			break dispatch
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	switch len(errs) {
+	case 0:
+		return ctx.Err()
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// Run fn in a transaction, joining the one of db if it already is a transaction
+func inTransaction(ctx context.Context, db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if transaction(db) != nil {
+		return fn(db)
+	}
+
+	tx := db.BeginTx(ctx, &sql.TxOptions{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Transaction db runs in, nil outside of one.
+// Connections wrapping a transaction, e.g. to bind it to a context, expose it with a Tx method.
+func transaction(db *gorm.DB) *sql.Tx {
+	switch common := db.CommonDB().(type) {
+	case *sql.Tx:
+		return common
+	case interface{ Tx() *sql.Tx }:
+		return common.Tx()
+	}
+	return nil
+}
+
+// Run a generated query returning rows
+func querySQL(ctx context.Context, db *gorm.DB, query string, vars []interface{}) (*sql.Rows, error) {
+	if ctx.Done() == nil {
+		return db.Raw(query, vars...).Rows()
+	}
+
+	query = rebind(db.Dialect(), query)
+	if common, ok := db.CommonDB().(queryContexter); ok {
+		return common.QueryContext(ctx, query, vars...)
+	}
+	return db.CommonDB().Query(query, vars...)
+}
+
+// Replace "?" placeholders of a generated statement with the dialect's bind variables
+func rebind(dialect gorm.Dialect, query string) string {
+	var builder strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			// gorm's mysql/sqlite/mssql dialects return "$$$" which gorm itself turns back into "?"
+			builder.WriteString(strings.Replace(dialect.BindVar(n), "$$$", "?", -1))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,81 @@
+package bulk_insert
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rebind(t *testing.T) {
+	query := "INSERT INTO t (a, b) VALUES (?, ?), (?, ?)"
+
+	assert.Equal(t, query, rebind(openFake(t, "mysql").Dialect(), query))
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES ($1, $2), ($3, $4)", rebind(openFake(t, "postgres").Dialect(), query))
+}
+
+func TestBuilder_ExecContext(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, NewBuilder(ChunkSizeOpt(2)).ExecContext(ctx, db, objects))
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+
+	// nothing is inserted once the context is cancelled
+	cancel()
+	assert.Equal(t, context.Canceled, BulkInsertContext(ctx, db, objects, 2, false))
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+}
+
+type uniqueDB struct {
+	ID   int
+	Name string `gorm:"unique_index"`
+}
+
+func TestBuilder_Exec_atomic(t *testing.T) {
+	db := openSQLite(t, &uniqueDB{})
+	defer db.Close()
+
+	// the second chunk conflicts with the first one
+	objects := []uniqueDB{{Name: "a"}, {Name: "b"}, {Name: "a"}}
+	assert.Error(t, NewBuilder(ChunkSizeOpt(2), AtomicOpt(true)).Exec(db, objects))
+
+	var count int
+	assert.NoError(t, db.Model(&uniqueDB{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
+
+func TestBuilder_runChunks(t *testing.T) {
+	var mu sync.Mutex
+	var executed []int
+	exec := func(i int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		executed = append(executed, i)
+		if i%2 == 1 {
+			return fmt.Errorf("chunk %d failed", i)
+		}
+		return nil
+	}
+
+	err := NewBuilder(ConcurrencyOpt(3, false)).runChunks(context.Background(), 6, exec)
+	assert.Len(t, executed, 6)
+	assert.IsType(t, gorm.Errors{}, err)
+	assert.Len(t, err.(gorm.Errors), 3)
+
+	// sequential execution stops at the first failing chunk
+	executed = nil
+	err = NewBuilder().runChunks(context.Background(), 6, exec)
+	assert.Equal(t, []int{0, 1}, executed)
+	assert.EqualError(t, err, "chunk 1 failed")
+}
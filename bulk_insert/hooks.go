@@ -0,0 +1,34 @@
+package bulk_insert
+
+import (
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Call the hook methods of every element the same way gorm's create callbacks do
+func callHooks(db *gorm.DB, elems []reflect.Value, methods ...string) error {
+	for _, elem := range elems {
+		scope := db.NewScope(elem.Addr().Interface())
+		scope.DB().Error = nil
+		for _, method := range methods {
+			scope.CallMethod(method)
+			if scope.HasError() {
+				return scope.DB().Error
+			}
+		}
+	}
+	return nil
+}
+
+// Replace elements which are not addressable with addressable copies, so pointer receiver methods can be called
+func addressable(elems []reflect.Value) []reflect.Value {
+	for i, elem := range elems {
+		if !elem.CanAddr() {
+			ptr := reflect.New(elem.Type())
+			ptr.Elem().Set(elem)
+			elems[i] = ptr.Elem()
+		}
+	}
+	return elems
+}
@@ -0,0 +1,45 @@
+package bulk_insert
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hookDB struct {
+	ID      int
+	Name    string
+	created bool
+}
+
+func (h *hookDB) BeforeCreate() error {
+	if h.Name == "" {
+		return errors.New("name is required")
+	}
+	h.Name += "!"
+	return nil
+}
+
+func (h *hookDB) AfterCreate() {
+	h.created = true
+}
+
+func TestBuilder_Exec_hooks(t *testing.T) {
+	db := openSQLite(t, &hookDB{})
+	defer db.Close()
+
+	objects := []hookDB{{Name: "a"}, {Name: "b"}}
+	assert.NoError(t, NewBuilder(RunHooksOpt(true)).Exec(db, objects))
+	assert.True(t, objects[0].created)
+	assert.True(t, objects[1].created)
+
+	var stored []hookDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, "a!", stored[0].Name)
+
+	assert.EqualError(t, NewBuilder(RunHooksOpt(true)).Exec(db, []hookDB{{}}), "name is required")
+
+	// hooks are not called by default
+	assert.NoError(t, NewBuilder().Exec(db, []hookDB{{}}))
+}
@@ -0,0 +1,34 @@
+package bulk_insert
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cochainio/orm/idgen"
+)
+
+// Fill blank string ID primary keys with the IDs of idgen like the orm create callback does, archive tables excluded.
+// Elements which are not addressable are replaced with copies holding the generated ID.
+func assignIDs(db *gorm.DB, elems []reflect.Value) []reflect.Value {
+	if len(elems) == 0 {
+		return elems
+	}
+
+	scope := db.NewScope(elems[0].Interface())
+	pf := scope.PrimaryField()
+	if pf == nil || pf.Name != "ID" || pf.Struct.Type.Kind() != reflect.String || strings.HasSuffix(scope.TableName(), "deleted") {
+		return elems
+	}
+
+	elems = addressable(elems)
+	for _, elem := range elems {
+		elemScope := db.NewScope(elem.Addr().Interface())
+		field, _ := elemScope.FieldByName(pf.Name)
+		if field.IsBlank {
+			field.Set(idgen.New(elemScope))
+		}
+	}
+	return elems
+}
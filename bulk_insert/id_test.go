@@ -0,0 +1,32 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type xidDB struct {
+	ID   string `gorm:"primary_key;size:20"`
+	Name string
+}
+
+func TestBuilder_Exec_assignIDs(t *testing.T) {
+	db := openSQLite(t, &xidDB{})
+	defer db.Close()
+
+	objects := []xidDB{{Name: "a"}, {ID: "given", Name: "b"}}
+	assert.NoError(t, NewBuilder().Exec(db, objects))
+	assert.Len(t, objects[0].ID, 20)
+	assert.Equal(t, "given", objects[1].ID)
+
+	var stored xidDB
+	assert.NoError(t, db.First(&stored, "id = ?", objects[0].ID).Error)
+	assert.Equal(t, "a", stored.Name)
+
+	// the copy of a struct held by an interface gets an ID as well
+	assert.NoError(t, NewBuilder().Exec(db, []interface{}{xidDB{Name: "c"}}))
+	var copied xidDB
+	assert.NoError(t, db.First(&copied, "name = ?", "c").Error)
+	assert.Len(t, copied.ID, 20)
+}
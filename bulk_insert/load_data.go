@@ -0,0 +1,123 @@
+package bulk_insert
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/rs/xid"
+)
+
+// Load a chunk into MySQL with LOAD DATA LOCAL INFILE reading from an in-memory CSV
+func (b *Builder) loadObjSet(ctx context.Context, db *gorm.DB, _ *statements, offset int, objects []interface{}, _ []reflect.Value) (int64, error) {
+	if b.upsert || b.writeBack {
+		return 0, errors.New("load data can not be combined with upsert or write back")
+	}
+	if len(objects) == 0 {
+		return 0, nil
+	}
+
+	rows, err := b.extractRows(db, offset, objects)
+	if err != nil {
+		return 0, err
+	}
+	columns := sortedKeys(rows[0])
+
+	var buf bytes.Buffer
+	for _, objAttrs := range rows {
+		if len(objAttrs) != len(columns) {
+			return 0, errors.New("attribute sizes are inconsistent")
+		}
+
+		values := make([]interface{}, 0, len(columns))
+		for _, key := range columns {
+			if _, ok := objAttrs[key].(defaultExpr); ok {
+				return 0, fmt.Errorf("default expression of column %s can not be loaded, set the field", key)
+			}
+			values = append(values, objAttrs[key])
+		}
+		if err := writeCSVLine(&buf, values); err != nil {
+			return 0, err
+		}
+	}
+
+	name := "bulk_insert_" + xid.New().String()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return &buf })
+	defer mysql.DeregisterReaderHandler(name)
+
+	scope := db.NewScope(objects[0])
+	modifier := ""
+	if b.replace {
+		modifier = "REPLACE"
+	} else if b.ignoreConflicts {
+		modifier = "IGNORE"
+	}
+	return execSQL(ctx, db, loadDataStatement(scope, name, columns, modifier), nil)
+}
+
+func loadDataStatement(scope *gorm.Scope, handler string, columns []string, modifier string) string {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, scope.Quote(column))
+	}
+
+	if modifier != "" {
+		modifier += " "
+	}
+
+	return fmt.Sprintf(`LOAD DATA LOCAL INFILE 'Reader::%s' %sINTO TABLE %s CHARACTER SET utf8mb4 `+
+		`FIELDS TERMINATED BY ',' ENCLOSED BY '"' ESCAPED BY '' LINES TERMINATED BY '\n' (%s)`,
+		handler,
+		modifier,
+		scope.QuotedTableName(),
+		strings.Join(quoted, ", "),
+	)
+}
+
+// Write one CSV line, every value is enclosed so that an unenclosed NULL is unambiguous
+func writeCSVLine(w io.Writer, values []interface{}) error {
+	fields := make([]string, 0, len(values))
+	for _, value := range values {
+		value, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			return err
+		}
+
+		var field string
+		switch v := value.(type) {
+		case nil:
+			fields = append(fields, "NULL")
+			continue
+		case []byte:
+			field = string(v)
+		case string:
+			field = v
+		case time.Time:
+			field = v.Format("2006-01-02 15:04:05.999999")
+		case bool:
+			field = "0"
+			if v {
+				field = "1"
+			}
+		case int64:
+			field = strconv.FormatInt(v, 10)
+		case float64:
+			field = strconv.FormatFloat(v, 'g', -1, 64)
+		default:
+			field = fmt.Sprint(v)
+		}
+		fields = append(fields, `"`+strings.Replace(field, `"`, `""`, -1)+`"`)
+	}
+
+	_, err := io.WriteString(w, strings.Join(fields, ",")+"\n")
+	return err
+}
@@ -0,0 +1,35 @@
+package bulk_insert
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeCSVLine(t *testing.T) {
+	var buf bytes.Buffer
+
+	values := []interface{}{
+		"say \"hi\", bye",
+		42,
+		true,
+		sql.NullString{},
+		time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC),
+		[]byte("raw"),
+	}
+	assert.NoError(t, writeCSVLine(&buf, values))
+	assert.Equal(t, `"say ""hi"", bye","42","1",NULL,"2019-06-01 12:30:00","raw"`+"\n", buf.String())
+}
+
+func Test_loadDataStatement(t *testing.T) {
+	scope := openFake(t, "mysql").NewScope(fakeDB{})
+
+	assert.Equal(t,
+		"LOAD DATA LOCAL INFILE 'Reader::h' REPLACE INTO TABLE `fake_dbs` CHARACTER SET utf8mb4 "+
+			`FIELDS TERMINATED BY ',' ENCLOSED BY '"' ESCAPED BY '' LINES TERMINATED BY '\n' `+"(`name`, `publish`)",
+		loadDataStatement(scope, "h", []string{"name", "publish"}, "REPLACE"),
+	)
+}
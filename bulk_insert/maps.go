@@ -0,0 +1,111 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ExecMaps inserts rows of column values into table.
+// Every row must have the same keys, excluded columns are dropped from all rows.
+// Options which need struct fields or records, such as WriteBackOpt, RunHooksOpt or CopyOpt, are rejected.
+func (b *Builder) ExecMaps(db *gorm.DB, table string, rows []map[string]interface{}) error {
+	return b.execMaps(context.Background(), db, table, rows)
+}
+
+// Insert multiple rows built from column maps at once, see Builder.ExecMaps
+func BulkInsertMaps(db *gorm.DB, table string, rows []map[string]interface{}, opts ...BuilderOpt) error {
+	return NewBuilder(opts...).ExecMaps(db, table, rows)
+}
+
+func (b *Builder) execMaps(ctx context.Context, db *gorm.DB, table string, rows []map[string]interface{}) error {
+	if err := b.validate(); err != nil {
+		return err
+	}
+	// Options about fields, records or the loading path have nothing to act on in maps
+	if b.writeBack || b.runHooks || b.associations || b.partition != nil || b.copy || b.loadData ||
+		len(b.includeColumns) > 0 || len(b.nullBlankColumns) > 0 {
+		return errors.New("maps can not be combined with write back, hooks, associations, partition, copy, load data, " +
+			"include columns or null blank columns, which require a slice of struct")
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	rows, err := b.validateRows(rows)
+	if err != nil {
+		return err
+	}
+	rows, _, err = b.dedupeRows(rows)
+	if err != nil {
+		return err
+	}
+
+	chunks := splitRows(rows, b.safeChunkSize(db, len(rows[0]), 0))
+	sizes := make([]int, len(chunks))
+	for i := range chunks {
+		sizes[i] = len(chunks[i])
+	}
+
+	stmts := newStatements()
+	defer stmts.close()
+
+	insert := func(db *gorm.DB, offset, count int) (int64, error) {
+		return b.insertRows(ctx, db, stmts, db.Table(table).NewScope(nil), rows[offset:offset+count], nil)
+	}
+	probe := func(db *gorm.DB, offset, count int) error {
+		_, err := insert(db, offset, count)
+		return err
+	}
+	_, err = b.execChunks(ctx, db, sizes, insert, probe)
+	return err
+}
+
+// Drop excluded columns, apply the transform and make sure every row has the keys of the first one
+func (b *Builder) validateRows(rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	filtered := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		filtered[i] = make(map[string]interface{}, len(row))
+		for key, value := range row {
+			if !containString(b.excludeColumns, key) {
+				filtered[i][key] = value
+			}
+		}
+		if b.transform != nil {
+			if err := b.transform(i, filtered[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	keys := sortedKeys(filtered[0])
+	if len(keys) == 0 {
+		return nil, errors.New("rows must have at least one column")
+	}
+	for i, row := range filtered {
+		if len(row) != len(keys) {
+			return nil, fmt.Errorf("row %d has %d columns, expected %d", i, len(row), len(keys))
+		}
+		for _, key := range keys {
+			if _, ok := row[key]; !ok {
+				return nil, fmt.Errorf("row %d is missing column %s", i, key)
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// Separate rows into several size
+func splitRows(rows []map[string]interface{}, size int) [][]map[string]interface{} {
+	var chunkSet [][]map[string]interface{}
+	for len(rows) > size {
+		chunkSet = append(chunkSet, rows[:size])
+		rows = rows[size:]
+	}
+	if len(rows) > 0 {
+		chunkSet = append(chunkSet, rows)
+	}
+	return chunkSet
+}
@@ -0,0 +1,67 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkInsertMaps(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	rows := []map[string]interface{}{
+		{"name": "a", "created_at": "2019-01-01 00:00:00", "ignored": 1},
+		{"name": "b", "created_at": "2019-01-02 00:00:00", "ignored": 2},
+		{"name": "c", "created_at": "2019-01-03 00:00:00", "ignored": 3},
+	}
+	assert.NoError(t, BulkInsertMaps(db, "write_back_dbs", rows, ChunkSizeOpt(2), ExcludeColumnsOpt([]string{"ignored"})))
+
+	var stored []writeBackDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Len(t, stored, 3)
+	assert.Equal(t, "c", stored[2].Name)
+}
+
+func TestBulkInsertMaps_unsupportedOpts(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	rows := []map[string]interface{}{{"name": "a"}}
+	for _, opt := range []BuilderOpt{
+		WriteBackOpt(true),
+		RunHooksOpt(true),
+		AssociationsOpt(true),
+		PartitionOpt(func(interface{}) string { return "write_back_dbs" }),
+		CopyOpt(true),
+		LoadDataOpt(true),
+		IncludeColumnsOpt([]string{"name"}),
+		NullBlankColumnsOpt([]string{"name"}),
+	} {
+		assert.Error(t, BulkInsertMaps(db, "write_back_dbs", rows, opt))
+	}
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+}
+
+func TestBuilder_validateRows(t *testing.T) {
+	_, err := NewBuilder().validateRows([]map[string]interface{}{{"a": 1, "b": 2}, {"a": 1, "c": 2}})
+	assert.EqualError(t, err, "row 1 is missing column b")
+
+	_, err = NewBuilder().validateRows([]map[string]interface{}{{"a": 1}, {"a": 1, "b": 2}})
+	assert.EqualError(t, err, "row 1 has 2 columns, expected 1")
+
+	rows, err := NewBuilder(ExcludeColumnsOpt([]string{"b"})).validateRows([]map[string]interface{}{{"a": 1, "b": 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{"a": 1}}, rows)
+}
+
+func Test_splitRows(t *testing.T) {
+	rows := make([]map[string]interface{}, 5)
+
+	chunks := splitRows(rows, 2)
+	assert.Len(t, chunks, 3)
+	assert.Len(t, chunks[2], 1)
+}
@@ -0,0 +1,106 @@
+package bulk_insert
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SQL Server has neither REPLACE nor ON CONFLICT, upserts and ignored conflicts are written as MERGE.
+// Rows conflicting with each other within a chunk make MERGE fail, see DedupeByOpt.
+func (b *Builder) mergeStatement(scope *gorm.Scope, keys []string, placeholders []string) (string, error) {
+	var conflictColumns, updateColumns []string
+	if !b.ignoreConflicts {
+		var err error
+		if b.replace {
+			conflictColumns, updateColumns, err = upsertColumns(scope, b.conflictColumns, nil, nil, keys)
+		} else {
+			conflictColumns, updateColumns, err = upsertColumns(scope, b.conflictColumns, b.updateColumns, b.ignoreColumns, keys)
+		}
+		if err != nil {
+			return "", err
+		}
+	} else if conflictColumns = b.conflictColumns; len(conflictColumns) == 0 {
+		for _, field := range scope.PrimaryFields() {
+			conflictColumns = append(conflictColumns, field.DBName)
+		}
+	}
+	if len(conflictColumns) == 0 {
+		return "", fmt.Errorf("merge on %s requires conflict columns", scope.TableName())
+	}
+
+	target, source := scope.Quote("target"), scope.Quote("source")
+	columns := make([]string, 0, len(keys))
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		quoted := scope.Quote(gorm.ToColumnName(key))
+		columns = append(columns, quoted)
+		values = append(values, source+"."+quoted)
+	}
+	conditions := make([]string, 0, len(conflictColumns))
+	for _, column := range conflictColumns {
+		quoted := scope.Quote(column)
+		conditions = append(conditions, fmt.Sprintf("%s.%s = %s.%s", target, quoted, source, quoted))
+	}
+
+	var matched string
+	if len(updateColumns) > 0 {
+		assignments := make([]string, 0, len(updateColumns))
+		for _, column := range updateColumns {
+			quoted := scope.Quote(column)
+			assignments = append(assignments, fmt.Sprintf("%s.%s = %s.%s", target, quoted, source, quoted))
+		}
+		matched = " WHEN MATCHED THEN UPDATE SET " + strings.Join(assignments, ", ")
+	}
+
+	return fmt.Sprintf("MERGE INTO %s AS %s USING (VALUES %s) AS %s (%s) ON %s%s WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		scope.QuotedTableName(),
+		target,
+		strings.Join(placeholders, ", "),
+		source,
+		strings.Join(columns, ", "),
+		strings.Join(conditions, " AND "),
+		matched,
+		strings.Join(columns, ", "),
+		strings.Join(values, ", "),
+	), nil
+}
+
+// Allow explicit values for the identity column while query runs when the inserted columns contain it.
+// IDENTITY_INSERT is a session setting, so it is switched off again even when query fails.
+func identityInsert(scope *gorm.Scope, keys []string, query string) string {
+	column := identityColumn(scope)
+	if column == "" {
+		return query
+	}
+
+	for _, key := range keys {
+		if gorm.ToColumnName(key) == column {
+			table := scope.QuotedTableName()
+			return fmt.Sprintf("SET IDENTITY_INSERT %s ON; BEGIN TRY %s; END TRY BEGIN CATCH SET IDENTITY_INSERT %s OFF; THROW; END CATCH; SET IDENTITY_INSERT %s OFF;",
+				table, strings.TrimSuffix(query, ";"), table, table)
+		}
+	}
+	return query
+}
+
+// Column that gorm creates as IDENTITY on SQL Server: a single integer primary key without auto_increment:false
+func identityColumn(scope *gorm.Scope) string {
+	fields := scope.PrimaryFields()
+	if len(fields) != 1 {
+		return ""
+	}
+	field := fields[0]
+	if value, ok := field.TagSettingsGet("AUTO_INCREMENT"); ok && strings.ToLower(value) == "false" {
+		return ""
+	}
+
+	switch field.Struct.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return field.DBName
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package bulk_insert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type identityDB struct {
+	ID   int
+	Name string
+}
+
+func TestBuilder_Exec_mssql(t *testing.T) {
+	merge := "MERGE INTO [identity_dbs] AS [target] USING (VALUES (?, ?), (?, ?)) AS [source] ([id], [name]) ON [target].[id] = [source].[id]%s " +
+		"WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES ([source].[id], [source].[name])"
+	update := " WHEN MATCHED THEN UPDATE SET [target].[name] = [source].[name]"
+	identity := "SET IDENTITY_INSERT [identity_dbs] ON; BEGIN TRY %s; END TRY BEGIN CATCH SET IDENTITY_INSERT [identity_dbs] OFF; THROW; END CATCH; SET IDENTITY_INSERT [identity_dbs] OFF;"
+
+	tests := []struct {
+		name     string
+		opts     []BuilderOpt
+		objects  []identityDB
+		expected string
+	}{
+		{"generated keys", nil, []identityDB{{Name: "a"}, {Name: "b"}}, "INSERT INTO [identity_dbs] ([name]) VALUES (?), (?)"},
+		{"provided keys", nil, []identityDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, fmt.Sprintf(identity, "INSERT INTO [identity_dbs] ([id], [name]) VALUES (?, ?), (?, ?)")},
+		{"upsert", []BuilderOpt{UpsertOpt(nil, nil)}, []identityDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, fmt.Sprintf(identity, fmt.Sprintf(merge, update))},
+		{"replace", []BuilderOpt{ReplaceOpt(true)}, []identityDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, fmt.Sprintf(identity, fmt.Sprintf(merge, update))},
+		{"ignore conflicts", []BuilderOpt{IgnoreConflictsOpt(true)}, []identityDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, fmt.Sprintf(identity, fmt.Sprintf(merge, ""))},
+	}
+	for _, test := range tests {
+		db, common := openRecorder(t, "mssql")
+		assert.NoError(t, NewBuilder(test.opts...).Exec(db, test.objects), test.name)
+		assert.Equal(t, []string{test.expected}, common.queries, test.name)
+	}
+
+	// Keys that are not identities are inserted as is
+	db, common := openRecorder(t, "mssql")
+	assert.NoError(t, NewBuilder().Exec(db, []xidDB{{ID: "a", Name: "first"}}))
+	assert.Equal(t, []string{"INSERT INTO [xid_dbs] ([id], [name]) VALUES (?, ?)"}, common.queries)
+}
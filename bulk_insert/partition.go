@@ -0,0 +1,110 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Insert objects grouped by the table returned by the partition function, one partition after the other
+func (b *Builder) runPartitions(ctx context.Context, db *gorm.DB, objects interface{}) (*Result, error) {
+	value := reflect.ValueOf(objects)
+	if value.Kind() != reflect.Slice {
+		return nil, errors.New("objects must be a slice")
+	}
+	elems, err := indirectElems(value)
+	if err != nil {
+		return nil, err
+	}
+	tables, partitions, indexes, err := b.partitionElems(addressable(elems))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	run := func(db *gorm.DB) error {
+		var failures BulkErrors
+		for _, table := range tables {
+			partition := *b
+			partition.partition = nil
+			partition.table = table
+
+			partResult, err := partition.run(ctx, db, partitions[table].Interface())
+			if err != nil {
+				err = remapBulkErrors(err, indexes[table])
+				// Failed chunks of every partition are gathered, other errors abort
+				if errs, ok := err.(BulkErrors); ok && b.continueOnError {
+					failures = append(failures, errs...)
+					continue
+				}
+				return err
+			}
+			result.RowsAffected += partResult.RowsAffected
+			result.Duplicates += partResult.Duplicates
+		}
+		if len(failures) > 0 {
+			return failures
+		}
+		return nil
+	}
+
+	if b.atomic {
+		err = inTransaction(ctx, db, run)
+	} else {
+		err = run(db)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Group elems, which are addressable, by the table returned by the partition function, in order of first appearance,
+// along with the indexes of the elems of each partition. Partitions are slices of pointers, so that written back keys
+// reach the objects.
+func (b *Builder) partitionElems(elems []reflect.Value) ([]string, map[string]reflect.Value, map[string][]int, error) {
+	var tables []string
+	partitions := map[string]reflect.Value{}
+	indexes := map[string][]int{}
+	for i, elem := range elems {
+		table := b.partition(elem.Addr().Interface())
+		if table == "" {
+			return nil, nil, nil, errors.New("partition returned an empty table name")
+		}
+		partition, ok := partitions[table]
+		if !ok {
+			tables = append(tables, table)
+			partition = reflect.MakeSlice(reflect.SliceOf(elem.Addr().Type()), 0, 1)
+		}
+		partitions[table] = reflect.Append(partition, elem.Addr())
+		indexes[table] = append(indexes[table], i)
+	}
+	return tables, partitions, indexes, nil
+}
+
+// Map the offsets and rows of the chunk failures of a partition to the indexes of the objects given to Run
+func remapBulkErrors(err error, indexes []int) error {
+	remap := func(bulkErr *BulkError) {
+		bulkErr.Offset = indexes[bulkErr.Offset]
+		if bulkErr.Row >= 0 {
+			bulkErr.Row = indexes[bulkErr.Row]
+		}
+	}
+	switch e := err.(type) {
+	case *BulkError:
+		remap(e)
+	case BulkErrors:
+		for _, bulkErr := range e {
+			remap(bulkErr)
+		}
+	case gorm.Errors:
+		for _, err := range e {
+			if bulkErr, ok := err.(*BulkError); ok {
+				remap(bulkErr)
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package bulk_insert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_Run_partition(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	for _, table := range []string{"events_a", "events_b"} {
+		assert.NoError(t, db.Table(table).AutoMigrate(&writeBackDB{}).Error)
+	}
+
+	partition := func(obj interface{}) string {
+		return "events_" + obj.(*writeBackDB).Name[:1]
+	}
+	objects := []writeBackDB{{Name: "a1"}, {Name: "b1"}, {Name: "a2"}}
+	result, err := NewBuilder(PartitionOpt(partition), WriteBackOpt(true), AtomicOpt(true)).Run(context.Background(), db, objects)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), result.RowsAffected)
+	assert.Equal(t, []int{1, 1, 2}, []int{objects[0].ID, objects[1].ID, objects[2].ID})
+
+	var names []string
+	assert.NoError(t, db.Table("events_a").Order("id").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"a1", "a2"}, names)
+}
+
+func TestBuilder_Run_partitionContinueOnError(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	for _, table := range []string{"events_a", "events_b"} {
+		assert.NoError(t, db.Table(table).AutoMigrate(&writeBackDB{}).Error)
+		assert.NoError(t, db.Table(table).Create(&writeBackDB{ID: 1, Name: "x"}).Error)
+	}
+
+	partition := func(obj interface{}) string {
+		return "events_" + obj.(*writeBackDB).Name[:1]
+	}
+	objects := []writeBackDB{{Name: "a1"}, {Name: "b1"}, {ID: 1, Name: "b2"}, {ID: 1, Name: "a2"}, {Name: "b3"}}
+	_, err := NewBuilder(PartitionOpt(partition), ChunkSizeOpt(1), ContinueOnErrorOpt(true)).Run(context.Background(), db, objects)
+
+	errs, ok := err.(BulkErrors)
+	if assert.True(t, ok, "%v", err) && assert.Len(t, errs, 2) {
+		assert.Equal(t, 3, errs[0].Offset)
+		assert.Equal(t, 2, errs[1].Offset)
+	}
+
+	var count int
+	assert.NoError(t, db.Table("events_b").Count(&count).Error)
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,169 @@
+package bulk_insert
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// fieldPlan holds what extractMapValue needs to know about a column, computed once per model type
+type fieldPlan struct {
+	name         string
+	dbName       string
+	index        [][]int // Index of each field on the way to an embedded field
+	isPrimaryKey bool
+	isTimestamp  bool
+	isString     bool
+	nullBlank    bool
+	hasDefault   bool
+	defaultValue interface{}
+	valuer       bool // The field type implements driver.Valuer
+	ptrValuer    bool // Only a pointer to the field implements driver.Valuer
+	randomized   bool // The field implements Randomized, it is compared as is
+	dialect      bool // The field implements DialectValuer, it is converted once the dialect is known
+}
+
+type modelPlan struct {
+	fields      []*fieldPlan
+	names       map[string]bool // Field and column names of every field, relations included
+	primaryKeys int
+	needsAddr   bool // Some field is a pointer receiver Valuer, which requires an addressable struct
+}
+
+var modelPlans sync.Map
+
+// Obtain the cached plan of the struct type typ
+func planOf(typ reflect.Type) *modelPlan {
+	if plan, ok := modelPlans.Load(typ); ok {
+		return plan.(*modelPlan)
+	}
+
+	modelStruct := (&gorm.Scope{Value: reflect.New(typ).Interface()}).GetModelStruct()
+	plan := &modelPlan{primaryKeys: len(modelStruct.PrimaryFields), names: map[string]bool{}}
+	for _, field := range modelStruct.StructFields {
+		plan.names[field.Name] = true
+		plan.names[field.DBName] = true
+
+		// Exclude relational record because it's not directly contained in database columns
+		_, hasForeignKey := field.TagSettingsGet("FOREIGNKEY")
+		isColumn := isValuer(field.Struct.Type) || (field.Relationship == nil && !hasForeignKey)
+		if !isColumn || field.IsIgnored {
+			continue
+		}
+
+		f := &fieldPlan{
+			name:         field.Name,
+			dbName:       field.DBName,
+			index:        fieldIndexes(typ, field.Names),
+			isPrimaryKey: field.IsPrimaryKey,
+			isTimestamp:  field.Name == "CreatedAt" || field.Name == "UpdatedAt",
+			isString:     field.Struct.Type.Kind() == reflect.String,
+			valuer:       field.Struct.Type.Implements(valuerType),
+		}
+		f.ptrValuer = !f.valuer && reflect.PtrTo(field.Struct.Type).Implements(valuerType)
+		f.randomized = reflect.PtrTo(field.Struct.Type).Implements(randomizedType)
+		f.dialect = field.Struct.Type.Implements(dialectValuerType)
+		_, f.nullBlank = field.TagSettingsGet("NULL_BLANK")
+		if val, ok := field.TagSettingsGet("DEFAULT"); ok && field.HasDefaultValue {
+			f.hasDefault, f.defaultValue = true, defaultValue(val)
+		}
+
+		plan.fields = append(plan.fields, f)
+		plan.needsAddr = plan.needsAddr || f.ptrValuer
+	}
+
+	actual, _ := modelPlans.LoadOrStore(typ, plan)
+	return actual.(*modelPlan)
+}
+
+// Whether column names the field by its struct field name or column name, either as is or converted by the naming strategy
+func (f *fieldPlan) matches(column string) bool {
+	return f.name == column || f.dbName == column || f.dbName == gorm.ToColumnName(column)
+}
+
+func (f *fieldPlan) matchesAny(columns []string) bool {
+	for _, column := range columns {
+		if f.matches(column) {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether column names a field of the model
+func (p *modelPlan) has(column string) bool {
+	return p.names[column] || p.names[gorm.ToColumnName(column)]
+}
+
+// Struct field indexes following names through embedded structs
+func fieldIndexes(typ reflect.Type, names []string) [][]int {
+	indexes := make([][]int, 0, len(names))
+	for _, name := range names {
+		for typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		field, _ := typ.FieldByName(name)
+		indexes = append(indexes, field.Index)
+		typ = field.Type
+	}
+	return indexes
+}
+
+// Value of the field within the struct value, nil embedded pointers read as zero values
+func (f *fieldPlan) field(value reflect.Value) reflect.Value {
+	for i, index := range f.index {
+		if i > 0 {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					value = reflect.Zero(value.Type().Elem())
+				} else {
+					value = value.Elem()
+				}
+			}
+		}
+		value = value.FieldByIndex(index)
+	}
+	return value
+}
+
+// Value of field to be sent to the database, calling driver.Valuer when implemented.
+// DialectValuers are returned as they are, see dialectValues.
+func (f *fieldPlan) value(field reflect.Value) (interface{}, error) {
+	switch {
+	case f.dialect:
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			return nil, nil
+		}
+		return field.Interface(), nil
+	case f.valuer:
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			return nil, nil
+		}
+		return field.Interface().(driver.Valuer).Value()
+	case f.ptrValuer && field.CanAddr():
+		return field.Addr().Interface().(driver.Valuer).Value()
+	}
+	return field.Interface(), nil
+}
+
+// Same as gorm's blank check
+func isBlank(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+
+	return reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface())
+}
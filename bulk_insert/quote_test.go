@@ -0,0 +1,37 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	_ "github.com/jinzhu/gorm/dialects/mssql"
+	"github.com/stretchr/testify/assert"
+)
+
+// Columns named after reserved words
+type reservedDB struct {
+	ID    int
+	Order int
+	Group string
+	Desc  string
+}
+
+func TestBuilder_Exec_quoting(t *testing.T) {
+	expected := map[string]string{
+		"mysql":    "INSERT INTO `reserved_dbs` (`desc`, `group`, `order`) VALUES (?, ?, ?), (?, ?, ?)",
+		"postgres": `INSERT INTO "reserved_dbs" ("desc", "group", "order") VALUES ($1, $2, $3), ($4, $5, $6)`,
+		"sqlite3":  `INSERT INTO "reserved_dbs" ("desc", "group", "order") VALUES (?, ?, ?), (?, ?, ?)`,
+		"mssql":    "INSERT INTO [reserved_dbs] ([desc], [group], [order]) VALUES (?, ?, ?), (?, ?, ?)",
+	}
+
+	for dialect, query := range expected {
+		db, common := openRecorder(t, dialect)
+		assert.NoError(t, NewBuilder().Exec(db, []reservedDB{{Order: 1}, {Order: 2}}), dialect)
+		assert.Equal(t, []string{query}, common.queries, dialect)
+	}
+}
+
+func TestBuilder_ExecUpdate_quoting(t *testing.T) {
+	db, common := openRecorder(t, "mysql")
+	assert.NoError(t, NewBuilder().ExecUpdate(db, []reservedDB{{ID: 1, Order: 2}}, "order"))
+	assert.Equal(t, []string{"UPDATE `reserved_dbs` SET `order` = CASE `id` WHEN ? THEN ? END WHERE `id` IN (?)"}, common.queries)
+}
@@ -0,0 +1,31 @@
+package bulk_insert
+
+import (
+	"context"
+	"time"
+
+	"github.com/cochainio/orm/internal/dberr"
+	"github.com/jinzhu/gorm"
+)
+
+// Run fn until it succeeds, fails with a non retryable error, see dberr.IsRetryable, or the attempts are exhausted
+func (b *Builder) retry(ctx context.Context, db *gorm.DB, fn func() error) error {
+	if transaction(db) != nil || b.retryAttempts <= 1 {
+		return fn()
+	}
+
+	backoff := b.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= b.retryAttempts || !dberr.IsRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,37 @@
+package bulk_insert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_retry(t *testing.T) {
+	db := openFake(t, "mysql")
+
+	calls := 0
+	deadlock := func() error {
+		calls++
+		if calls < 3 {
+			return &mysql.MySQLError{Number: 1213}
+		}
+		return nil
+	}
+	assert.NoError(t, NewBuilder(RetryOpt(3, time.Millisecond)).retry(context.Background(), db, deadlock))
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	assert.Error(t, NewBuilder(RetryOpt(2, time.Millisecond)).retry(context.Background(), db, deadlock))
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	duplicate := func() error {
+		calls++
+		return &mysql.MySQLError{Number: 1062}
+	}
+	assert.Error(t, NewBuilder(RetryOpt(3, time.Millisecond)).retry(context.Background(), db, duplicate))
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,56 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ExecSave inserts the objects whose primary key is blank and updates every column of the others, in one transaction.
+// [objects] Must be a slice of struct
+func (b *Builder) ExecSave(db *gorm.DB, objects interface{}) error {
+	value := reflect.ValueOf(objects)
+	if value.Kind() != reflect.Slice {
+		return errors.New("objects must be a slice")
+	}
+	elems, err := indirectElems(value)
+	if err != nil {
+		return err
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+
+	// Partition as pointers, so that generated keys are set on the caller's objects
+	elems = addressable(elems)
+	sliceType := reflect.SliceOf(reflect.PtrTo(elems[0].Type()))
+	created := reflect.MakeSlice(sliceType, 0, len(elems))
+	updated := reflect.MakeSlice(sliceType, 0, len(elems))
+	for _, elem := range elems {
+		if db.NewScope(elem.Addr().Interface()).PrimaryKeyZero() {
+			created = reflect.Append(created, elem.Addr())
+		} else {
+			updated = reflect.Append(updated, elem.Addr())
+		}
+	}
+
+	ctx := context.Background()
+	return inTransaction(ctx, db, func(tx *gorm.DB) error {
+		if created.Len() > 0 {
+			if err := b.ExecContext(ctx, tx, created.Interface()); err != nil {
+				return err
+			}
+		}
+		if updated.Len() > 0 {
+			return b.ExecUpdate(tx, updated.Interface())
+		}
+		return nil
+	})
+}
+
+// Insert new and update existing records at once, see Builder.ExecSave
+func BulkSave(db *gorm.DB, objects interface{}, opts ...BuilderOpt) error {
+	return NewBuilder(opts...).ExecSave(db, objects)
+}
@@ -0,0 +1,24 @@
+package bulk_insert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_ExecSave(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	existing := []*writeBackDB{{Name: "a"}, {Name: "b"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).Exec(db, existing))
+
+	objects := []writeBackDB{*existing[0], {Name: "c"}}
+	objects[0].Name = "renamed"
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).ExecSave(db, objects))
+	assert.NotZero(t, objects[1].ID)
+
+	var names []string
+	assert.NoError(t, db.Model(&writeBackDB{}).Order("id").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"renamed", "b", "c"}, names)
+}
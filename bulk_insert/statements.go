@@ -0,0 +1,107 @@
+package bulk_insert
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+type preparedKey struct {
+	common gorm.SQLCommon
+	query  string
+}
+
+// statements caches the SQL generated for a shape of chunk during one bulk operation,
+// and prepares a statement once the same SQL is executed again.
+// Chunks of full size thus share one prepared statement, while a trailing partial chunk is executed once as is.
+type statements struct {
+	mu       sync.Mutex
+	sql      map[string]string
+	used     map[preparedKey]bool
+	prepared map[preparedKey]*sql.Stmt
+}
+
+func newStatements() *statements {
+	return &statements{
+		sql:      map[string]string{},
+		used:     map[preparedKey]bool{},
+		prepared: map[preparedKey]*sql.Stmt{},
+	}
+}
+
+// Return the SQL cached under key, calling build on a miss
+func (s *statements) query(key string, build func() (string, error)) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if query, ok := s.sql[key]; ok {
+		return query, nil
+	}
+	query, err := build()
+	if err != nil {
+		return "", err
+	}
+	s.sql[key] = query
+	return query, nil
+}
+
+// Execute query like execSQL, through a prepared statement when it has been executed before on the same connection
+func (s *statements) exec(ctx context.Context, db *gorm.DB, query string, vars []interface{}) (int64, error) {
+	stmt, err := s.stmt(ctx, db, query)
+	if err != nil {
+		return 0, err
+	}
+	if stmt == nil {
+		return execSQL(ctx, db, query, vars)
+	}
+
+	result, err := stmt.ExecContext(ctx, vars...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *statements) stmt(ctx context.Context, db *gorm.DB, query string) (*sql.Stmt, error) {
+	common := db.CommonDB()
+	p, ok := common.(preparer)
+	if !ok {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := preparedKey{common: common, query: query}
+	if stmt, ok := s.prepared[key]; ok {
+		return stmt, nil
+	}
+	if !s.used[key] {
+		s.used[key] = true
+		return nil, nil
+	}
+
+	stmt, err := p.PrepareContext(ctx, rebind(db.Dialect(), query))
+	if err != nil {
+		return nil, err
+	}
+	s.prepared[key] = stmt
+	return stmt, nil
+}
+
+// Release every prepared statement, to be called once the bulk operation is over
+func (s *statements) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, stmt := range s.prepared {
+		stmt.Close()
+		delete(s.prepared, key)
+	}
+}
@@ -0,0 +1,32 @@
+package bulk_insert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_statements(t *testing.T) {
+	db := openSQLite(t, &uniqueDB{})
+	defer db.Close()
+
+	stmts := newStatements()
+	builder := NewBuilder(ChunkSizeOpt(2))
+	objects := []interface{}{uniqueDB{Name: "a"}, uniqueDB{Name: "b"}, uniqueDB{Name: "c"}, uniqueDB{Name: "d"}, uniqueDB{Name: "e"}}
+	for _, chunk := range splitObjects(objects, 2) {
+		rowsAffected, err := builder.insertObjSet(context.Background(), db, stmts, 0, chunk, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(chunk)), rowsAffected)
+	}
+
+	// the full size chunks share a prepared statement, the trailing one is executed once without preparing
+	assert.Len(t, stmts.sql, 2)
+	assert.Len(t, stmts.prepared, 1)
+	stmts.close()
+	assert.Len(t, stmts.prepared, 0)
+
+	var count int
+	assert.NoError(t, db.Model(&uniqueDB{}).Count(&count).Error)
+	assert.Equal(t, 5, count)
+}
@@ -0,0 +1,176 @@
+package bulk_insert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ExecUpdate updates records identified by their primary keys with one UPDATE ... CASE statement per chunk
+// [objects] Must be a slice of struct
+// [columns] Struct field or column names to update. All columns but the primary key and CreatedAt are updated if omitted.
+func (b *Builder) ExecUpdate(db *gorm.DB, objects interface{}, columns ...string) error {
+	db = b.target(db)
+	value := reflect.ValueOf(objects)
+	if value.Kind() != reflect.Slice {
+		return errors.New("objects must be a slice")
+	}
+	elems, err := indirectElems(value)
+	if err != nil {
+		return err
+	}
+	objectInterfaces := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		objectInterfaces[i] = elem.Interface()
+	}
+
+	if len(objectInterfaces) == 0 {
+		return nil
+	}
+	fields, err := updatableFields(db.NewScope(objectInterfaces[0]), columns)
+	if err != nil {
+		return err
+	}
+
+	// Each CASE binds the key and the value, plus one key for IN
+	for _, objSet := range splitObjects(objectInterfaces, b.safeChunkSize(db, 2*len(fields)+1, 0)) {
+		if err := updateObjSet(db, objSet, columns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Update multiple records at once, see Builder.ExecUpdate
+func BulkUpdate(db *gorm.DB, objects interface{}, chunkSize int, columns ...string) error {
+	return NewBuilder(ChunkSizeOpt(chunkSize)).ExecUpdate(db, objects, columns...)
+}
+
+func updateObjSet(db *gorm.DB, objects []interface{}, columns []string) error {
+	if len(objects) == 0 {
+		return nil
+	}
+
+	mainScope := db.NewScope(objects[0])
+	if len(mainScope.PrimaryFields()) != 1 {
+		return errors.New("bulk update requires exactly one primary key")
+	}
+	pk := mainScope.Quote(mainScope.PrimaryKey())
+
+	fields, err := updatableFields(mainScope, columns)
+	if err != nil {
+		return err
+	}
+
+	now := gorm.NowFunc()
+	ids := make([]interface{}, 0, len(objects))
+	whens := make([][]string, len(fields))
+	vars := make([][]interface{}, len(fields))
+	for _, obj := range objects {
+		scope := db.NewScope(obj)
+		if scope.PrimaryKeyZero() {
+			return errors.New("bulk update requires primary keys to be set")
+		}
+		id := scope.PrimaryKeyValue()
+		ids = append(ids, id)
+
+		for i, f := range fields {
+			field, _ := scope.FieldByName(f.Name)
+			value, err := dialectFieldValue(db.Dialect().GetName(), field)
+			if err != nil {
+				return err
+			}
+			if f.Name == "UpdatedAt" {
+				value = now
+			}
+			whens[i] = append(whens[i], "WHEN ? THEN "+castPlaceholder(mainScope, f))
+			vars[i] = append(vars[i], id, value)
+		}
+	}
+
+	assignments := make([]string, 0, len(fields))
+	var sqlVars []interface{}
+	for i, f := range fields {
+		assignments = append(assignments, fmt.Sprintf("%s = CASE %s %s END", mainScope.Quote(f.DBName), pk, strings.Join(whens[i], " ")))
+		sqlVars = append(sqlVars, vars[i]...)
+	}
+	sqlVars = append(sqlVars, ids...)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s IN (%s)",
+		mainScope.QuotedTableName(),
+		strings.Join(assignments, ", "),
+		pk,
+		strings.Repeat("?, ", len(ids)-1)+"?",
+	)
+	_, err = execSQL(context.Background(), db, query, sqlVars)
+	return err
+}
+
+// Collect the fields to update, UpdatedAt is always refreshed like gorm does
+func updatableFields(scope *gorm.Scope, columns []string) ([]*gorm.Field, error) {
+	var fields []*gorm.Field
+	matched := map[string]bool{}
+	for _, field := range scope.Fields() {
+		_, hasForeignKey := field.TagSettingsGet("FOREIGNKEY")
+		if field.IsIgnored || field.IsPrimaryKey || field.Relationship != nil || hasForeignKey || field.Name == "CreatedAt" {
+			continue
+		}
+
+		if len(columns) > 0 && field.Name != "UpdatedAt" {
+			if !containString(columns, field.Name) && !containString(columns, field.DBName) {
+				continue
+			}
+			matched[field.Name] = true
+			matched[field.DBName] = true
+		}
+		fields = append(fields, field)
+	}
+
+	for _, column := range columns {
+		if !matched[column] && column != "UpdatedAt" {
+			return nil, fmt.Errorf("column %s can not be updated", column)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("no columns to update")
+	}
+	return fields, nil
+}
+
+// Postgres resolves untyped CASE results as text, so values are cast to the column type
+func castPlaceholder(scope *gorm.Scope, field *gorm.Field) string {
+	if scope.Dialect().GetName() != "postgres" {
+		return "?"
+	}
+	if typ := columnType(scope.Dialect(), field.StructField); typ != "" {
+		return "CAST(? AS " + typ + ")"
+	}
+	return "?"
+}
+
+// columnType returns the bare SQL type of the field, without the NOT NULL, UNIQUE,
+// DEFAULT and COMMENT clauses of its column definition, or "" when it has none
+func columnType(dialect gorm.Dialect, field *gorm.StructField) (typ string) {
+	_, typ, _, extra := gorm.ParseFieldStructForDialect(field, dialect)
+	if typ != "" {
+		return typ
+	}
+	defer func() {
+		// Dialects panic on struct Valuers without a type tag
+		if recover() != nil {
+			typ = ""
+		}
+	}()
+	typ = strings.TrimSpace(strings.TrimSuffix(dialect.DataTypeOf(field), extra))
+	switch typ {
+	case "serial":
+		return "integer"
+	case "bigserial":
+		return "bigint"
+	}
+	return typ
+}
@@ -0,0 +1,70 @@
+package bulk_insert
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder_ExecUpdate(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).Exec(db, objects))
+
+	for i := range objects {
+		objects[i].Name += "!"
+	}
+	assert.NoError(t, NewBuilder(ChunkSizeOpt(2)).ExecUpdate(db, objects, "name"))
+
+	var stored []writeBackDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, []string{"a!", "b!", "c!"}, []string{stored[0].Name, stored[1].Name, stored[2].Name})
+
+	assert.Error(t, BulkUpdate(db, objects, 10, "Unknown"))
+	assert.Error(t, BulkUpdate(db, []writeBackDB{{Name: "new"}}, 10))
+}
+
+func Test_updatableFields(t *testing.T) {
+	scope := openFake(t, "postgres").NewScope(fakeDB{})
+
+	fields, err := updatableFields(scope, []string{"Name", "email"})
+	assert.NoError(t, err)
+
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.DBName)
+	}
+	assert.Equal(t, []string{"name", "email", "updated_at"}, names)
+	assert.Equal(t, "CAST(? AS text)", castPlaceholder(scope, fields[0]))
+}
+
+type castDB struct {
+	ID     int
+	Status string `gorm:"not null;default:'active'"`
+	Code   string `gorm:"type:varchar(8);unique"`
+	Point  point
+}
+
+// point is a struct Valuer without a type tag
+type point struct{ X, Y int }
+
+func (p point) Value() (driver.Value, error) { return fmt.Sprintf("(%d,%d)", p.X, p.Y), nil }
+
+func TestBuilder_ExecUpdate_postgresCast(t *testing.T) {
+	db, common := openRecorder(t, "postgres")
+
+	objects := []castDB{{ID: 1, Status: "a", Code: "x"}}
+	assert.NoError(t, NewBuilder().ExecUpdate(db, objects, "status", "code", "point"))
+	if assert.Len(t, common.queries, 1) {
+		query := common.queries[0]
+		assert.Contains(t, query, "THEN CAST($2 AS text)")
+		assert.Contains(t, query, "THEN CAST($4 AS varchar(8))")
+		assert.Contains(t, query, "THEN $6")
+		assert.NotContains(t, query, "NOT NULL")
+		assert.NotContains(t, query, "DEFAULT")
+	}
+}
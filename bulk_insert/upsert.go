@@ -0,0 +1,71 @@
+package bulk_insert
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Build the dialect specific clause appended to INSERT to update conflicting rows.
+// Conflicts default to the primary key, ignored columns are never overwritten.
+func upsertClause(scope *gorm.Scope, conflictColumns, updateColumns, ignoreColumns, insertColumns []string) (string, error) {
+	conflictColumns, updateColumns, err := upsertColumns(scope, conflictColumns, updateColumns, ignoreColumns, insertColumns)
+	if err != nil {
+		return "", err
+	}
+
+	switch dialect := scope.Dialect().GetName(); dialect {
+	case "mysql":
+		assignments := make([]string, 0, len(updateColumns))
+		for _, column := range updateColumns {
+			quoted := scope.Quote(column)
+			assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", quoted, quoted))
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", "), nil
+	case "postgres", "sqlite3":
+		if len(conflictColumns) == 0 {
+			return "", fmt.Errorf("upsert on %s requires conflict columns", dialect)
+		}
+		conflicts := make([]string, 0, len(conflictColumns))
+		for _, column := range conflictColumns {
+			conflicts = append(conflicts, scope.Quote(column))
+		}
+		assignments := make([]string, 0, len(updateColumns))
+		for _, column := range updateColumns {
+			quoted := scope.Quote(column)
+			assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted))
+		}
+		return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+			strings.Join(conflicts, ", "),
+			strings.Join(assignments, ", "),
+		), nil
+	default:
+		return "", fmt.Errorf("upsert is not supported by dialect %s", dialect)
+	}
+}
+
+// Resolve the columns identifying a conflict and the columns overwritten on one
+func upsertColumns(scope *gorm.Scope, conflictColumns, updateColumns, ignoreColumns, insertColumns []string) ([]string, []string, error) {
+	if len(conflictColumns) == 0 {
+		for _, field := range scope.PrimaryFields() {
+			conflictColumns = append(conflictColumns, field.DBName)
+		}
+	}
+
+	candidates := updateColumns
+	if len(candidates) == 0 {
+		candidates = insertColumns
+	}
+	updateColumns = nil
+	for _, column := range candidates {
+		if !containString(conflictColumns, column) && !containString(ignoreColumns, column) {
+			updateColumns = append(updateColumns, column)
+		}
+	}
+	if len(updateColumns) == 0 {
+		return nil, nil, errors.New("upsert requires at least one column to update")
+	}
+	return conflictColumns, updateColumns, nil
+}
@@ -0,0 +1,79 @@
+package bulk_insert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_upsertClause(t *testing.T) {
+	columns := []string{"email", "id", "name"}
+
+	mysqlScope := openFake(t, "mysql").NewScope(fakeDB{})
+	clause, err := upsertClause(mysqlScope, nil, nil, nil, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, " ON DUPLICATE KEY UPDATE `email` = VALUES(`email`), `name` = VALUES(`name`)", clause)
+
+	postgresScope := openFake(t, "postgres").NewScope(fakeDB{})
+	clause, err = upsertClause(postgresScope, nil, nil, nil, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, ` ON CONFLICT ("id") DO UPDATE SET "email" = EXCLUDED."email", "name" = EXCLUDED."name"`, clause)
+
+	clause, err = upsertClause(postgresScope, []string{"email"}, []string{"name"}, nil, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, ` ON CONFLICT ("email") DO UPDATE SET "name" = EXCLUDED."name"`, clause)
+
+	clause, err = upsertClause(postgresScope, nil, nil, []string{"email"}, columns)
+	assert.NoError(t, err)
+	assert.Equal(t, ` ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`, clause)
+
+	// postgres can not infer the constraint without a primary key
+	_, err = upsertClause(openFake(t, "postgres").NewScope(nil), nil, nil, nil, columns)
+	assert.Error(t, err)
+
+	// nothing left to update
+	_, err = upsertClause(postgresScope, nil, nil, []string{"email", "name"}, columns)
+	assert.Error(t, err)
+}
+
+func TestBuilder_Exec_upsert(t *testing.T) {
+	db := openSQLite(t, &xidDB{})
+	defer db.Close()
+
+	assert.NoError(t, NewBuilder().Exec(db, []xidDB{{ID: "a", Name: "first"}}))
+	assert.NoError(t, NewBuilder(ConflictUpdateColumnsOpt([]string{"name"})).Exec(db, []xidDB{{ID: "a", Name: "second"}, {ID: "b", Name: "new"}}))
+
+	var stored []xidDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Equal(t, []xidDB{{ID: "a", Name: "second"}, {ID: "b", Name: "new"}}, stored)
+
+	// every column but the key is preserved, so there is nothing to update
+	assert.Error(t, NewBuilder(ConflictIgnoreColumnsOpt([]string{"name"})).Exec(db, []xidDB{{ID: "a", Name: "third"}}))
+}
+
+func TestBuilder_Run_ignoreConflicts(t *testing.T) {
+	db := openSQLite(t, &uniqueDB{})
+	defer db.Close()
+
+	assert.NoError(t, NewBuilder().Exec(db, []uniqueDB{{Name: "a"}}))
+	result, err := NewBuilder(IgnoreConflictsOpt(true)).Run(context.Background(), db, []uniqueDB{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	var count int
+	assert.NoError(t, db.Model(&uniqueDB{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+
+	_, err = NewBuilder(IgnoreConflictsOpt(true), UpsertOpt(nil, nil)).Run(context.Background(), db, []uniqueDB{{Name: "a"}})
+	assert.Error(t, err)
+
+	for dialect, expected := range map[string]string{
+		"mysql":    "INSERT IGNORE INTO `unique_dbs` (`name`) VALUES (?)",
+		"postgres": `INSERT INTO "unique_dbs" ("name") VALUES ($1) ON CONFLICT DO NOTHING`,
+	} {
+		db, common := openRecorder(t, dialect)
+		assert.NoError(t, NewBuilder(IgnoreConflictsOpt(true)).Exec(db, []uniqueDB{{Name: "a"}}))
+		assert.Equal(t, []string{expected}, common.queries, dialect)
+	}
+}
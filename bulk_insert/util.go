@@ -1,6 +1,15 @@
 package bulk_insert
 
-import "sort"
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
 
 // Separate objects into several size
 func splitObjects(objArr []interface{}, size int) [][]interface{} {
@@ -18,6 +27,99 @@ func splitObjects(objArr []interface{}, size int) [][]interface{} {
 	return chunkSet
 }
 
+// Dereference pointers and interfaces down to the underlying value
+func indirectValue(value reflect.Value) (reflect.Value, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, errors.New("objects must not contain nil")
+		}
+		value = value.Elem()
+	}
+	return value, nil
+}
+
+// Collect the elements of a slice of structs, pointers or interfaces as struct values.
+// Elements reached through pointers stay addressable, so values can be written back.
+func indirectElems(slice reflect.Value) ([]reflect.Value, error) {
+	elems := make([]reflect.Value, slice.Len())
+	for i := range elems {
+		elem, err := indirectValue(slice.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if elem.Kind() != reflect.Struct {
+			return nil, errors.New("objects must be a slice of struct")
+		}
+		elems[i] = elem
+	}
+	return elems, nil
+}
+
+// Type of driver.Valuer, whose implementations are bound as they are
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// Randomized is implemented by the Valuers whose Value differs on every call for the same field, like encrypted ones.
+// Dedupe compares such fields themselves rather than their values.
+type Randomized interface {
+	RandomizedValue()
+}
+
+var randomizedType = reflect.TypeOf((*Randomized)(nil)).Elem()
+
+// DialectValuer is implemented by the types whose database value depends on the dialect,
+// like arrays native on postgres and encoded elsewhere. It takes precedence over driver.Valuer.
+type DialectValuer interface {
+	DialectValue(dialect string) (driver.Value, error)
+}
+
+var dialectValuerType = reflect.TypeOf((*DialectValuer)(nil)).Elem()
+
+// Convert the DialectValuers among the attributes to their value for dialect
+func dialectValues(dialect string, attrs map[string]interface{}) error {
+	for column, value := range attrs {
+		if valuer, ok := value.(DialectValuer); ok {
+			converted, err := valuer.DialectValue(dialect)
+			if err != nil {
+				return err
+			}
+			attrs[column] = converted
+		}
+	}
+	return nil
+}
+
+// Whether values of typ, or pointers to them, implement driver.Valuer
+func isValuer(typ reflect.Type) bool {
+	return typ.Implements(valuerType) || reflect.PtrTo(typ).Implements(valuerType)
+}
+
+// Obtain the value of field to be sent to the database, calling driver.Valuer when implemented
+func fieldValue(field *gorm.Field) (interface{}, error) {
+	value := field.Field
+	if !value.Type().Implements(valuerType) && value.CanAddr() && reflect.PtrTo(value.Type()).Implements(valuerType) {
+		value = value.Addr()
+	}
+	if !value.Type().Implements(valuerType) {
+		return value.Interface(), nil
+	}
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, nil
+	}
+	return value.Interface().(driver.Valuer).Value()
+}
+
+// Value of field for dialect, DialectValuer taking precedence over driver.Valuer
+func dialectFieldValue(dialect string, field *gorm.Field) (interface{}, error) {
+	valuer, ok := field.Field.Interface().(DialectValuer)
+	if !ok {
+		return fieldValue(field)
+	}
+	if field.Field.Kind() == reflect.Ptr && field.Field.IsNil() {
+		return nil, nil
+	}
+	return valuer.DialectValue(dialect)
+}
+
 // Enable map keys to be retrieved in same order when iterating
 func sortedKeys(val map[string]interface{}) []string {
 	var keys []string
@@ -37,3 +139,69 @@ func containString(s []string, value string) bool {
 	}
 	return false
 }
+
+// Maximum number of bind variables a single statement may hold per dialect
+var placeholderLimits = map[string]int{
+	"mysql":    65535,
+	"postgres": 65535,
+	"sqlite3":  999,
+	// sp_executesql takes two of the 2100 parameters itself
+	"mssql": 2098,
+}
+
+// Maximum number of rows of a single VALUES list
+var rowLimits = map[string]int{
+	"mssql": 1000,
+}
+
+// PlaceholderLimit returns the maximum number of bind variables a single statement of dialect may hold
+func PlaceholderLimit(dialect string) int {
+	if limit, ok := placeholderLimits[dialect]; ok {
+		return limit
+	}
+	return placeholderLimits["sqlite3"]
+}
+
+// Largest chunk size not exceeding the configured one that keeps a statement within the placeholder and row limits
+func (b *Builder) safeChunkSize(db *gorm.DB, varsPerRecord int, reservedVars int) int {
+	limit := PlaceholderLimit(db.Dialect().GetName())
+	if varsPerRecord < 1 {
+		varsPerRecord = 1
+	}
+
+	size := (limit - reservedVars) / varsPerRecord
+	if rows, ok := rowLimits[db.Dialect().GetName()]; ok && rows < size {
+		size = rows
+	}
+	if b.chunkSize > 0 && b.chunkSize < size {
+		size = b.chunkSize
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// defaultExpr is a default value tag holding an SQL expression, written into the statement instead of bound
+type defaultExpr string
+
+var functionCall = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*\(.*\)$`)
+
+// Keywords a default value tag may hold as an expression, other bare words being literals
+var defaultKeywords = map[string]bool{
+	"CURRENT_TIMESTAMP": true, "CURRENT_DATE": true, "CURRENT_TIME": true, "LOCALTIMESTAMP": true, "LOCALTIME": true,
+	"NULL": true, "TRUE": true, "FALSE": true,
+}
+
+// Interpret a default value tag the way it reads in DDL: quoted strings are literals,
+// keywords like CURRENT_TIMESTAMP and function calls like uuid_generate_v4() are expressions,
+// anything else such as numbers or bare words like active is bound as is.
+func defaultValue(tag string) interface{} {
+	if len(tag) >= 2 && tag[0] == '\'' && tag[len(tag)-1] == '\'' {
+		return strings.Replace(tag[1:len(tag)-1], "''", "'", -1)
+	}
+	if defaultKeywords[strings.ToUpper(tag)] || functionCall.MatchString(tag) {
+		return defaultExpr(tag)
+	}
+	return tag
+}
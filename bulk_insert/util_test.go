@@ -1,6 +1,7 @@
 package bulk_insert
 
 import (
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -38,3 +39,44 @@ func Test_containString(t *testing.T) {
 	assert.True(t, containString(sliceVal, "a"))
 	assert.False(t, containString(sliceVal, "d"))
 }
+
+func TestBuilder_safeChunkSize(t *testing.T) {
+	sqlite := openFake(t, "sqlite3")
+	postgres := openFake(t, "postgres")
+
+	assert.Equal(t, 2000, NewBuilder().safeChunkSize(postgres, 10, 0))
+	assert.Equal(t, 1310, NewBuilder().safeChunkSize(postgres, 50, 0))
+	assert.Equal(t, 99, NewBuilder().safeChunkSize(sqlite, 10, 0))
+	assert.Equal(t, 998, NewBuilder().safeChunkSize(sqlite, 1, 1))
+	assert.Equal(t, 50, NewBuilder(ChunkSizeOpt(50)).safeChunkSize(sqlite, 1, 0))
+	assert.Equal(t, 1, NewBuilder().safeChunkSize(sqlite, 2000, 0))
+
+	// SQL Server caps a VALUES list at 1000 rows
+	assert.Equal(t, 1000, NewBuilder().safeChunkSize(openFake(t, "mssql"), 1, 0))
+}
+
+func Test_indirectElems(t *testing.T) {
+	first, second := &fakeDB{Name: "a"}, fakeDB{Name: "b"}
+
+	elems, err := indirectElems(reflect.ValueOf([]interface{}{first, second}))
+	assert.NoError(t, err)
+	assert.Len(t, elems, 2)
+	assert.True(t, elems[0].CanAddr())
+	assert.Equal(t, "b", elems[1].Interface().(fakeDB).Name)
+
+	_, err = indirectElems(reflect.ValueOf([]*fakeDB{first, nil}))
+	assert.Error(t, err)
+
+	_, err = indirectElems(reflect.ValueOf([]interface{}{1}))
+	assert.Error(t, err)
+}
+
+func Test_defaultValue(t *testing.T) {
+	assert.Equal(t, defaultExpr("CURRENT_TIMESTAMP"), defaultValue("CURRENT_TIMESTAMP"))
+	assert.Equal(t, defaultExpr("uuid_generate_v4()"), defaultValue("uuid_generate_v4()"))
+	assert.Equal(t, "it's", defaultValue("'it''s'"))
+	assert.Equal(t, "42", defaultValue("42"))
+	assert.Equal(t, "default@mail.com", defaultValue("default@mail.com"))
+	assert.Equal(t, "active", defaultValue("active"))
+	assert.Equal(t, defaultExpr("null"), defaultValue("null"))
+}
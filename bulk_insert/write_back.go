@@ -0,0 +1,95 @@
+package bulk_insert
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Fill blank CreatedAt/UpdatedAt of the original elements so the inserted values can be observed by the caller
+func setTimestamps(db *gorm.DB, elems []reflect.Value) {
+	now := gorm.NowFunc()
+	for _, elem := range elems {
+		if !elem.CanAddr() || elem.Kind() != reflect.Struct {
+			continue
+		}
+		scope := db.NewScope(elem.Addr().Interface())
+		for _, name := range []string{"CreatedAt", "UpdatedAt"} {
+			if field, ok := scope.FieldByName(name); ok && field.IsBlank {
+				field.Set(now)
+			}
+		}
+	}
+}
+
+// Execute the generated INSERT and set the generated primary keys back into elems
+func writeBackIDs(ctx context.Context, db *gorm.DB, mainScope *gorm.Scope, attrs map[string]interface{}, elems []reflect.Value) (int64, error) {
+	pf := mainScope.PrimaryField()
+	if pf == nil {
+		return execSQL(ctx, db, mainScope.SQL, mainScope.SQLVars)
+	}
+	if _, inserted := attrs[pf.DBName]; inserted {
+		// Primary key is provided by the caller, nothing is generated
+		return execSQL(ctx, db, mainScope.SQL, mainScope.SQLVars)
+	}
+
+	switch dialect := mainScope.Dialect().GetName(); dialect {
+	case "postgres":
+		rows, err := querySQL(ctx, db, mainScope.SQL+" RETURNING "+mainScope.Quote(pf.DBName), mainScope.SQLVars)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+
+		var rowsAffected int64
+		for ; rows.Next(); rowsAffected++ {
+			if rowsAffected >= int64(len(elems)) {
+				return rowsAffected, fmt.Errorf("returned more primary keys than inserted rows")
+			}
+			id := reflect.New(pf.Struct.Type)
+			if err := rows.Scan(id.Interface()); err != nil {
+				return rowsAffected, err
+			}
+			if err := setPrimaryKey(db, elems[rowsAffected], id.Elem().Interface()); err != nil {
+				return rowsAffected, err
+			}
+		}
+		return rowsAffected, rows.Err()
+	case "mysql", "sqlite3":
+		result, err := execResult(ctx, db, mainScope.SQL, mainScope.SQLVars)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		lastID, err := result.LastInsertId()
+		if err != nil {
+			return rowsAffected, err
+		}
+
+		// MySQL reports the first generated id of the statement, SQLite the last one
+		firstID := lastID
+		if dialect == "sqlite3" {
+			firstID = lastID - int64(len(elems)) + 1
+		}
+		for i, elem := range elems {
+			if err := setPrimaryKey(db, elem, firstID+int64(i)); err != nil {
+				return rowsAffected, err
+			}
+		}
+		return rowsAffected, nil
+	default:
+		return 0, fmt.Errorf("write back is not supported by dialect %s", dialect)
+	}
+}
+
+func setPrimaryKey(db *gorm.DB, elem reflect.Value, id interface{}) error {
+	if !elem.CanAddr() {
+		return fmt.Errorf("can not write primary key back into %s", elem.Type())
+	}
+	return db.NewScope(elem.Addr().Interface()).PrimaryField().Set(id)
+}
@@ -0,0 +1,67 @@
+package bulk_insert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type writeBackDB struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func Test_writeBackIDs(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	objects := []writeBackDB{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true), ChunkSizeOpt(2)).Exec(db, objects))
+
+	var stored []writeBackDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Len(t, stored, 3)
+	for i, object := range objects {
+		assert.Equal(t, stored[i].ID, object.ID)
+		assert.Equal(t, stored[i].Name, object.Name)
+		assert.False(t, object.CreatedAt.IsZero())
+		assert.False(t, object.UpdatedAt.IsZero())
+	}
+}
+
+func Test_writeBackIDs_pointers(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	objects := []*writeBackDB{{Name: "a"}, {Name: "b"}}
+	assert.NoError(t, NewBuilder(WriteBackOpt(true)).Exec(db, objects))
+	assert.Equal(t, 1, objects[0].ID)
+	assert.Equal(t, 2, objects[1].ID)
+
+	// interfaces holding structs are inserted but can not be written back
+	assert.NoError(t, NewBuilder().Exec(db, []interface{}{writeBackDB{Name: "c"}, &writeBackDB{Name: "d"}}))
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 4, count)
+}
+
+func Test_writeBackIDs_conflicts(t *testing.T) {
+	db := openSQLite(t, &writeBackDB{})
+	defer db.Close()
+
+	assert.NoError(t, NewBuilder().Exec(db, []writeBackDB{{ID: 1, Name: "a"}}))
+
+	// the duplicate is skipped, the ids of the following rows can not be derived
+	objects := []writeBackDB{{ID: 1, Name: "a"}, {Name: "b"}}
+	assert.EqualError(t, NewBuilder(WriteBackOpt(true), IgnoreConflictsOpt(true)).Exec(db, objects),
+		"write back can not be combined with ignore conflicts or upsert")
+	assert.Error(t, NewBuilder(WriteBackOpt(true), UpsertOpt([]string{"id"}, nil)).Exec(db, objects))
+
+	var count int
+	assert.NoError(t, db.Model(&writeBackDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
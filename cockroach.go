@@ -0,0 +1,27 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"github.com/cochainio/orm/internal/dberr"
+)
+
+// Backoff before the first retry of a transaction
+const cockroachBackoff = 10 * time.Millisecond
+
+// CockroachOpt enables the cockroach mode: CockroachDB speaks the postgres protocol, open it with a postgres DSN,
+// but aborts transactions on contention with serialization failures that clients are expected to retry.
+// ExecuteTx re-runs its closure up to maxRetries times on such failures.
+func CockroachOpt(maxRetries int) Option {
+	return func(o *options) {
+		o.txRetries = maxRetries
+	}
+}
+
+// ExecuteTx runs fn like Transaction. In cockroach mode the whole transaction, fn included,
+// is run again after a serialization failure, so fn must not have effects outside of tx.
+func (db *DB) ExecuteTx(ctx context.Context, fn func(tx *TX) error) error {
+	_, err := db.retryTransaction(ctx, RetryPolicy{MaxAttempts: db.txRetries + 1, Backoff: cockroachBackoff}, dberr.IsSerializationFailure, fn)
+	return err
+}
@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+type cockroachDB struct {
+	ID   int
+	Name string
+}
+
+func TestDB_ExecuteTx(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "cockroach.db"), NamingStrategyOpt(&gorm.NamingStrategy{}), CockroachOpt(2))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&cockroachDB{}).Error)
+
+	restart := &pq.Error{Code: "40001", Message: "restart transaction"}
+
+	// Serialization failures re-run the closure, the aborted attempts leave nothing behind
+	attempts := 0
+	assert.NoError(t, db.ExecuteTx(context.Background(), func(tx *TX) error {
+		attempts++
+		if err := tx.Create(&cockroachDB{Name: "a"}).Error; err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return restart
+		}
+		return nil
+	}))
+	assert.Equal(t, 3, attempts)
+	var count int
+	assert.NoError(t, db.Model(&cockroachDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+
+	// Retries are limited
+	attempts = 0
+	assert.Equal(t, restart, db.ExecuteTx(context.Background(), func(tx *TX) error {
+		attempts++
+		return restart
+	}))
+	assert.Equal(t, 3, attempts)
+
+	// Other errors are returned at once
+	attempts = 0
+	failure := errors.New("failure")
+	assert.Equal(t, failure, db.ExecuteTx(context.Background(), func(tx *TX) error {
+		attempts++
+		return failure
+	}))
+	assert.Equal(t, 1, attempts)
+
+	// A panic rolls back
+	assert.Panics(t, func() {
+		db.ExecuteTx(context.Background(), func(tx *TX) error {
+			tx.Create(&cockroachDB{Name: "b"})
+			panic("failure")
+		})
+	})
+	assert.NoError(t, db.Model(&cockroachDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
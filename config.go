@@ -0,0 +1,250 @@
+package orm
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Config describes a database connection, an alternative to writing the DSN by hand
+type Config struct {
+	// mysql, postgres, sqlite3, mssql or clickhouse
+	Dialect  string
+	Host     string
+	Port     int // Defaults to the standard port of the dialect
+	User     string
+	Password string
+	// Database name, or the file of a sqlite3 database
+	DBName string
+	// Driver specific connection parameters, like parseTime for mysql or connect_timeout for postgres
+	Params map[string]string
+	// tls for mysql, sslmode for postgres, encrypt for mssql and secure for clickhouse,
+	// or the name of a config registered by RegisterTLS
+	TLS string
+
+	MaxOpenConns    int
+	MaxIdleConns    int // Zero keeps the database/sql default
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+var defaultPorts = map[string]int{
+	"mysql":      3306,
+	"postgres":   5432,
+	"mssql":      1433,
+	"clickhouse": 9000,
+}
+
+// DSN writes the config as driver://source, the form taken by New and Instantiate
+func (c Config) DSN() (string, error) {
+	source, err := c.source()
+	if err != nil {
+		return "", err
+	}
+	return c.Dialect + "://" + source, nil
+}
+
+func (c Config) port() string {
+	if c.Port == 0 {
+		return strconv.Itoa(defaultPorts[c.Dialect])
+	}
+	return strconv.Itoa(c.Port)
+}
+
+func (c Config) address() string {
+	return net.JoinHostPort(c.Host, c.port())
+}
+
+// Data source name in the format of the driver of the dialect
+func (c Config) source() (string, error) {
+	switch c.Dialect {
+	case "mysql":
+		cfg := mysql.NewConfig()
+		cfg.User = c.User
+		cfg.Passwd = c.Password
+		cfg.Net = "tcp"
+		cfg.Addr = c.address()
+		cfg.DBName = c.DBName
+		cfg.TLSConfig = c.TLS
+		if len(c.Params) > 0 {
+			cfg.Params = c.Params
+		}
+		return cfg.FormatDSN(), nil
+	case "postgres":
+		params := map[string]string{
+			"host":     c.Host,
+			"port":     c.port(),
+			"user":     c.User,
+			"password": c.Password,
+			"dbname":   c.DBName,
+			"sslmode":  c.TLS,
+		}
+		if tls, ok := registeredTLS(c.TLS); ok {
+			for key, value := range tls.postgresParams() {
+				params[key] = value
+			}
+		}
+		for key, value := range c.Params {
+			params[key] = value
+		}
+		return keyValues(params), nil
+	case "sqlite3":
+		if len(c.Params) == 0 {
+			return c.DBName, nil
+		}
+		return c.DBName + "?" + queryString(c.Params), nil
+	case "mssql":
+		params := map[string]string{"database": c.DBName, "encrypt": c.TLS}
+		if tls, ok := registeredTLS(c.TLS); ok {
+			for key, value := range tls.mssqlParams() {
+				params[key] = value
+			}
+		}
+		for key, value := range c.Params {
+			params[key] = value
+		}
+		u := url.URL{
+			Scheme:   "sqlserver",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     c.address(),
+			RawQuery: queryString(params),
+		}
+		return u.String(), nil
+	case "clickhouse":
+		params := map[string]string{"username": c.User, "password": c.Password, "database": c.DBName, "secure": c.TLS}
+		if _, ok := registeredTLS(c.TLS); ok {
+			params["secure"] = "true"
+			params["tls_config"] = c.TLS
+		}
+		for key, value := range c.Params {
+			params[key] = value
+		}
+		u := url.URL{Scheme: "tcp", Host: c.address(), RawQuery: queryString(params)}
+		return u.String(), nil
+	}
+	return "", fmt.Errorf("unsupported dialect %q", c.Dialect)
+}
+
+// Write params in the key=value form of lib/pq, quoting values when needed
+func keyValues(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for _, key := range sortedKeys(params) {
+		value := params[key]
+		if value == "" {
+			continue
+		}
+		if strings.ContainsAny(value, ` '\`) {
+			value = "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, " ")
+}
+
+// Encode the non empty params as a query string
+func queryString(params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	return values.Encode()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Options applying the pool settings of the config
+func (c Config) options() []Option {
+	var opts []Option
+	if c.MaxOpenConns > 0 {
+		opts = append(opts, MaxOpenConnsOpt(c.MaxOpenConns))
+	}
+	if c.MaxIdleConns > 0 {
+		opts = append(opts, MaxIdleConnsOpt(c.MaxIdleConns))
+	}
+	if c.ConnMaxLifetime > 0 {
+		opts = append(opts, ConnMaxLifetimeOpt(c.ConnMaxLifetime))
+	}
+	if c.ConnMaxIdleTime > 0 {
+		opts = append(opts, ConnMaxIdleTimeOpt(c.ConnMaxIdleTime))
+	}
+	return opts
+}
+
+// NewFromConfig opens the database described by cfg, see New.
+// The pool settings of cfg apply before opts.
+func NewFromConfig(cfg Config, opts ...Option) (*DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+	return New(dsn, append(cfg.options(), opts...)...)
+}
+
+// ConfigFromEnv reads a Config from the environment variables named after prefix:
+// <prefix>_DIALECT, _HOST, _PORT, _USER, _PASSWORD, _NAME, _PARAMS (as a query string), _TLS,
+// _MAX_OPEN_CONNS, _MAX_IDLE_CONNS, _CONN_MAX_LIFETIME and _CONN_MAX_IDLE_TIME (as durations like 5m).
+func ConfigFromEnv(prefix string) (Config, error) {
+	env := func(name string) string {
+		return os.Getenv(prefix + "_" + name)
+	}
+
+	cfg := Config{
+		Dialect:  env("DIALECT"),
+		Host:     env("HOST"),
+		User:     env("USER"),
+		Password: env("PASSWORD"),
+		DBName:   env("NAME"),
+		TLS:      env("TLS"),
+	}
+
+	var err error
+	for name, target := range map[string]*int{
+		"PORT":           &cfg.Port,
+		"MAX_OPEN_CONNS": &cfg.MaxOpenConns,
+		"MAX_IDLE_CONNS": &cfg.MaxIdleConns,
+	} {
+		if value := env(name); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil {
+				return Config{}, fmt.Errorf("%s_%s: %v", prefix, name, err)
+			}
+		}
+	}
+	for name, target := range map[string]*time.Duration{
+		"CONN_MAX_LIFETIME":  &cfg.ConnMaxLifetime,
+		"CONN_MAX_IDLE_TIME": &cfg.ConnMaxIdleTime,
+	} {
+		if value := env(name); value != "" {
+			if *target, err = time.ParseDuration(value); err != nil {
+				return Config{}, fmt.Errorf("%s_%s: %v", prefix, name, err)
+			}
+		}
+	}
+
+	if value := env("PARAMS"); value != "" {
+		values, err := url.ParseQuery(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s_PARAMS: %v", prefix, err)
+		}
+		cfg.Params = make(map[string]string, len(values))
+		for key := range values {
+			cfg.Params[key] = values.Get(key)
+		}
+	}
+	return cfg, nil
+}
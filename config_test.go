@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_DSN(t *testing.T) {
+	tests := []struct {
+		cfg      Config
+		expected string
+	}{
+		{
+			Config{Dialect: "mysql", Host: "db", User: "app", Password: "p@ss", DBName: "shop", Params: map[string]string{"parseTime": "true"}, TLS: "true"},
+			"mysql://app:p@ss@tcp(db:3306)/shop?tls=true&parseTime=true",
+		},
+		{
+			Config{Dialect: "postgres", Host: "db", Port: 6432, User: "app", Password: "it's secret", DBName: "shop", TLS: "verify-full"},
+			`postgres://dbname=shop host=db password='it\'s secret' port=6432 sslmode=verify-full user=app`,
+		},
+		{
+			Config{Dialect: "sqlite3", DBName: "/tmp/shop.db", Params: map[string]string{"_busy_timeout": "5000"}},
+			"sqlite3:///tmp/shop.db?_busy_timeout=5000",
+		},
+		{
+			Config{Dialect: "mssql", Host: "db", User: "sa", Password: "p@ss", DBName: "shop", TLS: "true"},
+			"mssql://sqlserver://sa:p%40ss@db:1433?database=shop&encrypt=true",
+		},
+		{
+			Config{Dialect: "clickhouse", Host: "ch", User: "app", DBName: "events"},
+			"clickhouse://tcp://ch:9000?database=events&username=app",
+		},
+	}
+	for _, test := range tests {
+		dsn, err := test.cfg.DSN()
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, dsn)
+	}
+
+	_, err := Config{Dialect: "oracle"}.DSN()
+	assert.Error(t, err)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	for name, value := range map[string]string{
+		"TEST_DB_DIALECT":           "postgres",
+		"TEST_DB_HOST":              "db",
+		"TEST_DB_PORT":              "6432",
+		"TEST_DB_NAME":              "shop",
+		"TEST_DB_PARAMS":            "connect_timeout=5&application_name=api",
+		"TEST_DB_MAX_OPEN_CONNS":    "20",
+		"TEST_DB_CONN_MAX_LIFETIME": "5m",
+	} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	cfg, err := ConfigFromEnv("TEST_DB")
+	assert.NoError(t, err)
+	assert.Equal(t, Config{
+		Dialect:         "postgres",
+		Host:            "db",
+		Port:            6432,
+		DBName:          "shop",
+		Params:          map[string]string{"connect_timeout": "5", "application_name": "api"},
+		MaxOpenConns:    20,
+		ConnMaxLifetime: 5 * time.Minute,
+	}, cfg)
+
+	os.Setenv("TEST_DB_PORT", "default")
+	_, err = ConfigFromEnv("TEST_DB")
+	assert.Error(t, err)
+}
+
+func TestNewFromConfig(t *testing.T) {
+	db, err := NewFromConfig(Config{Dialect: "sqlite3", DBName: filepath.Join(t.TempDir(), "config.db"), MaxOpenConns: 2})
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.Equal(t, 2, db.PoolStats()["primary"].MaxOpenConnections)
+}
@@ -0,0 +1,188 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"unsafe"
+
+	"github.com/jinzhu/gorm"
+)
+
+// WithContext returns db running every statement, transaction and bulk operation with ctx,
+// so that its deadline and cancellation reach the database
+func (db *DB) WithContext(ctx context.Context) *DB {
+	derived := *db
+	derived.ctx = ctx
+	derived.DB = withContext(db.DB, ctx)
+	return &derived
+}
+
+// WithContext returns tx running its statements with ctx, it shares the transaction with tx
+func (tx *TX) WithContext(ctx context.Context) *TX {
+	derived := *tx
+	derived.ctx = ctx
+	derived.DB = withContext(tx.DB, ctx)
+	return &derived
+}
+
+// Context of db, background unless set by WithContext
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
+}
+
+func (tx *TX) context() context.Context {
+	if tx.ctx == nil {
+		return context.Background()
+	}
+	return tx.ctx
+}
+
+// Clone db with its connection bound to ctx.
+// gorm v1 has no context support and keeps its connection unexported, it is replaced through reflection.
+func withContext(db *gorm.DB, ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return db
+	}
+	clone := db.Set(contextKey, ctx)
+	common := unwrapCommon(clone.CommonDB())
+
+	// gorm tells transactions from pools by the methods of the connection, so both kinds are kept apart
+	var bound gorm.SQLCommon = &contextDB{contextCommon{common: common, ctx: ctx}}
+	switch conn := common.(type) {
+	case *sql.Tx:
+		bound = &contextTx{contextCommon: contextCommon{common: common, ctx: ctx}, tx: conn}
+	case *xaConn:
+		bound = &contextCommon{common: conn, ctx: ctx}
+	}
+	setCommon(clone, bound)
+	return clone
+}
+
+// Replace the connection of db, which gorm keeps unexported
+func setCommon(db *gorm.DB, common gorm.SQLCommon) {
+	field := reflect.ValueOf(db).Elem().FieldByName("db")
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Set(reflect.ValueOf(common))
+}
+
+// contextCommon runs the statements of a connection with a context
+type contextCommon struct {
+	common gorm.SQLCommon
+	ctx    context.Context
+}
+
+// contextDB is a pool bound to a context, transactions begin with the context
+type contextDB struct {
+	contextCommon
+}
+
+// contextTx is a transaction bound to a context
+type contextTx struct {
+	contextCommon
+	tx *sql.Tx
+}
+
+type contextCommonDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func (c *contextCommon) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(c.ctx, query, args...)
+}
+
+func (c *contextCommon) Prepare(query string) (*sql.Stmt, error) {
+	return c.PrepareContext(c.ctx, query)
+}
+
+func (c *contextCommon) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(c.ctx, query, args...)
+}
+
+func (c *contextCommon) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(c.ctx, query, args...)
+}
+
+func (c *contextCommon) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if common, ok := c.common.(contextCommonDB); ok {
+		return common.ExecContext(ctx, query, args...)
+	}
+	return c.common.Exec(query, args...)
+}
+
+func (c *contextCommon) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if common, ok := c.common.(contextCommonDB); ok {
+		return common.PrepareContext(ctx, query)
+	}
+	return c.common.Prepare(query)
+}
+
+func (c *contextCommon) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if common, ok := c.common.(contextCommonDB); ok {
+		return common.QueryContext(ctx, query, args...)
+	}
+	return c.common.Query(query, args...)
+}
+
+func (c *contextCommon) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if common, ok := c.common.(contextCommonDB); ok {
+		return common.QueryRowContext(ctx, query, args...)
+	}
+	return c.common.QueryRow(query, args...)
+}
+
+func (c *contextDB) Begin() (*sql.Tx, error) {
+	return c.BeginTx(c.ctx, nil)
+}
+
+// BeginTx starts a transaction bound to the given context, or to the one of c when it can not be canceled
+func (c *contextDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if ctx.Done() == nil {
+		ctx = c.ctx
+	}
+	if common, ok := c.common.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	}); ok {
+		return common.BeginTx(ctx, opts)
+	}
+	return nil, errors.New("orm: connection can not begin transactions")
+}
+
+func (c *contextDB) Close() error {
+	if closer, ok := c.common.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return errors.New("orm: connection can not be closed")
+}
+
+func (c *contextTx) Commit() error {
+	return c.tx.Commit()
+}
+
+func (c *contextTx) Rollback() error {
+	return c.tx.Rollback()
+}
+
+// Tx returns the transaction, so that it is recognized through the context binding
+func (c *contextTx) Tx() *sql.Tx {
+	return c.tx
+}
+
+// Connection underneath the context binding
+func unwrapCommon(common gorm.SQLCommon) gorm.SQLCommon {
+	switch bound := common.(type) {
+	case *contextDB:
+		return bound.common
+	case *contextTx:
+		return bound.common
+	case *contextCommon:
+		return bound.common
+	}
+	return common
+}
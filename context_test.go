@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+type contextModel struct {
+	ID   int
+	Name string
+}
+
+func openContextDB(t *testing.T) *DB {
+	gormDB, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "context.db"))
+	assert.NoError(t, err)
+	assert.NoError(t, gormDB.AutoMigrate(&contextModel{}).Error)
+	return &DB{DB: gormDB}
+}
+
+func TestDB_WithContext(t *testing.T) {
+	db := openContextDB(t)
+	defer db.Close(context.Background())
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Equal(t, context.Canceled, db.WithContext(canceled).Find(&[]contextModel{}).Error)
+	assert.Equal(t, context.Canceled, db.WithContext(canceled).Create(&contextModel{Name: "a"}).Error)
+	assert.Equal(t, context.Canceled, db.WithContext(canceled).Begin().Error)
+	assert.Error(t, db.WithContext(canceled).BulkCreate([]contextModel{{Name: "a"}}))
+	assert.Error(t, db.WithContext(canceled).Exec("DELETE FROM context_models").Error)
+
+	ctx := context.Background()
+	assert.NoError(t, db.WithContext(ctx).Create(&contextModel{Name: "a"}).Error)
+	assert.NoError(t, db.WithContext(ctx).BulkCreate([]contextModel{{Name: "b"}, {Name: "c"}}))
+
+	var count int
+	assert.NoError(t, db.WithContext(ctx).Model(&contextModel{}).Count(&count).Error)
+	assert.Equal(t, 3, count)
+}
+
+func TestTX_WithContext(t *testing.T) {
+	db := openContextDB(t)
+	defer db.Close(context.Background())
+
+	tx := db.Begin()
+	defer tx.End()
+	bound := tx.WithContext(context.Background())
+	assert.NoError(t, bound.Create(&contextModel{Name: "a"}).Error)
+	// Bulk inserts see the transaction through the context binding instead of starting another one
+	assert.NoError(t, bound.BulkCreate([]contextModel{{Name: "b"}}, bulk_insert.AtomicOpt(true)))
+	assert.NoError(t, bound.Commit(true))
+	assert.True(t, tx.committed)
+
+	var count int
+	assert.NoError(t, db.Model(&contextModel{}).Count(&count).Error)
+	assert.Equal(t, 2, count)
+
+	// A transaction begun with a context is rolled back once it is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	tx = db.WithContext(ctx).Begin()
+	assert.NoError(t, tx.Create(&contextModel{Name: "c"}).Error)
+	cancel()
+	assert.Error(t, tx.Create(&contextModel{Name: "d"}).Error)
+	assert.Error(t, tx.Commit(true))
+
+	assert.NoError(t, db.Model(&contextModel{}).Count(&count).Error)
+	assert.Equal(t, 2, count)
+}
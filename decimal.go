@@ -0,0 +1,224 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Decimal is an exact decimal number for NUMERIC/DECIMAL columns, like amounts of money.
+// It is sent and read as text so that no digit is lost, and refuses floats: there is no constructor from float64
+// and scanning a float fails. The zero value is 0, use *Decimal for nullable columns.
+// Columns are numeric on postgres, decimal(65,30) on mysql and text on sqlite, whose numeric affinity rounds to floats;
+// set the type tag for a given precision, like `gorm:"type:numeric(12,2)"`.
+type Decimal struct {
+	unscaled *big.Int // nil is 0
+	scale    int32    // Digits after the decimal point, never negative
+}
+
+// NewDecimal returns unscaled * 10^-scale, NewDecimal(1234, 2) being 12.34
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	d := Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+	if scale < 0 {
+		d.unscaled.Mul(d.unscaled, pow10(-scale))
+		d.scale = 0
+	}
+	return d
+}
+
+// ParseDecimal parses a decimal literal like "-12.30" or "1.5e3"
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := fmt.Errorf("orm: invalid decimal %q", s)
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Decimal{}, invalid
+		}
+		mantissa = s[:i]
+	}
+	digits, fraction := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits, fraction = mantissa[:i], mantissa[i+1:]
+	}
+	unsigned := strings.TrimLeft(digits, "+-")
+	if len(digits)-len(unsigned) > 1 || unsigned+fraction == "" || strings.ContainsAny(unsigned+fraction, "+-") {
+		return Decimal{}, invalid
+	}
+	unscaled, ok := new(big.Int).SetString(digits+fraction, 10)
+	if !ok {
+		return Decimal{}, invalid
+	}
+	scale := int64(len(fraction)) - exp
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(int32(-scale)))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// MustParseDecimal is ParseDecimal panicking on invalid literals, for constants
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Unscaled value of d at the given scale, which is at least the one of d
+func (d Decimal) rescaled(scale int32) *big.Int {
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return Decimal{unscaled: new(big.Int).Add(d.rescaled(scale), other.rescaled(scale)), scale: scale}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	return d.Add(other.Neg())
+}
+
+// Mul returns d * other, with the scales added
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), other.int()), scale: d.scale + other.scale}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Round returns d rounded half away from zero to places digits after the decimal point
+func (d Decimal) Round(places int32) Decimal {
+	if places < 0 {
+		places = 0
+	}
+	if places >= d.scale {
+		return Decimal{unscaled: d.rescaled(places), scale: places}
+	}
+	divisor := pow10(d.scale - places)
+	quotient, remainder := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	if remainder.Abs(remainder).Mul(remainder, big.NewInt(2)).Cmp(divisor) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(d.Sign())))
+	}
+	return Decimal{unscaled: quotient, scale: places}
+}
+
+// Cmp returns -1, 0 or 1 as d is lower than, equal to or greater than other
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxScale(d, other)
+	return d.rescaled(scale).Cmp(other.rescaled(scale))
+}
+
+// Equal reports whether d and other are the same number, whatever their scales
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Sign returns -1, 0 or 1 as d is negative, zero or positive
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero reports whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Scale returns the number of digits after the decimal point
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// String formats d with all the digits of its scale, like "12.30"
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Value implements driver.Valuer, sending d as text
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan implements sql.Scanner from text and integers, floats are refused
+func (d *Decimal) Scan(src interface{}) (err error) {
+	switch src := src.(type) {
+	case string:
+		*d, err = ParseDecimal(src)
+	case []byte:
+		*d, err = ParseDecimal(string(src))
+	case int64:
+		*d = NewDecimal(src, 0)
+	case nil:
+		return fmt.Errorf("orm: can not scan NULL into Decimal, use *Decimal")
+	default:
+		return fmt.Errorf("orm: can not scan %T into Decimal without losing precision", src)
+	}
+	return err
+}
+
+// MarshalJSON implements json.Marshaler, as a string to keep the digits through JavaScript
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler from strings and number literals
+func (d *Decimal) UnmarshalJSON(data []byte) (err error) {
+	literal := string(data)
+	if unquoted, err := strconv.Unquote(literal); err == nil {
+		literal = unquoted
+	}
+	*d, err = ParseDecimal(literal)
+	return err
+}
+
+// GormDataType sets the column type of Decimal fields
+func (Decimal) GormDataType(dialect gorm.Dialect) string {
+	switch dialect.GetName() {
+	case "postgres":
+		return "numeric"
+	case "mysql":
+		return "decimal(65,30)"
+	case "mssql":
+		return "decimal(38,18)"
+	case "clickhouse":
+		return "Decimal(38,18)"
+	}
+	return "text"
+}
@@ -0,0 +1,79 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type decimalDB struct {
+	ID     int
+	Amount Decimal
+	Fee    *Decimal
+}
+
+func TestDecimal(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&decimalDB{}).Error)
+
+	fee := MustParseDecimal("0.000000000000000001")
+	assert.NoError(t, db.Create(&decimalDB{ID: 1, Amount: MustParseDecimal("12345678901234567890.10"), Fee: &fee}).Error)
+	assert.NoError(t, db.BulkCreate([]decimalDB{{ID: 2, Amount: NewDecimal(-5, 1)}}))
+
+	var stored []decimalDB
+	assert.NoError(t, db.Order("id").Find(&stored).Error)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, "12345678901234567890.10", stored[0].Amount.String())
+	assert.Equal(t, "0.000000000000000001", stored[0].Fee.String())
+	assert.Equal(t, "-0.5", stored[1].Amount.String())
+	assert.Nil(t, stored[1].Fee)
+
+	var amount Decimal
+	assert.Error(t, amount.Scan(1.5))
+	assert.Error(t, amount.Scan(nil))
+	assert.NoError(t, amount.Scan(int64(7)))
+	assert.Equal(t, "7", amount.String())
+}
+
+func TestParseDecimal(t *testing.T) {
+	for literal, expected := range map[string]string{
+		"0": "0", "-12.30": "-12.30", "+.5": "0.5", "1.5e3": "1500", "15e-3": "0.015", "-0.001": "-0.001",
+	} {
+		d, err := ParseDecimal(literal)
+		assert.NoError(t, err, literal)
+		assert.Equal(t, expected, d.String(), literal)
+	}
+	for _, literal := range []string{"", ".", "1.2.3", "--1", "1-", "1e", "abc", "NaN"} {
+		_, err := ParseDecimal(literal)
+		assert.Error(t, err, literal)
+	}
+}
+
+func TestDecimal_arithmetic(t *testing.T) {
+	a, b := MustParseDecimal("0.1"), MustParseDecimal("0.20")
+	assert.Equal(t, "0.30", a.Add(b).String())
+	assert.Equal(t, "-0.10", a.Sub(b).String())
+	assert.Equal(t, "0.020", a.Mul(b).String())
+	assert.True(t, a.Add(b).Equal(MustParseDecimal("0.3")))
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.True(t, Decimal{}.IsZero())
+	assert.Equal(t, "0", Decimal{}.String())
+
+	assert.Equal(t, "1.24", MustParseDecimal("1.235").Round(2).String())
+	assert.Equal(t, "-1.24", MustParseDecimal("-1.235").Round(2).String())
+	assert.Equal(t, "1.23", MustParseDecimal("1.2349").Round(2).String())
+	assert.Equal(t, "1.500", MustParseDecimal("1.5").Round(3).String())
+
+	encoded, err := json.Marshal(MustParseDecimal("10.50"))
+	assert.NoError(t, err)
+	assert.Equal(t, `"10.50"`, string(encoded))
+	var decoded struct{ A, B Decimal }
+	assert.NoError(t, json.Unmarshal([]byte(`{"A": "1.10", "B": 2.25}`), &decoded))
+	assert.Equal(t, "1.10", decoded.A.String())
+	assert.Equal(t, "2.25", decoded.B.String())
+}
@@ -0,0 +1,190 @@
+// Package clickhouse registers the clickhouse driver and a gorm dialect for it.
+// Tables need an engine, given through gorm's table options:
+//
+//	db.Set("gorm:table_options", "ENGINE = MergeTree() ORDER BY id").AutoMigrate(&Event{})
+//
+// The driver only inserts in batches, inside a transaction, which bulk_insert does for this dialect.
+package clickhouse
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	_ "github.com/ClickHouse/clickhouse-go"
+	"github.com/jinzhu/gorm"
+)
+
+type clickhouse struct {
+	db gorm.SQLCommon
+	gorm.DefaultForeignKeyNamer
+}
+
+func init() {
+	gorm.RegisterDialect("clickhouse", &clickhouse{})
+}
+
+func (clickhouse) GetName() string {
+	return "clickhouse"
+}
+
+func (s *clickhouse) SetDB(db gorm.SQLCommon) {
+	s.db = db
+}
+
+func (clickhouse) BindVar(i int) string {
+	return "$$$"
+}
+
+func (clickhouse) Quote(key string) string {
+	return fmt.Sprintf("`%s`", key)
+}
+
+func (s *clickhouse) DataTypeOf(field *gorm.StructField) string {
+	var dataValue, sqlType, _, _ = gorm.ParseFieldStructForDialect(field, s)
+
+	if sqlType == "" {
+		switch dataValue.Kind() {
+		case reflect.Bool:
+			sqlType = "UInt8"
+		case reflect.Int8:
+			sqlType = "Int8"
+		case reflect.Int16:
+			sqlType = "Int16"
+		case reflect.Int32:
+			sqlType = "Int32"
+		case reflect.Int, reflect.Int64:
+			sqlType = "Int64"
+		case reflect.Uint8:
+			sqlType = "UInt8"
+		case reflect.Uint16:
+			sqlType = "UInt16"
+		case reflect.Uint32:
+			sqlType = "UInt32"
+		case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+			sqlType = "UInt64"
+		case reflect.Float32:
+			sqlType = "Float32"
+		case reflect.Float64:
+			sqlType = "Float64"
+		case reflect.String:
+			sqlType = "String"
+		case reflect.Struct:
+			if _, ok := dataValue.Interface().(time.Time); ok {
+				sqlType = "DateTime"
+			}
+		default:
+			if gorm.IsByteArrayOrSlice(dataValue) {
+				sqlType = "String"
+			}
+		}
+		if sqlType != "" && nullable(field) {
+			sqlType = "Nullable(" + sqlType + ")"
+		}
+	}
+
+	if sqlType == "" {
+		panic(fmt.Sprintf("invalid sql type %s (%s) for clickhouse", dataValue.Type().Name(), dataValue.Kind().String()))
+	}
+
+	// Columns are not null unless Nullable, and there are no unique constraints
+	if value, ok := field.TagSettingsGet("DEFAULT"); ok {
+		sqlType += " DEFAULT " + value
+	}
+	if value, ok := field.TagSettingsGet("COMMENT"); ok {
+		sqlType += " COMMENT " + value
+	}
+	return sqlType
+}
+
+// Pointers and scanners like sql.NullString hold NULL
+func nullable(field *gorm.StructField) bool {
+	if field.IsPrimaryKey {
+		return false
+	}
+	if field.Struct.Type.Kind() == reflect.Ptr {
+		return true
+	}
+	_, isScanner := reflect.New(field.Struct.Type).Interface().(sql.Scanner)
+	return isScanner && field.Struct.Type.Kind() == reflect.Struct
+}
+
+func (s clickhouse) count(query string, args ...interface{}) int {
+	var count int
+	s.db.QueryRow(query, args...).Scan(&count)
+	return count
+}
+
+func (s clickhouse) HasIndex(tableName string, indexName string) bool {
+	database, table := s.databaseAndTable(tableName)
+	return s.count("SELECT count() FROM system.data_skipping_indices WHERE database = ? AND table = ? AND name = ?", database, table, indexName) > 0
+}
+
+func (s clickhouse) RemoveIndex(tableName string, indexName string) error {
+	_, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %v DROP INDEX %v", s.Quote(tableName), s.Quote(indexName)))
+	return err
+}
+
+func (clickhouse) HasForeignKey(tableName string, foreignKeyName string) bool {
+	return false
+}
+
+func (s clickhouse) HasTable(tableName string) bool {
+	database, table := s.databaseAndTable(tableName)
+	return s.count("SELECT count() FROM system.tables WHERE database = ? AND name = ?", database, table) > 0
+}
+
+func (s clickhouse) HasColumn(tableName string, columnName string) bool {
+	database, table := s.databaseAndTable(tableName)
+	return s.count("SELECT count() FROM system.columns WHERE database = ? AND table = ? AND name = ?", database, table, columnName) > 0
+}
+
+func (s clickhouse) ModifyColumn(tableName string, columnName string, typ string) error {
+	_, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %v MODIFY COLUMN %v %v", s.Quote(tableName), s.Quote(columnName), typ))
+	return err
+}
+
+func (s clickhouse) CurrentDatabase() (name string) {
+	s.db.QueryRow("SELECT currentDatabase()").Scan(&name)
+	return
+}
+
+func (s clickhouse) databaseAndTable(tableName string) (string, string) {
+	if strings.Contains(tableName, ".") {
+		split := strings.SplitN(tableName, ".", 2)
+		return split[0], split[1]
+	}
+	return s.CurrentDatabase(), tableName
+}
+
+func (clickhouse) LimitAndOffsetSQL(limit, offset interface{}) (sql string) {
+	if limit != nil {
+		if parsedLimit, ok := limit.(int); ok && parsedLimit >= 0 {
+			sql += fmt.Sprintf(" LIMIT %d", parsedLimit)
+		}
+	}
+	if offset != nil {
+		if parsedOffset, ok := offset.(int); ok && parsedOffset >= 0 {
+			sql += fmt.Sprintf(" OFFSET %d", parsedOffset)
+		}
+	}
+	return
+}
+
+func (clickhouse) SelectFromDummyTable() string {
+	return ""
+}
+
+func (clickhouse) LastInsertIDReturningSuffix(tableName, columnName string) string {
+	return ""
+}
+
+func (clickhouse) DefaultValueStr() string {
+	return "VALUES ()"
+}
+
+func (clickhouse) NormalizeIndexAndColumn(indexName, columnName string) (string, string) {
+	return indexName, columnName
+}
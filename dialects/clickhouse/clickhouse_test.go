@@ -0,0 +1,48 @@
+package clickhouse
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	ID        uint64 `gorm:"primary_key"`
+	Name      string `gorm:"default:'unknown'"`
+	Count     int32
+	Ratio     float64
+	Active    bool
+	Payload   []byte
+	Note      sql.NullString
+	Parent    *int64
+	CreatedAt time.Time
+}
+
+func Test_clickhouse_DataTypeOf(t *testing.T) {
+	pool, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	db, err := gorm.Open("clickhouse", pool)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	types := map[string]string{}
+	for _, field := range db.NewScope(&event{}).GetModelStruct().StructFields {
+		types[field.DBName] = db.Dialect().DataTypeOf(field)
+	}
+	assert.Equal(t, map[string]string{
+		"id":         "UInt64",
+		"name":       "String DEFAULT 'unknown'",
+		"count":      "Int32",
+		"ratio":      "Float64",
+		"active":     "UInt8",
+		"payload":    "String",
+		"note":       "Nullable(String)",
+		"parent":     "Nullable(Int64)",
+		"created_at": "DateTime",
+	}, types)
+	assert.Equal(t, "`events`", db.NewScope(&event{}).QuotedTableName())
+}
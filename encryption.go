@@ -0,0 +1,201 @@
+package orm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeyProvider supplies the AES keys of EncryptedString and EncryptedBytes, 16, 24 or 32 bytes long.
+// Values are encrypted with the current key along with its ID, so that values encrypted with the keys
+// rotated out stay readable as long as Key returns them. Writing a value again encrypts it with the current key.
+type KeyProvider interface {
+	CurrentKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// StaticKeys provides fixed keys by ID, Current naming the one encrypting
+type StaticKeys struct {
+	Current string
+	Keys    map[string][]byte
+}
+
+func (k StaticKeys) CurrentKey() (string, []byte, error) {
+	key, err := k.Key(k.Current)
+	return k.Current, key, err
+}
+
+func (k StaticKeys) Key(id string) ([]byte, error) {
+	key, ok := k.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("orm: unknown encryption key %q", id)
+	}
+	return key, nil
+}
+
+// ErrNoKeyProvider is the error of the encrypted types until SetKeyProvider is called
+var ErrNoKeyProvider = errors.New("orm: no encryption key provider, see SetKeyProvider")
+
+var keyProvider struct {
+	sync.RWMutex
+	provider KeyProvider
+}
+
+// SetKeyProvider sets the provider of the keys of EncryptedString and EncryptedBytes
+func SetKeyProvider(provider KeyProvider) {
+	keyProvider.Lock()
+	defer keyProvider.Unlock()
+	keyProvider.provider = provider
+}
+
+func currentKeyProvider() (KeyProvider, error) {
+	keyProvider.RLock()
+	defer keyProvider.RUnlock()
+	if keyProvider.provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	return keyProvider.provider, nil
+}
+
+// Encrypted values are stored as the prefix of their key followed by the nonce and ciphertext in base64
+const encryptedPrefix = "enc:v1:"
+
+// EncryptedPrefix returns the prefix of the values encrypted with the key keyID, to find those to rotate with
+// a condition like "column NOT LIKE ?", EncryptedPrefix(current)+"%"
+func EncryptedPrefix(keyID string) string {
+	return encryptedPrefix + keyID + ":"
+}
+
+// EncryptedString is a string stored encrypted with AES-GCM, see KeyProvider.
+// Values stored before the column was encrypted are read as they are.
+type EncryptedString string
+
+// EncryptedBytes is EncryptedString for binary data, nil stored as NULL
+type EncryptedBytes []byte
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	return encrypt([]byte(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(src interface{}) error {
+	plain, err := decryptSource(src)
+	*s = EncryptedString(plain)
+	return err
+}
+
+// RandomizedValue tells bulk_insert that every Value differs, see bulk_insert.Randomized
+func (EncryptedString) RandomizedValue() {}
+
+// Value implements driver.Valuer
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	envelope, err := encrypt(b)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(envelope), nil
+}
+
+// Scan implements sql.Scanner
+func (b *EncryptedBytes) Scan(src interface{}) error {
+	if src == nil {
+		*b = nil
+		return nil
+	}
+	plain, err := decryptSource(src)
+	*b = plain
+	return err
+}
+
+// RandomizedValue tells bulk_insert that every Value differs, see bulk_insert.Randomized
+func (EncryptedBytes) RandomizedValue() {}
+
+// Seal plain with the current key into its stored form
+func encrypt(plain []byte) (string, error) {
+	provider, err := currentKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	id, key, err := provider.CurrentKey()
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(id, ":") {
+		return "", fmt.Errorf("orm: encryption key ID %q contains a colon", id)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plain, []byte(id))
+	return EncryptedPrefix(id) + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open the stored form src, returning it as is when it is not encrypted
+func decryptSource(src interface{}) ([]byte, error) {
+	var stored string
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		stored = src
+	case []byte:
+		stored = string(src)
+	default:
+		return nil, fmt.Errorf("orm: can not decrypt %T", src)
+	}
+	if !strings.HasPrefix(stored, encryptedPrefix) {
+		return []byte(stored), nil
+	}
+
+	id, data, ok := strings.Cut(stored[len(encryptedPrefix):], ":")
+	if !ok {
+		return nil, errors.New("orm: malformed encrypted value")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("orm: malformed encrypted value: %v", err)
+	}
+	provider, err := currentKeyProvider()
+	if err != nil {
+		return nil, err
+	}
+	key, err := provider.Key(id)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("orm: malformed encrypted value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return nil, fmt.Errorf("orm: decrypting with key %q: %v", id, err)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
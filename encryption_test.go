@@ -0,0 +1,62 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type encryptedDB struct {
+	ID     int
+	Email  EncryptedString
+	Secret EncryptedBytes
+}
+
+func TestEncryptedString(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&encryptedDB{}).Error)
+
+	SetKeyProvider(nil)
+	assert.ErrorIs(t, db.Create(&encryptedDB{ID: 1, Email: "a@example.com"}).Error, ErrNoKeyProvider)
+
+	keys := StaticKeys{Current: "k1", Keys: map[string][]byte{"k1": bytes.Repeat([]byte{1}, 32)}}
+	SetKeyProvider(keys)
+	defer SetKeyProvider(nil)
+	assert.NoError(t, db.Create(&encryptedDB{ID: 1, Email: "a@example.com", Secret: []byte{0, 1, 2}}).Error)
+	assert.NoError(t, db.BulkCreate([]*encryptedDB{{ID: 2, Email: "b@example.com"}}))
+
+	var stored []string
+	assert.NoError(t, db.Model(&encryptedDB{}).Order("id").Pluck("email", &stored).Error)
+	for _, email := range stored {
+		assert.True(t, strings.HasPrefix(email, EncryptedPrefix("k1")), email)
+		assert.NotContains(t, email, "example.com")
+	}
+
+	// Rotated keys stay readable, rewriting a value encrypts it with the current key
+	keys.Current, keys.Keys["k2"] = "k2", bytes.Repeat([]byte{2}, 32)
+	SetKeyProvider(keys)
+	var records []encryptedDB
+	assert.NoError(t, db.Order("id").Find(&records).Error)
+	assert.Equal(t, []encryptedDB{
+		{ID: 1, Email: "a@example.com", Secret: []byte{0, 1, 2}},
+		{ID: 2, Email: "b@example.com"},
+	}, records)
+	assert.NoError(t, db.Save(&records[0]).Error)
+	var count int
+	assert.NoError(t, db.Model(&encryptedDB{}).Where("email LIKE ?", EncryptedPrefix("k1")+"%").Count(&count).Error)
+	assert.Equal(t, 1, count)
+
+	// Values written before encryption read as they are, tampered ones fail
+	assert.NoError(t, db.Exec("INSERT INTO encrypted_db (id, email) VALUES (3, 'c@example.com')").Error)
+	var plain encryptedDB
+	assert.NoError(t, db.First(&plain, 3).Error)
+	assert.Equal(t, EncryptedString("c@example.com"), plain.Email)
+	assert.NoError(t, db.Exec("UPDATE encrypted_db SET email = ? WHERE id = 3", EncryptedPrefix("k2")+"AAAAAAAAAAAAAAAAAAAAAAAA").Error)
+	assert.Error(t, db.First(&plain, 3).Error)
+}
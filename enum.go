@@ -0,0 +1,208 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// EnumError is the error of a record written with a value its enum field does not allow
+type EnumError struct {
+	Field   string
+	Value   string
+	Allowed []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("orm: %q is not a value of %s, allowed: %s", e.Value, e.Field, strings.Join(e.Allowed, ", "))
+}
+
+// IsEnumError reports whether err is, or contains, an EnumError
+func IsEnumError(err error) bool {
+	if errs, ok := err.(gorm.Errors); ok {
+		for _, err := range errs {
+			if IsEnumError(err) {
+				return true
+			}
+		}
+	}
+	var enum *EnumError
+	return errors.As(err, &enum)
+}
+
+type enum struct {
+	name   string // Native type on postgres, empty for the enums of tags
+	values []string
+}
+
+func (e enum) allows(value string) bool {
+	for _, allowed := range e.values {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+var enums struct {
+	sync.RWMutex
+	byType map[reflect.Type]enum
+}
+
+// RegisterEnum declares the string type T as an enum of values, checked when records are written.
+// MigrateEnums makes it the native enum type name on postgres.
+func RegisterEnum[T ~string](name string, values ...T) {
+	e := enum{name: name}
+	for _, value := range values {
+		e.values = append(e.values, string(value))
+	}
+	enums.Lock()
+	defer enums.Unlock()
+	if enums.byType == nil {
+		enums.byType = make(map[reflect.Type]enum)
+	}
+	enums.byType[reflect.TypeOf((*T)(nil)).Elem()] = e
+}
+
+// Enum of field, from its registered type or its enum tag like `gorm:"enum:draft|published"`
+func enumOf(field *gorm.StructField) (enum, bool) {
+	typ := field.Struct.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	enums.RLock()
+	e, ok := enums.byType[typ]
+	enums.RUnlock()
+	if ok {
+		return e, true
+	}
+	if values, ok := field.TagSettingsGet("ENUM"); ok && typ.Kind() == reflect.String {
+		return enum{values: strings.Split(values, "|")}, true
+	}
+	return enum{}, false
+}
+
+// Register the callbacks rejecting the values enums do not allow
+func registerEnums(db *gorm.DB) {
+	check := func(scope *gorm.Scope, field *gorm.StructField, value reflect.Value) {
+		e, ok := enumOf(field)
+		if !ok {
+			return
+		}
+		value = reflect.Indirect(value)
+		if !value.IsValid() || value.Kind() != reflect.String {
+			return
+		}
+		if !e.allows(value.String()) {
+			scope.Err(&EnumError{Field: field.Name, Value: value.String(), Allowed: e.values})
+		}
+	}
+	validateCreate := func(scope *gorm.Scope) {
+		for _, field := range scope.Fields() {
+			check(scope, field.StructField, field.Field)
+		}
+	}
+	validateUpdate := func(scope *gorm.Scope) {
+		attrs, ok := scope.InstanceGet("gorm:update_attrs")
+		if !ok {
+			validateCreate(scope)
+			return
+		}
+		for column, value := range attrs.(map[string]interface{}) {
+			if field, ok := scope.FieldByName(column); ok {
+				check(scope, field.StructField, reflect.ValueOf(value))
+			}
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().After("gorm:before_create").Register("orm:enum", validateCreate)
+	callback.Update().After("gorm:before_update").Register("orm:enum", validateUpdate)
+}
+
+// MigrateEnums makes the database enforce the enum fields of models, whose tables exist.
+// Registered enums become native enum types on postgres, created or given their new values.
+// Other enums become CHECK constraints named <table>_<column>_enum, replaced when the values change.
+// sqlite and clickhouse can not add constraints to existing tables, the values are only checked when records are written.
+func (db *DB) MigrateEnums(models ...interface{}) error {
+	dialect := db.Dialect().GetName()
+	if dialect == "sqlite3" || dialect == "clickhouse" {
+		return nil
+	}
+	for _, model := range models {
+		scope := db.NewScope(model)
+		for _, field := range scope.GetModelStruct().StructFields {
+			e, ok := enumOf(field)
+			if !ok || field.IsIgnored || field.DBName == "" {
+				continue
+			}
+			if err := db.dropMySQLCheck(scope.TableName(), field.DBName); err != nil {
+				return err
+			}
+			for _, statement := range enumStatements(dialect, scope.TableName(), field.DBName, e) {
+				if err := db.Exec(statement).Error; err != nil {
+					return fmt.Errorf("orm: enum of %s.%s: %v", scope.TableName(), field.DBName, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Statements enforcing e on column of table
+func enumStatements(dialect, table, column string, e enum) []string {
+	quote := func(name string) string {
+		if dialect == "mysql" {
+			return "`" + name + "`"
+		}
+		return `"` + name + `"`
+	}
+	literals := make([]string, len(e.values))
+	for i, value := range e.values {
+		literals[i] = quoteLiteral(value)
+	}
+	values := strings.Join(literals, ", ")
+
+	if dialect == "postgres" && e.name != "" {
+		statements := []string{fmt.Sprintf(
+			"DO $$ BEGIN CREATE TYPE %s AS ENUM (%s); EXCEPTION WHEN duplicate_object THEN NULL; END $$",
+			quote(e.name), values)}
+		for _, literal := range literals {
+			statements = append(statements, fmt.Sprintf("ALTER TYPE %s ADD VALUE IF NOT EXISTS %s", quote(e.name), literal))
+		}
+		return append(statements, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s",
+			quote(table), quote(column), quote(e.name), quote(column), quote(e.name)))
+	}
+
+	constraint := table + "_" + column + "_enum"
+	add := fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IN (%s))", quote(table), quote(constraint), quote(column), values)
+	switch dialect {
+	case "postgres":
+		return []string{fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s", quote(table), quote(constraint)), add}
+	case "mssql":
+		return []string{fmt.Sprintf("IF OBJECT_ID(%s, 'C') IS NOT NULL ALTER TABLE %s DROP CONSTRAINT %s",
+			quoteLiteral(constraint), quote(table), quote(constraint)), add}
+	case "mysql":
+		return []string{add}
+	}
+	return nil
+}
+
+// MySQL has no IF EXISTS for checks, the constraint is looked up before being dropped
+func (db *DB) dropMySQLCheck(table, column string) error {
+	if db.Dialect().GetName() != "mysql" {
+		return nil
+	}
+	constraint := table + "_" + column + "_enum"
+	var count int
+	err := db.DB.Raw("SELECT COUNT(*) FROM information_schema.table_constraints WHERE table_schema = DATABASE() AND table_name = ? AND constraint_name = ?",
+		table, constraint).Row().Scan(&count)
+	if err != nil || count == 0 {
+		return err
+	}
+	return db.Exec(fmt.Sprintf("ALTER TABLE `%s` DROP CHECK `%s`", table, constraint)).Error
+}
@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type enumStatus string
+
+type enumDB struct {
+	ID       int
+	Status   enumStatus
+	Priority string `gorm:"enum:low|high"`
+	Previous *enumStatus
+}
+
+func TestRegisterEnum(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	RegisterEnum[enumStatus]("status", "draft", "published")
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&enumDB{}).Error)
+	assert.NoError(t, db.MigrateEnums(&enumDB{}))
+
+	record := enumDB{ID: 1, Status: "draft", Priority: "low"}
+	assert.NoError(t, db.Create(&record).Error)
+	err := db.Create(&enumDB{ID: 2, Status: "deleted", Priority: "low"}).Error
+	assert.True(t, IsEnumError(err))
+	assert.EqualError(t, err, `orm: "deleted" is not a value of Status, allowed: draft, published`)
+	assert.True(t, IsEnumError(db.Create(&enumDB{ID: 2, Status: "draft"}).Error))
+
+	record.Priority = "urgent"
+	assert.True(t, IsEnumError(db.Save(&record).Error))
+	assert.True(t, IsEnumError(db.Model(&record).Update("status", "archived").Error))
+	assert.True(t, IsEnumError(db.Model(&record).UpdateColumn("previous", enumStatus("archived")).Error))
+	assert.NoError(t, db.Model(&record).Updates(map[string]interface{}{"status": "published", "priority": "high"}).Error)
+
+	var stored enumDB
+	assert.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, enumDB{ID: 1, Status: "published", Priority: "high"}, stored)
+}
+
+func Test_enumStatements(t *testing.T) {
+	tagged := enum{values: []string{"a", "b'c"}}
+	assert.Equal(t, []string{
+		`ALTER TABLE "t" DROP CONSTRAINT IF EXISTS "t_c_enum"`,
+		`ALTER TABLE "t" ADD CONSTRAINT "t_c_enum" CHECK ("c" IN ('a', 'b''c'))`,
+	}, enumStatements("postgres", "t", "c", tagged))
+	assert.Equal(t, []string{"ALTER TABLE `t` ADD CONSTRAINT `t_c_enum` CHECK (`c` IN ('a', 'b''c'))"},
+		enumStatements("mysql", "t", "c", tagged))
+	assert.Equal(t, []string{
+		`IF OBJECT_ID('t_c_enum', 'C') IS NOT NULL ALTER TABLE "t" DROP CONSTRAINT "t_c_enum"`,
+		`ALTER TABLE "t" ADD CONSTRAINT "t_c_enum" CHECK ("c" IN ('a', 'b''c'))`,
+	}, enumStatements("mssql", "t", "c", tagged))
+
+	assert.Equal(t, []string{
+		`DO $$ BEGIN CREATE TYPE "status" AS ENUM ('a', 'b'); EXCEPTION WHEN duplicate_object THEN NULL; END $$`,
+		`ALTER TYPE "status" ADD VALUE IF NOT EXISTS 'a'`,
+		`ALTER TYPE "status" ADD VALUE IF NOT EXISTS 'b'`,
+		`ALTER TABLE "t" ALTER COLUMN "c" TYPE "status" USING "c"::text::"status"`,
+	}, enumStatements("postgres", "t", "c", enum{name: "status", values: []string{"a", "b"}}))
+}
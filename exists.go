@@ -0,0 +1,42 @@
+package orm
+
+import (
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Exists reports whether a record of model matches query, conditions as taken by Where, nil matching any record.
+// It selects 1 with LIMIT 1, wrapped in SELECT EXISTS on postgres, instead of counting every match.
+func (db *DB) Exists(model interface{}, query interface{}, args ...interface{}) (bool, error) {
+	return exists(db.DB, model, query, args...)
+}
+
+// Exists reports whether a record of model matches query in the transaction, see DB.Exists
+func (tx *TX) Exists(model interface{}, query interface{}, args ...interface{}) (bool, error) {
+	return exists(tx.DB, model, query, args...)
+}
+
+func exists(db *gorm.DB, model interface{}, query interface{}, args ...interface{}) (bool, error) {
+	search := db.Model(model)
+	if query != nil {
+		search = search.Where(query, args...)
+	}
+	if db.Dialect().GetName() == "postgres" {
+		setCommon(search, existsCommon{SQLCommon: search.CommonDB()})
+	}
+	var found []bool
+	if err := search.Limit(1).Pluck("1", &found).Error; err != nil {
+		return false, err
+	}
+	return len(found) > 0 && found[0], nil
+}
+
+// existsCommon wraps queries in SELECT EXISTS
+type existsCommon struct {
+	gorm.SQLCommon
+}
+
+func (c existsCommon) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.SQLCommon.Query("SELECT EXISTS ("+query+")", args...)
+}
@@ -0,0 +1,39 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_Exists(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&filterDB{}).Error)
+
+	found, err := db.Exists(&filterDB{}, nil)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, db.Create(&filterDB{ID: 1, Name: "a", Age: 30}).Error)
+	assert.NoError(t, db.Create(&filterDB{ID: 2, Name: "b", Age: 40}).Error)
+	found, err = db.Exists(&filterDB{}, "age > ?", 35)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	found, err = db.Exists(&filterDB{}, map[string]interface{}{"name": "c"})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	_, err = db.Exists(&filterDB{}, "missing = ?", 1)
+	assert.Error(t, err)
+
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		assert.NoError(t, tx.Create(&filterDB{ID: 3, Name: "c"}).Error)
+		found, err := tx.Exists(&filterDB{}, "name = ?", "c")
+		assert.NoError(t, err)
+		assert.True(t, found)
+		return nil
+	}))
+}
@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SQL operators of the filter tags
+var filterOperators = map[string]string{
+	"eq": "=", "ne": "<>", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+	"like": "LIKE", "in": "IN", "nin": "NOT IN",
+}
+
+// WhereFilter returns db filtering on the fields of filter, a struct or a pointer to one, tagged with
+// their column and operator, like `orm_filter:"name,like"`. The column defaults to the one of the field name
+// and the operator to eq; the others are ne, gt, gte, lt, lte, like, matching values containing the field,
+// in and nin, taking slices.
+// Zero fields, nil pointers and empty slices are skipped, a pointer to a zero value filtering on it.
+// Columns only come from the tags, so the values of filters can be bound from requests as they are.
+func (db *DB) WhereFilter(filter interface{}) *DB {
+	value := reflect.Indirect(reflect.ValueOf(filter))
+	if value.Kind() != reflect.Struct {
+		return db.withError(fmt.Errorf("orm: filter must be a struct, got %T", filter))
+	}
+	scope := db.NewScope(nil)
+	filtered := db
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag, ok := field.Tag.Lookup("orm_filter")
+		if !ok || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+		column, op, _ := strings.Cut(tag, ",")
+		if column == "" {
+			column = gorm.ToColumnName(field.Name)
+		}
+		if op == "" {
+			op = "eq"
+		}
+		operator, ok := filterOperators[op]
+		if !ok {
+			return db.withError(fmt.Errorf("orm: unknown filter operator %q of %s", op, field.Name))
+		}
+
+		arg := value.Field(i)
+		switch {
+		case arg.Kind() == reflect.Ptr:
+			if arg.IsNil() {
+				continue
+			}
+			arg = arg.Elem()
+		case arg.Kind() == reflect.Slice:
+			if arg.Len() == 0 {
+				continue
+			}
+		case arg.IsZero():
+			continue
+		}
+
+		quoted := scope.Quote(column)
+		switch op {
+		case "in", "nin":
+			if arg.Kind() != reflect.Slice {
+				return db.withError(fmt.Errorf("orm: filter %s of %s needs a slice", op, field.Name))
+			}
+			filtered = filtered.where(fmt.Sprintf("%s %s (?)", quoted, operator), arg.Interface())
+		case "like":
+			pattern := "%" + likeEscaper.Replace(fmt.Sprint(arg.Interface())) + "%"
+			filtered = filtered.where(quoted+" LIKE ? ESCAPE '!'", pattern)
+		default:
+			filtered = filtered.where(fmt.Sprintf("%s %s ?", quoted, operator), arg.Interface())
+		}
+	}
+	return filtered
+}
+
+// Escape the wildcards of LIKE patterns with !, a backslash needing escaping itself in mysql literals
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
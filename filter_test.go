@@ -0,0 +1,62 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type filterDB struct {
+	ID     int
+	Name   string
+	Age    int
+	Status string
+}
+
+type userFilter struct {
+	Name     string   `orm_filter:"name,like"`
+	MinAge   int      `orm_filter:"age,gte"`
+	MaxAge   *int     `orm_filter:"age,lt"`
+	Status   []string `orm_filter:"status,in"`
+	Excluded []string `orm_filter:"status,nin"`
+	ID       int      `orm_filter:""`
+	Page     int
+}
+
+func TestDB_WhereFilter(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&filterDB{}).Error)
+	for _, row := range []filterDB{
+		{ID: 1, Name: "alice", Age: 30, Status: "active"},
+		{ID: 2, Name: "bob", Age: 0, Status: "banned"},
+		{ID: 3, Name: "malice_100%", Age: 50, Status: "active"},
+	} {
+		assert.NoError(t, db.Create(&row).Error)
+	}
+
+	ids := func(filter interface{}) []int {
+		var ids []int
+		assert.NoError(t, db.WhereFilter(filter).Model(&filterDB{}).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	zero, forty := 0, 40
+	assert.Equal(t, []int{1, 2, 3}, ids(userFilter{Page: 2}))
+	assert.Equal(t, []int{1, 3}, ids(&userFilter{Name: "lic"}))
+	assert.Equal(t, []int{3}, ids(userFilter{Name: "_100%"}))
+	assert.Equal(t, []int{3}, ids(userFilter{MinAge: 40}))
+	assert.Equal(t, []int{1, 2}, ids(userFilter{MaxAge: &forty}))
+	assert.Empty(t, ids(userFilter{MaxAge: &zero}))
+	assert.Equal(t, []int{2}, ids(userFilter{Status: []string{"banned", "deleted"}}))
+	assert.Equal(t, []int{1, 3}, ids(userFilter{Excluded: []string{"banned"}}))
+	assert.Equal(t, []int{3}, ids(userFilter{Status: []string{"active"}, MinAge: 40}))
+	assert.Equal(t, []int{2}, ids(userFilter{ID: 2}))
+
+	assert.Error(t, db.WhereFilter(struct {
+		Name string `orm_filter:"name,regexp"`
+	}{"a"}).Find(&[]filterDB{}).Error)
+	assert.Error(t, db.WhereFilter("name").Find(&[]filterDB{}).Error)
+}
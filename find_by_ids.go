@@ -0,0 +1,108 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+// Bind variables left to the other conditions of the statements of FindByIDs
+const reservedIDVars = 16
+
+type findByIDsConfig struct {
+	chunkSize int
+	keepOrder bool
+}
+
+type FindByIDsOpt func(*findByIDsConfig)
+
+// IDChunkSizeOpt queries at most size IDs at a time, the placeholder limit of the dialect being the default
+func IDChunkSizeOpt(size int) FindByIDsOpt {
+	return func(c *findByIDsConfig) {
+		c.chunkSize = size
+	}
+}
+
+// KeepOrderOpt orders the records found into a slice as their IDs, a repeated ID repeating its record
+func KeepOrderOpt() FindByIDsOpt {
+	return func(c *findByIDsConfig) {
+		c.keepOrder = true
+	}
+}
+
+// FindByIDs finds the records of primary keys ids, a slice, into out, a pointer to a slice of records,
+// or to a map of records by primary key. IDs without record are left out.
+// Long lists are queried in chunks, each with an IN list within the placeholder limit of the dialect.
+func (db *DB) FindByIDs(out interface{}, ids interface{}, opts ...FindByIDsOpt) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || (target.Elem().Kind() != reflect.Slice && target.Elem().Kind() != reflect.Map) {
+		return fmt.Errorf("orm: finding by IDs needs a pointer to a slice or a map, got %T", out)
+	}
+	idList := reflect.ValueOf(ids)
+	if idList.Kind() != reflect.Slice {
+		return fmt.Errorf("orm: IDs must be a slice, got %T", ids)
+	}
+	cfg := findByIDsConfig{chunkSize: bulk_insert.PlaceholderLimit(db.Dialect().GetName()) - reservedIDVars}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.chunkSize <= 0 {
+		return fmt.Errorf("orm: ID chunk size must be positive, got %d", cfg.chunkSize)
+	}
+
+	elemType := target.Elem().Type().Elem()
+	recordType := modelType(elemType)
+	scope := db.NewScope(reflect.New(recordType).Interface())
+	primary := scope.PrimaryField()
+	if primary == nil || len(scope.PrimaryFields()) > 1 {
+		return fmt.Errorf("orm: finding %s by IDs needs a single primary key", scope.TableName())
+	}
+	index := fieldIndex(recordType, primary)
+
+	found := reflect.MakeSlice(reflect.SliceOf(elemType), 0, idList.Len())
+	for start := 0; start < idList.Len(); start += cfg.chunkSize {
+		end := start + cfg.chunkSize
+		if end > idList.Len() {
+			end = idList.Len()
+		}
+		batch := reflect.New(reflect.SliceOf(elemType))
+		if err := db.Where(scope.Quote(primary.DBName)+" IN (?)", idList.Slice(start, end).Interface()).Find(batch.Interface()).Error; err != nil {
+			return err
+		}
+		found = reflect.AppendSlice(found, batch.Elem())
+	}
+
+	if target.Elem().Kind() == reflect.Map {
+		records := target.Elem()
+		if records.IsNil() {
+			records.Set(reflect.MakeMap(records.Type()))
+		}
+		keyType := records.Type().Key()
+		for i := 0; i < found.Len(); i++ {
+			key := reflect.Indirect(found.Index(i)).FieldByIndex(index)
+			// Integers convert to strings as runes, not digits
+			if !key.Type().ConvertibleTo(keyType) || (keyType.Kind() == reflect.String) != (key.Kind() == reflect.String) {
+				return fmt.Errorf("orm: primary key %s can not be a key of %s", key.Type(), records.Type())
+			}
+			records.SetMapIndex(key.Convert(keyType), found.Index(i))
+		}
+		return nil
+	}
+
+	if cfg.keepOrder {
+		byID := make(map[string]reflect.Value, found.Len())
+		for i := 0; i < found.Len(); i++ {
+			byID[fmt.Sprint(reflect.Indirect(found.Index(i)).FieldByIndex(index).Interface())] = found.Index(i)
+		}
+		ordered := reflect.MakeSlice(found.Type(), 0, idList.Len())
+		for i := 0; i < idList.Len(); i++ {
+			if record, ok := byID[fmt.Sprint(idList.Index(i).Interface())]; ok {
+				ordered = reflect.Append(ordered, record)
+			}
+		}
+		found = ordered
+	}
+	target.Elem().Set(found)
+	return nil
+}
@@ -0,0 +1,43 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_FindByIDs(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&filterDB{}).Error)
+	for id := 1; id <= 5; id++ {
+		assert.NoError(t, db.Create(&filterDB{ID: id, Name: string(rune('a' + id - 1))}).Error)
+	}
+
+	var records []filterDB
+	assert.NoError(t, db.FindByIDs(&records, []int{4, 2, 9, 5}, IDChunkSizeOpt(2)))
+	assert.Len(t, records, 3)
+
+	var ordered []*filterDB
+	assert.NoError(t, db.FindByIDs(&ordered, []int64{4, 2, 9, 5, 2}, IDChunkSizeOpt(2), KeepOrderOpt()))
+	var names []string
+	for _, record := range ordered {
+		names = append(names, record.Name)
+	}
+	assert.Equal(t, []string{"d", "b", "e", "b"}, names)
+
+	var byID map[int64]filterDB
+	assert.NoError(t, db.FindByIDs(&byID, []int{1, 3, 9}))
+	assert.Len(t, byID, 2)
+	assert.Equal(t, "c", byID[3].Name)
+
+	var none []filterDB
+	assert.NoError(t, db.FindByIDs(&none, []int{}))
+	assert.Empty(t, none)
+	assert.Error(t, db.FindByIDs(records, []int{1}))
+	assert.Error(t, db.FindByIDs(&records, 1))
+	assert.Error(t, db.FindByIDs(&map[string]filterDB{}, []int{1}))
+}
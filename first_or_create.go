@@ -0,0 +1,29 @@
+package orm
+
+import (
+	"reflect"
+
+	"github.com/cochainio/orm/internal/dberr"
+)
+
+// FirstOrCreateLocked finds the first record matching where into out, a pointer to a struct, or creates it
+// from where and attrs, nil for none, like FirstOrCreate but safe under concurrency.
+// It runs in a transaction selecting FOR UPDATE on postgres and mysql, so that the row found stays as is
+// until the transaction ends, and runs again when the creation loses the race against a concurrent one and fails
+// with a unique violation. where must match a unique key for no duplicate to be created.
+func (db *DB) FirstOrCreateLocked(out interface{}, where interface{}, attrs interface{}) error {
+	value := reflect.ValueOf(out).Elem()
+	_, err := db.retryTransaction(db.context(), RetryPolicy{}, dberr.IsUniqueViolation, func(tx *TX) error {
+		value.Set(reflect.Zero(value.Type()))
+		query := tx.DB.Where(where)
+		switch tx.Dialect().GetName() {
+		case "postgres", "mysql":
+			query = query.Set("gorm:query_option", "FOR UPDATE")
+		}
+		if attrs != nil {
+			query = query.Attrs(attrs)
+		}
+		return query.FirstOrCreate(out).Error
+	})
+	return err
+}
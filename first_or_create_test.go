@@ -0,0 +1,46 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm/internal/dberr"
+)
+
+func TestDB_FirstOrCreateLocked(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&upsertDB{}).Error)
+
+	var created upsertDB
+	assert.NoError(t, db.FirstOrCreateLocked(&created, upsertDB{Email: "a@example.com"}, upsertDB{Name: "a"}))
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, "a", created.Name)
+
+	found := upsertDB{Name: "stale"}
+	assert.NoError(t, db.FirstOrCreateLocked(&found, map[string]interface{}{"email": "a@example.com"}, nil))
+	assert.Equal(t, created.ID, found.ID)
+	assert.Equal(t, "a", found.Name)
+
+	var count int
+	assert.NoError(t, db.Model(&upsertDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+}
+
+func TestFirstOrCreateLocked_uniqueViolation(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&upsertDB{}).Error)
+
+	assert.NoError(t, db.Create(&upsertDB{ID: 1, Email: "a@example.com"}).Error)
+	assert.True(t, dberr.IsUniqueViolation(db.Create(&upsertDB{ID: 2, Email: "a@example.com"}).Error))
+	assert.False(t, dberr.IsUniqueViolation(db.Create(&upsertDB{ID: 3, Email: "b@example.com"}).Error))
+	assert.False(t, dberr.IsUniqueViolation(gorm.ErrRecordNotFound))
+	assert.True(t, dberr.IsUniqueViolation(fmt.Errorf("creating: %w", db.Create(&upsertDB{ID: 4, Email: "a@example.com"}).Error)))
+}
@@ -0,0 +1,112 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Deadline of a health check when the context has none
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+type Health struct {
+	// Time taken to ping every pool
+	Latency time.Duration
+	// Open, in use and idle connections and waits of the primary pool
+	Stats sql.DBStats
+	// Stats of each replica pool, in the order of ReplicasOpt
+	Replicas []sql.DBStats
+}
+
+// HealthCheck pings the primary and every replica, failing when any of them does not answer before the deadline.
+// The pool stats are reported either way.
+func (db *DB) HealthCheck(ctx context.Context) (*Health, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+		defer cancel()
+	}
+
+	primary, replicas := db.pools()
+	if primary == nil {
+		return nil, errors.New("health check requires a *sql.DB connection")
+	}
+
+	health := &Health{Stats: primary.Stats()}
+	start := time.Now()
+	err := primary.PingContext(ctx)
+	for i, replica := range replicas {
+		if replicaErr := replica.PingContext(ctx); replicaErr != nil && err == nil {
+			err = fmt.Errorf("replica %d: %v", i, replicaErr)
+		}
+		health.Replicas = append(health.Replicas, replica.Stats())
+	}
+	health.Latency = time.Since(start)
+	return health, err
+}
+
+// WatchHealth runs HealthCheck every interval until ctx is done, calling notify whenever the database
+// becomes unhealthy or healthy again. The database is assumed healthy before the first check.
+func (db *DB) WatchHealth(ctx context.Context, interval time.Duration, notify func(healthy bool, err error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		healthy := true
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			_, err := db.HealthCheck(checkCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if (err == nil) != healthy {
+				healthy = err == nil
+				notify(healthy, err)
+			}
+		}
+	}()
+}
+
+// Connection pools of the primary and of the replicas
+func (db *DB) pools() (*sql.DB, []*sql.DB) {
+	if db.resolver != nil {
+		return db.resolver.primary, db.resolver.replicas
+	}
+	return primaryPool(db.DB.CommonDB()), nil
+}
+
+// Pool of the primary behind common, nil when it is not backed by a *sql.DB
+func primaryPool(common gorm.SQLCommon) *sql.DB {
+	switch pool := unwrapCommon(common).(type) {
+	case *sql.DB:
+		return pool
+	case *resolver:
+		return pool.primary
+	}
+	return nil
+}
+
+// PoolStats returns the stats of the primary pool under "primary" and of each replica pool under "replica<i>"
+func (db *DB) PoolStats() map[string]sql.DBStats {
+	primary, replicas := db.pools()
+	stats := make(map[string]sql.DBStats, 1+len(replicas))
+	if primary != nil {
+		stats["primary"] = primary.Stats()
+	}
+	for i, replica := range replicas {
+		stats["replica"+strconv.Itoa(i)] = replica.Stats()
+	}
+	return stats
+}
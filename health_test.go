@@ -0,0 +1,39 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_HealthCheck(t *testing.T) {
+	gormDB, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	db := &DB{DB: gormDB}
+
+	health, err := db.HealthCheck(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, health.Stats.OpenConnections)
+	assert.Empty(t, health.Replicas)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan bool, 1)
+	db.WatchHealth(ctx, 10*time.Millisecond, func(healthy bool, err error) {
+		changes <- healthy
+	})
+
+	db.Close(context.Background())
+	select {
+	case healthy := <-changes:
+		assert.False(t, healthy)
+	case <-time.After(time.Second):
+		t.Fatal("unhealthy database was not reported")
+	}
+
+	_, err = db.HealthCheck(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+// Package idgen generates the string IDs assigned to records on creation, xid unless configured otherwise,
+// prefixed per model when the model declares a prefix.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rs/xid"
+)
+
+// Model is implemented by the models generating their own IDs, which takes precedence over the generator set
+type Model interface {
+	IDGenerator() string
+}
+
+var generator struct {
+	sync.RWMutex
+	generate func(scope *gorm.Scope) string
+}
+
+// Set makes generate the generator of the IDs of every model, nil restoring XID.
+// The IDs it generates must fit the ID columns, 20 characters for orm.IDModel.
+func Set(generate func(scope *gorm.Scope) string) {
+	generator.Lock()
+	defer generator.Unlock()
+	generator.generate = generate
+}
+
+// New generates the ID of the record of scope, prefixed with the prefix of its model unless the model generates it
+func New(scope *gorm.Scope) string {
+	if model, ok := scope.Value.(Model); ok {
+		return model.IDGenerator()
+	}
+	generator.RLock()
+	generate := generator.generate
+	generator.RUnlock()
+	if generate == nil {
+		generate = XID
+	}
+	return PrefixOf(scope.Value) + generate(scope)
+}
+
+// XID generates a 20 characters xid, sortable by time
+func XID(*gorm.Scope) string {
+	return xid.New().String()
+}
+
+// Crockford's base32 alphabet of ULIDs
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID generates a 26 characters ULID, sortable by time
+func ULID(*gorm.Scope) string {
+	var id [16]byte
+	putMillis(id[:], time.Now())
+	rand.Read(id[6:])
+
+	// 128 bits as 26 characters of 5 bits, the first one holding only 3
+	out := make([]byte, 26)
+	hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out)
+}
+
+// UUIDv7 generates a 36 characters UUID of version 7, sortable by time
+func UUIDv7(*gorm.Scope) string {
+	var id [16]byte
+	putMillis(id[:], time.Now())
+	rand.Read(id[6:])
+	id[6] = id[6]&0x0f | 0x70 // Version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 4122 variant
+
+	out := make([]byte, 36)
+	hex.Encode(out[0:8], id[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], id[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], id[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], id[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], id[10:])
+	return string(out)
+}
+
+// Write the unix time of t in milliseconds to the first 6 bytes of b
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// Epoch of Snowflake IDs, the one of Twitter
+var snowflakeEpoch = time.UnixMilli(1288834974657)
+
+// Snowflake returns a generator of decimal Snowflake IDs: 41 bits of milliseconds, 10 of node and 12 of sequence.
+// Every process generating IDs for the same tables needs a node of its own, between 0 and 1023.
+func Snowflake(node int64) func(*gorm.Scope) string {
+	var mu sync.Mutex
+	var last, sequence int64
+	return func(*gorm.Scope) string {
+		mu.Lock()
+		defer mu.Unlock()
+		now := time.Since(snowflakeEpoch).Milliseconds()
+		if now <= last {
+			// Same millisecond, or the clock went back: go on from the last one
+			now = last
+			sequence = (sequence + 1) & 0xfff
+			if sequence == 0 {
+				now++
+			}
+		} else {
+			sequence = 0
+		}
+		last = now
+		return strconv.FormatInt(now<<22|(node&0x3ff)<<12|sequence, 10)
+	}
+}
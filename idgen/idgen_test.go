@@ -0,0 +1,60 @@
+package idgen
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+type record struct {
+	ID string
+}
+
+type customRecord struct {
+	ID string
+}
+
+func (*customRecord) IDGenerator() string {
+	return "custom"
+}
+
+func Test_New(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.Len(t, New(db.NewScope(&record{})), 20)
+	assert.Equal(t, "custom", New(db.NewScope(&customRecord{})))
+
+	Set(func(scope *gorm.Scope) string { return "set" })
+	assert.Equal(t, "set", New(db.NewScope(&record{})))
+	assert.Equal(t, "custom", New(db.NewScope(&customRecord{})))
+	Set(nil)
+	assert.Len(t, New(db.NewScope(&record{})), 20)
+}
+
+func Test_generators(t *testing.T) {
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`), ULID(nil))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), UUIDv7(nil))
+
+	snowflake := Snowflake(5)
+	ids := make([]string, 5000)
+	seen := make(map[string]bool)
+	for i := range ids {
+		ids[i] = snowflake(nil)
+		assert.False(t, seen[ids[i]])
+		seen[ids[i]] = true
+	}
+	assert.True(t, sort.SliceIsSorted(ids, func(i, j int) bool {
+		a, _ := strconv.ParseInt(ids[i], 10, 64)
+		b, _ := strconv.ParseInt(ids[j], 10, 64)
+		return a < b
+	}))
+	id, _ := strconv.ParseInt(ids[0], 10, 64)
+	assert.Equal(t, int64(5), id>>12&0x3ff)
+}
@@ -0,0 +1,93 @@
+package idgen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Prefixed is implemented by the models whose IDs start with a prefix, like "usr_".
+// The prefix can be declared with an idprefix tag on the ID field instead.
+// The ID columns must fit the prefix on top of the generated ID.
+type Prefixed interface {
+	IDPrefix() string
+}
+
+// PrefixOf returns the ID prefix of model, empty when it has none
+func PrefixOf(model interface{}) string {
+	if prefixed, ok := model.(Prefixed); ok {
+		return prefixed.IDPrefix()
+	}
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+	if field, ok := t.FieldByName("ID"); ok {
+		return field.Tag.Get("idprefix")
+	}
+	return ""
+}
+
+var prefixes struct {
+	sync.RWMutex
+	models map[string]reflect.Type
+}
+
+// Register records the prefixes of models so that Parse tells which model an ID is of.
+// It fails on a model without prefix or whose prefix is taken by another model.
+func Register(models ...interface{}) error {
+	prefixes.Lock()
+	defer prefixes.Unlock()
+	if prefixes.models == nil {
+		prefixes.models = make(map[string]reflect.Type)
+	}
+	for _, model := range models {
+		prefix, t := PrefixOf(model), modelType(model)
+		if prefix == "" {
+			return fmt.Errorf("idgen: %s has no ID prefix", t)
+		}
+		if registered, ok := prefixes.models[prefix]; ok && registered != t {
+			return fmt.Errorf("idgen: prefix %q of %s is taken by %s", prefix, t, registered)
+		}
+		prefixes.models[prefix] = t
+	}
+	return nil
+}
+
+// Parse returns the type of the model registered with the prefix of id, and id without the prefix
+func Parse(id string) (reflect.Type, string, error) {
+	prefixes.RLock()
+	defer prefixes.RUnlock()
+	var found string
+	for prefix := range prefixes.models {
+		if strings.HasPrefix(id, prefix) && len(prefix) > len(found) {
+			found = prefix
+		}
+	}
+	if found == "" || len(id) == len(found) {
+		return nil, "", fmt.Errorf("idgen: %q is not the ID of a registered model", id)
+	}
+	return prefixes.models[found], id[len(found):], nil
+}
+
+// Validate checks that id has the prefix of model followed by an ID
+func Validate(model interface{}, id string) error {
+	prefix := PrefixOf(model)
+	if !strings.HasPrefix(id, prefix) || len(id) == len(prefix) {
+		return fmt.Errorf("idgen: %q is not an ID of %s", id, modelType(model))
+	}
+	return nil
+}
+
+// Struct type of model, which may be a pointer to it
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,62 @@
+package idgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID string `idprefix:"usr_"`
+}
+
+type order struct {
+	ID string
+}
+
+func (order) IDPrefix() string {
+	return "ord_"
+}
+
+func Test_PrefixOf(t *testing.T) {
+	assert.Equal(t, "usr_", PrefixOf(&user{}))
+	assert.Equal(t, "ord_", PrefixOf(order{}))
+	assert.Equal(t, "ord_", PrefixOf(&order{}))
+	assert.Equal(t, "", PrefixOf(&record{}))
+	assert.Equal(t, "", PrefixOf(nil))
+}
+
+func Test_New_prefixed(t *testing.T) {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	id := New(db.NewScope(&user{}))
+	assert.True(t, strings.HasPrefix(id, "usr_"))
+	assert.Len(t, id, 24)
+	assert.NoError(t, Validate(&user{}, id))
+	assert.Error(t, Validate(&order{}, id))
+	assert.Error(t, Validate(&user{}, "usr_"))
+}
+
+func Test_Parse(t *testing.T) {
+	assert.NoError(t, Register(&user{}, order{}))
+	assert.Error(t, Register(&record{}))
+
+	model, id, err := Parse("usr_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, reflect.TypeOf(user{}), model)
+	assert.Equal(t, "abc", id)
+
+	model, _, err = Parse("ord_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, reflect.TypeOf(order{}), model)
+
+	_, _, err = Parse("cus_abc")
+	assert.Error(t, err)
+	_, _, err = Parse("usr_")
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ImmutableError is the error of an update changing a field tagged `orm:"immutable"`
+type ImmutableError struct {
+	Field string
+}
+
+func (e *ImmutableError) Error() string {
+	return fmt.Sprintf("orm: %s is immutable", e.Field)
+}
+
+// IsImmutable reports whether err is, or contains, an ImmutableError
+func IsImmutable(err error) bool {
+	if errs, ok := err.(gorm.Errors); ok {
+		for _, err := range errs {
+			if IsImmutable(err) {
+				return true
+			}
+		}
+	}
+	var immutable *ImmutableError
+	return errors.As(err, &immutable)
+}
+
+// Whether field is tagged `orm:"immutable"`
+func isImmutable(field *gorm.StructField) bool {
+	for _, option := range strings.Split(field.Tag.Get("orm"), ",") {
+		if strings.TrimSpace(option) == "immutable" {
+			return true
+		}
+	}
+	return false
+}
+
+// Register the callback protecting the fields tagged `orm:"immutable"` from updates.
+// Updates and UpdateColumns setting an immutable field fail with an ImmutableError, even to its current value.
+// Save sends every field, the immutable ones are left out of the statement.
+func registerImmutable(db *gorm.DB) {
+	protect := func(scope *gorm.Scope) {
+		var immutable []string
+		for _, field := range scope.GetModelStruct().StructFields {
+			if isImmutable(field) {
+				immutable = append(immutable, field.DBName)
+			}
+		}
+		if len(immutable) == 0 {
+			return
+		}
+
+		attrs, ok := scope.InstanceGet("gorm:update_attrs")
+		if !ok {
+			scope.Search.Omit(append(omitted(scope), immutable...)...)
+			return
+		}
+		for column := range attrs.(map[string]interface{}) {
+			if field, ok := scope.FieldByName(column); ok && isImmutable(field.StructField) {
+				scope.Err(&ImmutableError{Field: field.Name})
+				return
+			}
+		}
+	}
+
+	db.Callback().Update().After("gorm:assign_updating_attributes").Register("orm:immutable", protect)
+}
+
+// Columns omitted from the statement of scope.
+// gorm keeps them unexported, they are read through reflection.
+func omitted(scope *gorm.Scope) []string {
+	omits := reflect.ValueOf(scope.Search).Elem().FieldByName("omits")
+	columns := make([]string, omits.Len())
+	for i := range columns {
+		columns[i] = omits.Index(i).String()
+	}
+	return columns
+}
@@ -0,0 +1,48 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type immutableDB struct {
+	ID      int
+	Owner   string `orm:"immutable"`
+	Name    string
+	Comment string
+}
+
+func TestImmutable(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&immutableDB{}).Error)
+
+	record := immutableDB{ID: 1, Owner: "alice", Name: "a"}
+	assert.NoError(t, db.Create(&record).Error)
+
+	record.Owner, record.Name = "mallory", "b"
+	assert.NoError(t, db.Save(&record).Error)
+	var stored immutableDB
+	assert.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, immutableDB{ID: 1, Owner: "alice", Name: "b"}, stored)
+
+	assert.NoError(t, db.Omit("comment").Save(&immutableDB{ID: 1, Owner: "mallory", Name: "c", Comment: "lost"}).Error)
+	stored = immutableDB{}
+	assert.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, immutableDB{ID: 1, Owner: "alice", Name: "c"}, stored)
+
+	err := db.Model(&stored).Updates(map[string]interface{}{"owner": "mallory", "name": "d"}).Error
+	assert.True(t, IsImmutable(err))
+	assert.EqualError(t, err, "orm: Owner is immutable")
+	assert.True(t, IsImmutable(db.Model(&stored).UpdateColumn("owner", "mallory").Error))
+	// Blank fields of structs are not sent
+	assert.NoError(t, db.Model(&stored).Updates(immutableDB{Name: "e"}).Error)
+
+	stored = immutableDB{}
+	assert.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, immutableDB{ID: 1, Owner: "alice", Name: "e"}, stored)
+}
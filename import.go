@@ -0,0 +1,468 @@
+package orm
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+type ImportFormat int
+
+const (
+	// CSV with a header line naming the columns, unless columns are given with ImportTableOpt
+	CSVFormat ImportFormat = iota
+	// One JSON object per line, keys name the columns
+	JSONLinesFormat
+)
+
+type Importer struct {
+	format    ImportFormat
+	table     string
+	columns   []string
+	dryRun    bool
+	batchSize int
+	opts      []bulk_insert.BuilderOpt
+}
+
+type ImportOpt func(*Importer)
+
+func ImportFormatOpt(format ImportFormat) ImportOpt {
+	return func(c *Importer) {
+		c.format = format
+	}
+}
+
+// ImportTableOpt imports into table instead of a model, values are inserted as read.
+// When columns are given, CSV input has no header line and JSON keys are restricted to them.
+func ImportTableOpt(table string, columns ...string) ImportOpt {
+	return func(c *Importer) {
+		c.table = table
+		c.columns = columns
+	}
+}
+
+// ImportDryRunOpt parses and validates every row without inserting anything
+func ImportDryRunOpt(dryRun bool) ImportOpt {
+	return func(c *Importer) {
+		c.dryRun = dryRun
+	}
+}
+
+// ImportBatchSizeOpt sets how many rows are read before they are handed to bulk insert
+func ImportBatchSizeOpt(batchSize int) ImportOpt {
+	return func(c *Importer) {
+		c.batchSize = batchSize
+	}
+}
+
+// ImportBuilderOpt passes options to the underlying bulk insert builder
+func ImportBuilderOpt(opts ...bulk_insert.BuilderOpt) ImportOpt {
+	return func(c *Importer) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// ImportRowError reports a row which could not be imported, Line counts data rows from 1
+type ImportRowError struct {
+	Line int
+	Err  error
+}
+
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+type ImportResult struct {
+	// Rows read from the input
+	Rows int
+	// Rows handed to bulk insert, zero in dry-run mode
+	Inserted int
+	// Rows skipped because they could not be parsed or validated
+	Errors []*ImportRowError
+}
+
+func NewImporter(opts ...ImportOpt) *Importer {
+	i := &Importer{
+		batchSize: 10000,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Import reads rows from r and bulk inserts them as values of model, a struct or a pointer to one.
+// Rows failing to map onto the model are skipped and reported in the result, model is ignored with ImportTableOpt.
+func Import(db *gorm.DB, r io.Reader, model interface{}, opts ...ImportOpt) (*ImportResult, error) {
+	return NewImporter(opts...).Exec(db, r, model)
+}
+
+func (db *DB) Import(r io.Reader, model interface{}, opts ...ImportOpt) (*ImportResult, error) {
+	return NewImporter(opts...).Exec(db.DB, r, model)
+}
+
+func (tx *TX) Import(r io.Reader, model interface{}, opts ...ImportOpt) (*ImportResult, error) {
+	return NewImporter(opts...).Exec(tx.DB, r, model)
+}
+
+// A row as read from the input, CSV values are strings and JSON values raw messages.
+// err is set when the row could not be parsed at all.
+type importRow struct {
+	line   int
+	values map[string]interface{}
+	err    error
+}
+
+func (i *Importer) Exec(db *gorm.DB, r io.Reader, model interface{}) (*ImportResult, error) {
+	var sink importSink
+	if i.table != "" {
+		sink = &mapSink{importer: i, db: db}
+	} else {
+		modelType := reflect.TypeOf(model)
+		for modelType != nil && modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		if modelType == nil || modelType.Kind() != reflect.Struct {
+			return nil, errors.New("model must be a struct or a table must be given")
+		}
+		sink = &modelSink{
+			importer: i,
+			db:       db,
+			fields:   (&gorm.Scope{Value: reflect.New(modelType).Interface()}).GetModelStruct().StructFields,
+			rows:     reflect.MakeSlice(reflect.SliceOf(modelType), 0, i.batchSize),
+		}
+	}
+
+	result := &ImportResult{}
+	consume := func(row importRow) error {
+		result.Rows++
+		if row.err == nil {
+			row.err = sink.add(row.values)
+		}
+		if err := row.err; err != nil {
+			result.Errors = append(result.Errors, &ImportRowError{Line: row.line, Err: err})
+			return nil
+		}
+		if sink.len() >= i.batchSize {
+			return i.flush(sink, result)
+		}
+		return nil
+	}
+
+	var err error
+	switch i.format {
+	case CSVFormat:
+		err = i.readCSV(r, consume)
+	case JSONLinesFormat:
+		err = i.readJSONLines(r, consume)
+	default:
+		err = fmt.Errorf("unknown import format %d", i.format)
+	}
+	if err != nil {
+		return result, err
+	}
+	return result, i.flush(sink, result)
+}
+
+func (i *Importer) flush(sink importSink, result *ImportResult) error {
+	size := sink.len()
+	if size == 0 {
+		return nil
+	}
+	if i.dryRun {
+		sink.reset()
+		return nil
+	}
+	if err := sink.insert(); err != nil {
+		return err
+	}
+	result.Inserted += size
+	return nil
+}
+
+func (i *Importer) readCSV(r io.Reader, consume func(importRow) error) error {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header := i.columns
+	if len(header) == 0 {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		header = append([]string(nil), record...)
+	}
+	reader.FieldsPerRecord = len(header)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		values := make(map[string]interface{}, len(header))
+		for j, column := range header {
+			values[column] = record[j]
+		}
+		if err := consume(importRow{line: line, values: values}); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *Importer) readJSONLines(r io.Reader, consume func(importRow) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var raw map[string]json.RawMessage
+		values := map[string]interface{}{}
+		err := json.Unmarshal([]byte(text), &raw)
+		for key, value := range raw {
+			if len(i.columns) > 0 && !containString(i.columns, key) {
+				err = fmt.Errorf("unknown column %s", key)
+				break
+			}
+			values[key] = value
+		}
+		if err := consume(importRow{line: line, values: values, err: err}); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+type importSink interface {
+	add(values map[string]interface{}) error
+	len() int
+	insert() error
+	reset()
+}
+
+// Collects rows as column maps for ImportTableOpt
+type mapSink struct {
+	importer *Importer
+	db       *gorm.DB
+	rows     []map[string]interface{}
+}
+
+func (s *mapSink) add(values map[string]interface{}) error {
+	row := make(map[string]interface{}, len(values))
+	for column, value := range values {
+		switch v := value.(type) {
+		case json.RawMessage:
+			var decoded interface{}
+			decoder := json.NewDecoder(bytes.NewReader(v))
+			decoder.UseNumber()
+			if err := decoder.Decode(&decoded); err != nil {
+				return fmt.Errorf("column %s: %s", column, err)
+			}
+			switch decoded.(type) {
+			case map[string]interface{}, []interface{}:
+				// Nested documents are stored as JSON text
+				decoded = string(v)
+			}
+			row[column] = decoded
+		default:
+			row[column] = v
+		}
+	}
+	if len(s.rows) > 0 {
+		if len(s.rows[0]) != len(row) {
+			return fmt.Errorf("row has %d columns, expected %d", len(row), len(s.rows[0]))
+		}
+		for column := range row {
+			if _, ok := s.rows[0][column]; !ok {
+				return fmt.Errorf("unexpected column %s", column)
+			}
+		}
+	}
+	s.rows = append(s.rows, row)
+	return nil
+}
+
+func (s *mapSink) len() int {
+	return len(s.rows)
+}
+
+func (s *mapSink) insert() error {
+	defer s.reset()
+	return bulk_insert.NewBuilder(s.importer.opts...).ExecMaps(s.db, s.importer.table, s.rows)
+}
+
+func (s *mapSink) reset() {
+	s.rows = nil
+}
+
+// Collects rows as values of the model
+type modelSink struct {
+	importer *Importer
+	db       *gorm.DB
+	fields   []*gorm.StructField
+	rows     reflect.Value
+}
+
+func (s *modelSink) add(values map[string]interface{}) error {
+	elem := reflect.New(s.rows.Type().Elem()).Elem()
+	for column, value := range values {
+		field := s.field(column)
+		if field == nil {
+			return fmt.Errorf("unknown column %s", column)
+		}
+		target := fieldByNames(elem, field.Names)
+
+		var err error
+		switch v := value.(type) {
+		case string:
+			err = setString(target, v)
+		case json.RawMessage:
+			err = json.Unmarshal(v, target.Addr().Interface())
+		}
+		if err != nil {
+			return fmt.Errorf("column %s: %s", column, err)
+		}
+	}
+	s.rows = reflect.Append(s.rows, elem)
+	return nil
+}
+
+// Find the column of the model by field or column name
+func (s *modelSink) field(column string) *gorm.StructField {
+	for _, field := range s.fields {
+		if field.IsIgnored || field.Relationship != nil || !field.IsNormal {
+			continue
+		}
+		if field.Name == column || field.DBName == column {
+			return field
+		}
+	}
+	return nil
+}
+
+func (s *modelSink) len() int {
+	return s.rows.Len()
+}
+
+func (s *modelSink) insert() error {
+	defer s.reset()
+	return bulk_insert.NewBuilder(s.importer.opts...).Exec(s.db, s.rows.Interface())
+}
+
+func (s *modelSink) reset() {
+	s.rows = s.rows.Slice(0, 0)
+}
+
+// Walk to an embedded field, allocating nil embedded pointers on the way
+func fieldByNames(value reflect.Value, names []string) reflect.Value {
+	for _, name := range names {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.FieldByName(name)
+	}
+	return value
+}
+
+var importTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+// Parse a CSV value into field according to its type, blank values leave pointers nil
+func setString(field reflect.Value, s string) error {
+	if field.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		value := reflect.New(field.Type().Elem())
+		if err := setString(value.Elem(), s); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		for _, layout := range importTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid time %q", s)
+	}
+	if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+		if s == "" {
+			return scanner.Scan(nil)
+		}
+		return scanner.Scan(s)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		field.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func containString(s []string, value string) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type importDB struct {
+	ID        int
+	Name      string
+	Age       *int
+	Active    bool
+	CreatedAt time.Time
+}
+
+func openImportDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&importDB{}).Error)
+	return db
+}
+
+func TestImport_csv(t *testing.T) {
+	db := openImportDB(t)
+	defer db.Close()
+
+	input := "name,age,active\nalice,30,true\nbob,,false\ncarol,old,true\n"
+	result, err := Import(db, strings.NewReader(input), importDB{}, ImportBatchSizeOpt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Rows)
+	assert.Equal(t, 2, result.Inserted)
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error(), "column age")
+
+	var stored []importDB
+	assert.NoError(t, db.Order("name").Find(&stored).Error)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, 30, *stored[0].Age)
+	assert.True(t, stored[0].Active)
+	assert.Nil(t, stored[1].Age)
+
+	// unknown columns are reported against every row
+	result, err = Import(db, strings.NewReader("nickname\nx\n"), &importDB{})
+	assert.NoError(t, err)
+	assert.Equal(t, "line 1: unknown column nickname", result.Errors[0].Error())
+}
+
+func TestImport_jsonLines(t *testing.T) {
+	db := openImportDB(t)
+	defer db.Close()
+
+	input := `{"name": "alice", "age": 30}
+{"name": "bob", "age": "thirty"}
+not json
+
+{"Name": "carol", "CreatedAt": "2019-06-01T10:00:00Z"}
+`
+	result, err := Import(db, strings.NewReader(input), importDB{}, ImportFormatOpt(JSONLinesFormat), ImportDryRunOpt(true))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Rows)
+	assert.Equal(t, 0, result.Inserted)
+	if assert.Len(t, result.Errors, 2) {
+		assert.Equal(t, 2, result.Errors[0].Line)
+		assert.Equal(t, 3, result.Errors[1].Line)
+	}
+
+	var count int
+	assert.NoError(t, db.Model(&importDB{}).Count(&count).Error)
+	assert.Equal(t, 0, count)
+
+	result, err = Import(db, strings.NewReader(input), importDB{}, ImportFormatOpt(JSONLinesFormat))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+
+	var carol importDB
+	assert.NoError(t, db.Where("name = ?", "carol").Find(&carol).Error)
+	assert.Equal(t, time.Date(2019, 6, 1, 10, 0, 0, 0, time.UTC), carol.CreatedAt.UTC())
+}
+
+func TestImport_table(t *testing.T) {
+	db := openImportDB(t)
+	defer db.Close()
+
+	result, err := Import(db, strings.NewReader("alice,1\nbob,0\n"), nil, ImportTableOpt("import_dbs", "name", "active"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Inserted)
+
+	input := `{"name": "carol", "active": true}
+{"name": "dave", "age": 1}
+`
+	result, err = Import(db, strings.NewReader(input), nil, ImportFormatOpt(JSONLinesFormat), ImportTableOpt("import_dbs", "name", "active"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Inserted)
+	assert.Equal(t, "line 2: unknown column age", result.Errors[0].Error())
+
+	var names []string
+	assert.NoError(t, db.Model(&importDB{}).Order("name").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, names)
+}
@@ -0,0 +1,182 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Index describes an index on the table of a model, for those tags can not declare, like partial ones
+type Index struct {
+	Name    string   // Written unquoted, as gorm writes the indexes of tags
+	Columns []string // In order, plain column names
+	Unique  bool
+	Where   string // Condition of a partial index, not supported by mysql
+}
+
+// IndexMismatchError is the error of an index existing with other columns or uniqueness than declared
+type IndexMismatchError struct {
+	Table    string
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e *IndexMismatchError) Error() string {
+	return fmt.Sprintf("orm: index %s on %s is %s, declared %s", e.Name, e.Table, e.Actual, e.Expected)
+}
+
+// EnsureIndex creates the index name on columns of the table of model when missing, see Index.Ensure
+func (db *DB) EnsureIndex(model interface{}, name string, columns ...string) error {
+	return Index{Name: name, Columns: columns}.Ensure(db.DB, model)
+}
+
+// EnsureUniqueIndex creates the unique index name on columns of the table of model when missing, see Index.Ensure
+func (db *DB) EnsureUniqueIndex(model interface{}, name string, columns ...string) error {
+	return Index{Name: name, Columns: columns, Unique: true}.Ensure(db.DB, model)
+}
+
+// Ensure creates the index on the table of model with db when missing.
+// An index of the same name with other columns or uniqueness fails with an IndexMismatchError, Where is not compared.
+// On postgres, outside of a transaction, the index is built CONCURRENTLY so that writes go on meanwhile,
+// an invalid index left by an interrupted build being replaced. In a migration, see migrate.Migration.NoTransaction.
+func (idx Index) Ensure(db *gorm.DB, model interface{}) error {
+	scope := db.NewScope(model)
+	table := scope.TableName()
+	dialect := scope.Dialect().GetName()
+	if len(idx.Columns) == 0 {
+		return fmt.Errorf("orm: index %s has no column", idx.Name)
+	}
+	if idx.Where != "" && dialect == "mysql" {
+		return fmt.Errorf("orm: partial index %s is not supported by mysql", idx.Name)
+	}
+
+	existing, valid, err := readIndex(db, dialect, table, idx.Name)
+	if err != nil {
+		return err
+	}
+	concurrently := dialect == "postgres" && !inTransaction(db)
+	if existing != nil && valid {
+		if actual, expected := existing.definition(), idx.definition(); actual != expected {
+			return &IndexMismatchError{Table: table, Name: idx.Name, Expected: expected, Actual: actual}
+		}
+		return nil
+	}
+	if existing != nil {
+		if err := db.Exec(idx.dropSQL(dialect, scope, concurrently)).Error; err != nil {
+			return err
+		}
+	}
+
+	create := "CREATE "
+	if idx.Unique {
+		create += "UNIQUE "
+	}
+	create += "INDEX "
+	if concurrently {
+		create += "CONCURRENTLY "
+	}
+	if dialect == "postgres" || dialect == "sqlite3" {
+		create += "IF NOT EXISTS "
+	}
+	columns := make([]string, len(idx.Columns))
+	for i, column := range idx.Columns {
+		columns[i] = scope.Quote(column)
+	}
+	create += fmt.Sprintf("%s ON %s (%s)", idx.Name, scope.QuotedTableName(), strings.Join(columns, ", "))
+	if idx.Where != "" {
+		create += " WHERE " + idx.Where
+	}
+	return db.Exec(create).Error
+}
+
+// Drop removes the index from the table of model with db when it exists, CONCURRENTLY on postgres outside of a transaction
+func (idx Index) Drop(db *gorm.DB, model interface{}) error {
+	scope := db.NewScope(model)
+	dialect := scope.Dialect().GetName()
+	if dialect == "mysql" || dialect == "mssql" {
+		existing, _, err := readIndex(db, dialect, scope.TableName(), idx.Name)
+		if err != nil || existing == nil {
+			return err
+		}
+	}
+	return db.Exec(idx.dropSQL(dialect, scope, dialect == "postgres" && !inTransaction(db))).Error
+}
+
+func (idx Index) dropSQL(dialect string, scope *gorm.Scope, concurrently bool) string {
+	switch dialect {
+	case "mysql", "mssql":
+		return fmt.Sprintf("DROP INDEX %s ON %s", scope.Quote(idx.Name), scope.QuotedTableName())
+	case "postgres":
+		if concurrently {
+			return "DROP INDEX CONCURRENTLY IF EXISTS " + scope.Quote(idx.Name)
+		}
+	}
+	return "DROP INDEX IF EXISTS " + scope.Quote(idx.Name)
+}
+
+// Columns and uniqueness, as reported by errors
+func (idx Index) definition() string {
+	definition := "(" + strings.ToLower(strings.Join(idx.Columns, ", ")) + ")"
+	if idx.Unique {
+		return "unique " + definition
+	}
+	return definition
+}
+
+// Whether db runs in a transaction
+func inTransaction(db *gorm.DB) bool {
+	switch unwrapCommon(db.CommonDB()).(type) {
+	case *sql.Tx, *xaConn:
+		return true
+	}
+	return false
+}
+
+// Read the index name of table, nil when it does not exist, and whether it is valid,
+// which it is not on postgres after a concurrent build was interrupted
+func readIndex(db *gorm.DB, dialect, table, name string) (index *Index, valid bool, err error) {
+	var query string
+	switch dialect {
+	case "postgres":
+		query = `SELECT a.attname, ix.indisunique, ix.indisvalid FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_class t ON t.oid = ix.indrelid
+			JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey)
+			WHERE t.relname = $1 AND i.relname = $2 AND t.relkind IN ('r', 'p')
+			ORDER BY array_position(ix.indkey::int2[], a.attnum)`
+	case "mysql":
+		query = `SELECT column_name, non_unique = 0, 1 FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ? ORDER BY seq_in_index`
+	case "sqlite3":
+		query = `SELECT c.name, l."unique", 1 FROM pragma_index_list(?) l, pragma_index_info(l.name) c
+			WHERE l.name = ? ORDER BY c.seqno`
+	case "mssql":
+		query = `SELECT c.name, i.is_unique, 1 FROM sys.indexes i
+			JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+			JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+			WHERE i.object_id = OBJECT_ID(@p1) AND i.name = @p2 ORDER BY ic.key_ordinal`
+	default:
+		return nil, false, fmt.Errorf("orm: indexes are not supported by %s", dialect)
+	}
+
+	rows, err := db.CommonDB().Query(query, table, name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column string
+		var unique bool
+		if err := rows.Scan(&column, &unique, &valid); err != nil {
+			return nil, false, err
+		}
+		if index == nil {
+			index = &Index{Name: name, Unique: unique}
+		}
+		index.Columns = append(index.Columns, column)
+	}
+	return index, valid, rows.Err()
+}
@@ -0,0 +1,53 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type indexDB struct {
+	ID        int
+	Email     string
+	Name      string
+	DeletedAt *string
+}
+
+func TestDB_EnsureIndex(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&indexDB{}).Error)
+
+	assert.NoError(t, db.EnsureIndex(&indexDB{}, "idx_index_name_email", "name", "email"))
+	assert.True(t, db.Dialect().HasIndex("index_db", "idx_index_name_email"))
+	assert.NoError(t, db.EnsureIndex(&indexDB{}, "idx_index_name_email", "name", "email"))
+
+	err := db.EnsureIndex(&indexDB{}, "idx_index_name_email", "email", "name")
+	assert.EqualError(t, err, "orm: index idx_index_name_email on index_db is (name, email), declared (email, name)")
+	assert.IsType(t, &IndexMismatchError{}, err)
+	assert.Error(t, db.EnsureUniqueIndex(&indexDB{}, "idx_index_name_email", "name", "email"))
+
+	live := Index{Name: "uix_index_email", Columns: []string{"email"}, Unique: true, Where: "deleted_at IS NULL"}
+	assert.NoError(t, live.Ensure(db.DB, &indexDB{}))
+	deleted := "yes"
+	assert.NoError(t, db.Create(&indexDB{ID: 1, Email: "a", DeletedAt: &deleted}).Error)
+	assert.NoError(t, db.Create(&indexDB{ID: 2, Email: "a"}).Error)
+	assert.Error(t, db.Create(&indexDB{ID: 3, Email: "a"}).Error)
+
+	assert.NoError(t, live.Drop(db.DB, &indexDB{}))
+	assert.False(t, db.Dialect().HasIndex("index_db", "uix_index_email"))
+	assert.NoError(t, live.Drop(db.DB, &indexDB{}))
+	assert.Error(t, Index{Name: "empty"}.Ensure(db.DB, &indexDB{}))
+
+	tx := db.BeginTx(context.Background(), sql.TxOptions{})
+	defer tx.End()
+	assert.True(t, inTransaction(tx.DB))
+	assert.False(t, inTransaction(db.DB))
+	assert.NoError(t, Index{Name: "idx_index_name", Columns: []string{"name"}}.Ensure(tx.DB, &indexDB{}))
+	assert.NoError(t, tx.CommitE())
+	assert.True(t, db.Dialect().HasIndex("index_db", "idx_index_name"))
+}
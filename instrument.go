@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Logger receives every statement run through gorm's callbacks once it completes
+type Logger interface {
+	Query(sql string, args []interface{}, duration time.Duration, rows int64, err error)
+}
+
+// QueryEvent describes a completed statement, or a bulk operation made of several statements
+type QueryEvent struct {
+	// select, insert, update, delete or bulk
+	Operation string
+	SQL       string
+	Args      []interface{}
+	Duration  time.Duration
+	Rows      int64
+	Err       error
+}
+
+// QueryObserver is notified of every QueryEvent of a DB, like metrics collectors
+type QueryObserver interface {
+	ObserveQuery(e QueryEvent)
+}
+
+// instrument holds what is reported about the statements of a DB, shared by its primary and replicas
+type instrument struct {
+	mu            sync.RWMutex
+	logger        Logger
+	slowThreshold time.Duration
+	slowHandler   func(SlowQuery)
+	redactArgs    bool
+	observers     []QueryObserver
+	tracing       *tracing
+
+	longTxThreshold time.Duration
+	longTxHandler   func(TxStats)
+}
+
+const startedAtKey = "orm:started_at"
+
+// Register the callbacks timing every create, query, update, delete and row query of db
+func (in *instrument) register(db *gorm.DB) {
+	start := func(operation string) func(scope *gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			scope.InstanceSet(startedAtKey, time.Now())
+			in.startSpan(scope, operation)
+		}
+	}
+	finish := func(operation string) func(scope *gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			in.endSpan(scope)
+			startedAt, ok := scope.InstanceGet(startedAtKey)
+			if !ok || scope.SQL == "" {
+				return
+			}
+			in.report(QueryEvent{
+				Operation: operation,
+				SQL:       scope.SQL,
+				Args:      scope.SQLVars,
+				Duration:  time.Since(startedAt.(time.Time)),
+				Rows:      scope.DB().RowsAffected,
+				Err:       scope.DB().Error,
+			})
+			recordTxStatement(scope, scope.DB().RowsAffected)
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:begin_transaction").Register("orm:start", start("insert"))
+	callback.Create().After("gorm:commit_or_rollback_transaction").Register("orm:finish", finish("insert"))
+	callback.Update().Before("gorm:assign_updating_attributes").Register("orm:start", start("update"))
+	callback.Update().After("gorm:commit_or_rollback_transaction").Register("orm:finish", finish("update"))
+	callback.Delete().Before("gorm:begin_transaction").Register("orm:start", start("delete"))
+	callback.Delete().After("gorm:commit_or_rollback_transaction").Register("orm:finish", finish("delete"))
+	callback.Query().Before("gorm:query").Register("orm:start", start("select"))
+	callback.Query().After("gorm:after_query").Register("orm:finish", finish("select"))
+	callback.RowQuery().Before("gorm:row_query").Register("orm:start", start("select"))
+	callback.RowQuery().After("gorm:row_query").Register("orm:finish", finish("select"))
+}
+
+func (in *instrument) report(e QueryEvent) {
+	in.mu.RLock()
+	logger, observers := in.logger, in.observers
+	in.mu.RUnlock()
+
+	if e.Operation != "bulk" {
+		if logger != nil {
+			logger.Query(e.SQL, e.Args, e.Duration, e.Rows, e.Err)
+		}
+		in.reportSlow(e.SQL, e.Args, e.Duration, e.Rows, e.Err)
+	}
+	for _, observer := range observers {
+		observer.ObserveQuery(e)
+	}
+}
+
+// Time the bulk operation fn, its statements bypass gorm's callbacks
+func (in *instrument) observeBulk(ctx context.Context, fn func() error) error {
+	if in == nil {
+		return fn()
+	}
+	startedAt := time.Now()
+	err := in.trace(ctx, "orm.bulk", fn)
+	in.report(QueryEvent{Operation: "bulk", Duration: time.Since(startedAt), Err: err})
+	return err
+}
+
+// SetQueryLogger sends every statement to logger, nil stops logging
+func (db *DB) SetQueryLogger(logger Logger) {
+	in := db.instrumented()
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.logger = logger
+}
+
+// AddQueryObserver notifies observer of every statement and bulk operation from now on
+func (db *DB) AddQueryObserver(observer QueryObserver) {
+	in := db.instrumented()
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.observers = append(in.observers[:len(in.observers):len(in.observers)], observer)
+}
+
+// Instrument of db, registered on demand for a DB that was not opened by this package
+func (db *DB) instrumented() *instrument {
+	if db.instrument == nil {
+		db.instrument = &instrument{}
+		db.instrument.register(db.DB)
+	}
+	return db.instrument
+}
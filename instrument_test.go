@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type loggedQuery struct {
+	sql  string
+	args []interface{}
+	rows int64
+	err  error
+}
+
+type recordLogger struct {
+	queries []loggedQuery
+}
+
+func (l *recordLogger) Query(sql string, args []interface{}, duration time.Duration, rows int64, err error) {
+	l.queries = append(l.queries, loggedQuery{sql: sql, args: args, rows: rows, err: err})
+}
+
+type instrumentDB struct {
+	ID   int
+	Name string
+}
+
+func TestDB_SetQueryLogger(t *testing.T) {
+	gormDB, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer gormDB.Close()
+	assert.NoError(t, gormDB.AutoMigrate(&instrumentDB{}).Error)
+
+	db, logger := &DB{DB: gormDB}, &recordLogger{}
+	db.SetQueryLogger(logger)
+
+	assert.NoError(t, db.Create(&instrumentDB{Name: "alice"}).Error)
+	var found instrumentDB
+	assert.True(t, IsRecordNotFound(db.Where("name = ?", "bob").First(&found).Error))
+
+	assert.Len(t, logger.queries, 2)
+	assert.Contains(t, logger.queries[0].sql, "INSERT")
+	assert.Equal(t, []interface{}{"alice"}, logger.queries[0].args)
+	assert.Equal(t, int64(1), logger.queries[0].rows)
+	assert.Contains(t, logger.queries[1].sql, "SELECT")
+	assert.Equal(t, gorm.ErrRecordNotFound, logger.queries[1].err)
+
+	db.SetQueryLogger(nil)
+	assert.NoError(t, db.Find(&[]instrumentDB{}).Error)
+	assert.Len(t, logger.queries, 2)
+}
@@ -0,0 +1,76 @@
+// Package dberr classifies the errors of the database drivers, for the orm and bulk_insert packages
+package dberr
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+)
+
+// IsRetryable reports whether err, an error it wraps or one of gorm.Errors, is a transient locking error after which
+// the statement or the transaction may succeed when run again: MySQL deadlocks (1213) and lock wait timeouts (1205),
+// Postgres serialization failures (40001) and deadlocks (40P01), MSSQL deadlock victims (1205) and a locked SQLite database
+func IsRetryable(err error) bool {
+	return matchError(err, func(err error) bool {
+		var mysqlErr *mysql.MySQLError
+		var pqErr *pq.Error
+		var mssqlErr interface{ SQLErrorNumber() int32 }
+		switch {
+		case errors.As(err, &mysqlErr):
+			return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+		case errors.As(err, &pqErr):
+			return pqErr.Code == "40001" || pqErr.Code == "40P01"
+		case errors.As(err, &mssqlErr):
+			return mssqlErr.SQLErrorNumber() == 1205
+		}
+		return strings.Contains(err.Error(), "database is locked")
+	})
+}
+
+// IsSerializationFailure reports whether err, an error it wraps or one of gorm.Errors, tells a Postgres
+// or CockroachDB transaction to restart, SQLSTATE 40001
+func IsSerializationFailure(err error) bool {
+	return matchError(err, func(err error) bool {
+		var pqErr *pq.Error
+		return errors.As(err, &pqErr) && pqErr.Code == "40001"
+	})
+}
+
+// IsUniqueViolation reports whether err, an error it wraps or one of gorm.Errors, is the violation of a unique key:
+// MySQL 1062, Postgres 23505, MSSQL 2627 and 2601 or a SQLite UNIQUE constraint
+func IsUniqueViolation(err error) bool {
+	return matchError(err, func(err error) bool {
+		var mysqlErr *mysql.MySQLError
+		var pqErr *pq.Error
+		var mssqlErr interface{ SQLErrorNumber() int32 }
+		switch {
+		case errors.As(err, &mysqlErr):
+			return mysqlErr.Number == 1062
+		case errors.As(err, &pqErr):
+			return pqErr.Code == "23505"
+		case errors.As(err, &mssqlErr):
+			return mssqlErr.SQLErrorNumber() == 2627 || mssqlErr.SQLErrorNumber() == 2601
+		}
+		return strings.Contains(err.Error(), "UNIQUE constraint failed")
+	})
+}
+
+// Whether match holds for err or one of the gorm.Errors it is or wraps
+func matchError(err error, match func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+	var errs gorm.Errors
+	if errors.As(err, &errs) {
+		for _, err := range errs {
+			if matchError(err, match) {
+				return true
+			}
+		}
+		return false
+	}
+	return match(err)
+}
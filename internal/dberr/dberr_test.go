@@ -0,0 +1,38 @@
+package dberr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, IsRetryable(&pq.Error{Code: "40P01"}))
+	assert.True(t, IsRetryable(gorm.Errors{errors.New("other"), &pq.Error{Code: "40001"}}))
+	assert.True(t, IsRetryable(errors.New("database is locked")))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsRetryable(errors.New("syntax error")))
+	assert.True(t, IsRetryable(fmt.Errorf("transfer: %w", &mysql.MySQLError{Number: 1205})))
+	assert.True(t, IsRetryable(fmt.Errorf("transfer: %w", gorm.Errors{&pq.Error{Code: "40001"}})))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, IsUniqueViolation(&mysql.MySQLError{Number: 1062}))
+	assert.True(t, IsUniqueViolation(fmt.Errorf("creating: %w", &pq.Error{Code: "23505"})))
+	assert.True(t, IsUniqueViolation(gorm.Errors{errors.New("other"), errors.New("UNIQUE constraint failed: users.email")}))
+	assert.False(t, IsUniqueViolation(&pq.Error{Code: "40001"}))
+	assert.False(t, IsUniqueViolation(nil))
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, IsSerializationFailure(fmt.Errorf("transfer: %w", &pq.Error{Code: "40001"})))
+	assert.False(t, IsSerializationFailure(&pq.Error{Code: "40P01"}))
+	assert.False(t, IsSerializationFailure(&mysql.MySQLError{Number: 1213}))
+}
@@ -0,0 +1,229 @@
+package orm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// JSON is a JSON document column, jsonb on postgres, json on mysql and text elsewhere.
+// nil is stored as NULL.
+type JSON json.RawMessage
+
+// Value implements driver.Valuer
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+// Scan implements sql.Scanner
+func (j *JSON) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*j = nil
+	case string:
+		*j = JSON(src)
+	case []byte:
+		*j = append(JSON(nil), src...)
+	default:
+		return fmt.Errorf("orm: can not scan %T into JSON", src)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
+
+// GormDataType sets the column type of JSON fields
+func (JSON) GormDataType(dialect gorm.Dialect) string {
+	switch dialect.GetName() {
+	case "postgres":
+		return "jsonb"
+	case "mysql":
+		return "json"
+	case "mssql":
+		return "nvarchar(max)"
+	case "clickhouse":
+		return "String"
+	}
+	return "text"
+}
+
+// Unmarshal decodes the document into v
+func (j JSON) Unmarshal(v interface{}) error {
+	return json.Unmarshal(j, v)
+}
+
+// Comparison operators of WhereJSONPath
+var jsonOperators = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "LIKE": true}
+
+// WhereJSONPath returns db filtering on the value at path in the JSON document column, compared with op to value.
+// path is a dot-separated list of keys and array indexes, like "address.lines.0".
+// The value at path is compared as text on postgres and mysql.
+func (db *DB) WhereJSONPath(column, path string, op string, value interface{}) *DB {
+	op = strings.ToUpper(strings.TrimSpace(op))
+	if !jsonOperators[op] {
+		return db.withError(fmt.Errorf("orm: unsupported JSON comparison %q", op))
+	}
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return db.withError(fmt.Errorf("orm: invalid JSON path %q", path))
+		}
+	}
+	extracted := jsonExtract(db.Dialect().GetName(), db.NewScope(nil).Quote(column), keys)
+	return db.where(fmt.Sprintf("%s %s ?", extracted, op), value)
+}
+
+// WhereJSONContains returns db filtering on the JSON document column containing value, encoded to JSON:
+// the objects holding its keys with matching values, the arrays holding its elements or, for a scalar, equal to it.
+// sqlite only supports values whose keys and elements are scalars.
+func (db *DB) WhereJSONContains(column string, value interface{}) *DB {
+	document, err := json.Marshal(value)
+	if err != nil {
+		return db.withError(err)
+	}
+	quoted := db.NewScope(nil).Quote(column)
+	switch db.Dialect().GetName() {
+	case "postgres":
+		return db.where(quoted+" @> ?", string(document))
+	case "mysql":
+		return db.where("JSON_CONTAINS("+quoted+", ?)", string(document))
+	case "sqlite3":
+		return db.whereSQLiteContains(quoted, value, document)
+	}
+	return db.withError(fmt.Errorf("orm: JSON containment is not supported by %s", db.Dialect().GetName()))
+}
+
+// sqlite has no containment function: objects are matched key by key and arrays element by element
+func (db *DB) whereSQLiteContains(quoted string, value interface{}, document []byte) *DB {
+	var decoded interface{}
+	if err := json.Unmarshal(document, &decoded); err != nil {
+		return db.withError(err)
+	}
+	element := func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errors.New("orm: sqlite only matches JSON scalars")
+		case bool:
+			if v {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		return v, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	switch decoded := decoded.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(decoded))
+		for key := range decoded {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			arg, err := element(decoded[key])
+			if err != nil {
+				return db.withError(err)
+			}
+			conditions = append(conditions, jsonExtract("sqlite3", quoted, []string{key})+" = ?")
+			args = append(args, arg)
+		}
+	case []interface{}:
+		for _, v := range decoded {
+			arg, err := element(v)
+			if err != nil {
+				return db.withError(err)
+			}
+			conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each("+quoted+") WHERE json_each.value = ?)")
+			args = append(args, arg)
+		}
+	default:
+		arg, _ := element(decoded)
+		conditions = append(conditions, "(json_extract("+quoted+", '$') = ? OR EXISTS (SELECT 1 FROM json_each("+quoted+") WHERE json_each.value = ?))")
+		args = append(args, arg, arg)
+	}
+	if len(conditions) == 0 {
+		return db
+	}
+	return db.where(strings.Join(conditions, " AND "), args...)
+}
+
+// Expression extracting the value at keys of the JSON document column quoted, as text where the dialect allows
+func jsonExtract(dialect, quoted string, keys []string) string {
+	switch dialect {
+	case "postgres":
+		expression := quoted
+		for i, key := range keys {
+			operator := "->"
+			if i == len(keys)-1 {
+				operator = "->>"
+			}
+			if _, err := strconv.Atoi(key); err == nil {
+				expression += operator + key
+			} else {
+				expression += operator + quoteLiteral(key)
+			}
+		}
+		return expression
+	case "mysql":
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + quoted + ", " + quoteLiteral(jsonPath(keys)) + "))"
+	case "mssql":
+		return "JSON_VALUE(" + quoted + ", " + quoteLiteral(jsonPath(keys)) + ")"
+	}
+	return "json_extract(" + quoted + ", " + quoteLiteral(jsonPath(keys)) + ")"
+}
+
+// SQL/JSON path of keys, like $.address.lines[0]
+func jsonPath(keys []string) string {
+	path := "$"
+	for _, key := range keys {
+		if _, err := strconv.Atoi(key); err == nil {
+			path += "[" + key + "]"
+		} else {
+			path += "." + strconv.Quote(key)
+		}
+	}
+	return path
+}
+
+// SQL string literal of s
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// db with the condition added
+func (db *DB) where(query string, args ...interface{}) *DB {
+	derived := *db
+	derived.DB = db.DB.Where(query, args...)
+	return &derived
+}
+
+// db failing with err
+func (db *DB) withError(err error) *DB {
+	derived := *db
+	derived.DB = db.DB.New()
+	derived.DB.AddError(err)
+	return &derived
+}
@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonDB struct {
+	ID   int
+	Data JSON
+}
+
+func TestJSON(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&jsonDB{}).Error)
+
+	for id, data := range []string{
+		`{"name": "a", "age": 30, "tags": ["x", "y"], "address": {"city": "Paris"}}`,
+		`{"name": "b", "age": 40, "tags": ["y"], "address": {"city": "Lyon"}}`,
+		`[1, 2, 3]`,
+	} {
+		assert.NoError(t, db.Create(&jsonDB{ID: id + 1, Data: JSON(data)}).Error)
+	}
+	assert.NoError(t, db.Create(&jsonDB{ID: 4}).Error)
+
+	ids := func(db *DB) []int {
+		var ids []int
+		assert.NoError(t, db.Model(&jsonDB{}).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	assert.Equal(t, []int{1}, ids(db.WhereJSONPath("data", "name", "=", "a")))
+	assert.Equal(t, []int{2}, ids(db.WhereJSONPath("data", "age", ">", 35)))
+	assert.Equal(t, []int{2}, ids(db.WhereJSONPath("data", "address.city", "=", "Lyon")))
+	assert.Equal(t, []int{1}, ids(db.WhereJSONPath("data", "tags.0", "=", "x")))
+	assert.Error(t, db.WhereJSONPath("data", "name", "; DROP", "a").Find(&[]jsonDB{}).Error)
+
+	assert.Equal(t, []int{1, 2}, ids(db.WhereJSONPath("data", "tags.0", "<>", "")))
+	assert.Equal(t, []int{1}, ids(db.WhereJSONContains("data", map[string]interface{}{"name": "a", "age": 30})))
+	assert.Equal(t, []int{3}, ids(db.WhereJSONContains("data", []int{1, 3})))
+	assert.Equal(t, []int{3}, ids(db.WhereJSONContains("data", 2)))
+
+	var stored jsonDB
+	assert.NoError(t, db.First(&stored, 1).Error)
+	var decoded struct{ Name string }
+	assert.NoError(t, stored.Data.Unmarshal(&decoded))
+	assert.Equal(t, "a", decoded.Name)
+	var null jsonDB
+	assert.NoError(t, db.First(&null, 4).Error)
+	assert.Nil(t, null.Data)
+
+	encoded, err := json.Marshal(struct{ Data JSON }{JSON(`{"a":1}`)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Data": {"a": 1}}`, string(encoded))
+	encoded, err = json.Marshal(struct{ Data JSON }{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Data": null}`, string(encoded))
+}
+
+func Test_jsonExtract(t *testing.T) {
+	keys := []string{"address", "lines", "0"}
+	assert.Equal(t, `"data"->'address'->'lines'->>0`, jsonExtract("postgres", `"data"`, keys))
+	assert.Equal(t, "JSON_UNQUOTE(JSON_EXTRACT(`data`, '$.\"address\".\"lines\"[0]'))", jsonExtract("mysql", "`data`", keys))
+	assert.Equal(t, `"data"->>'it''s'`, jsonExtract("postgres", `"data"`, []string{"it's"}))
+}
@@ -0,0 +1,244 @@
+// Package loader batches the lookups of records by key made while serving a request, so that resolving
+// the records of a list one at a time runs a single IN query instead of one query per record.
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cochainio/orm"
+)
+
+// Loader loads records of the model T by the values of a key column, see For
+type Loader[K comparable, T any] struct {
+	column   string
+	wait     time.Duration
+	maxBatch int
+	db       *orm.DB
+
+	mu    sync.Mutex
+	batch *batch[K, T]
+	cache map[K]*T
+}
+
+// Keys waiting to be loaded together
+type batch[K comparable, T any] struct {
+	ctx     context.Context
+	keys    []K
+	pending map[K]bool
+	done    chan struct{}
+	records map[K]*T
+	err     error
+}
+
+type config struct {
+	wait     time.Duration
+	maxBatch int
+	db       *orm.DB
+}
+
+type Opt func(*config)
+
+// WaitOpt sets how long the first Load of a batch waits for others to join it, 1ms by default
+func WaitOpt(d time.Duration) Opt {
+	return func(c *config) {
+		c.wait = d
+	}
+}
+
+// MaxBatchOpt sets the number of keys loaded by a single query, 500 by default.
+// A full batch is loaded without waiting.
+func MaxBatchOpt(size int) Opt {
+	return func(c *config) {
+		c.maxBatch = size
+	}
+}
+
+// DBOpt loads the records with db rather than orm.FromContext
+func DBOpt(db *orm.DB) Opt {
+	return func(c *config) {
+		c.db = db
+	}
+}
+
+// New returns a loader of records of T by column, caching what it loads for its whole life,
+// which should thus be a single request. See For to share loaders through a context.
+func New[K comparable, T any](column string, opts ...Opt) *Loader[K, T] {
+	cfg := config{wait: time.Millisecond, maxBatch: 500}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.maxBatch < 1 {
+		cfg.maxBatch = 1
+	}
+	return &Loader[K, T]{column: column, wait: cfg.wait, maxBatch: cfg.maxBatch, db: cfg.db, cache: map[K]*T{}}
+}
+
+type loadersKey struct{}
+
+// Loaders of a request, by model, key column and key type
+type loaders struct {
+	sync.Mutex
+	byKey map[loaderKey]interface{}
+}
+
+type loaderKey struct {
+	model, key reflect.Type
+	column     string
+}
+
+// WithLoaders returns ctx carrying a set of loaders for For to share, typically made per request by a middleware
+func WithLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{byKey: map[loaderKey]interface{}{}})
+}
+
+// For returns the loader of records of T by column of the loaders ctx carries, made with opts when it is the first,
+// so that the lookups of a request share batches and cache. Without loaders in ctx, it returns a new loader.
+//
+//	ctx = loader.WithLoaders(ctx)
+//	author, err := loader.For[string, User](ctx, "id").Load(ctx, post.AuthorID)
+func For[K comparable, T any](ctx context.Context, column string, opts ...Opt) *Loader[K, T] {
+	set, ok := ctx.Value(loadersKey{}).(*loaders)
+	if !ok {
+		return New[K, T](column, opts...)
+	}
+	key := loaderKey{model: reflect.TypeOf((*T)(nil)).Elem(), key: reflect.TypeOf((*K)(nil)).Elem(), column: column}
+	set.Lock()
+	defer set.Unlock()
+	if l, ok := set.byKey[key]; ok {
+		return l.(*Loader[K, T])
+	}
+	l := New[K, T](column, opts...)
+	set.byKey[key] = l
+	return l
+}
+
+// Load returns the record whose column is key, loaded along with the keys requested meanwhile,
+// or gorm.ErrRecordNotFound when there is none. A batch is loaded with the DB of DBOpt or orm.FromContext,
+// bound to the context of its first Load, so within the transaction it carries.
+func (l *Loader[K, T]) Load(ctx context.Context, key K) (*T, error) {
+	l.mu.Lock()
+	if record, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		if record == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return record, nil
+	}
+	b := l.batch
+	if b == nil {
+		b = &batch[K, T]{ctx: ctx, pending: map[K]bool{}, done: make(chan struct{})}
+		l.batch = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	if !b.pending[key] {
+		b.pending[key] = true
+		b.keys = append(b.keys, key)
+	}
+	if len(b.keys) >= l.maxBatch {
+		go l.dispatch(b)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	if record := b.records[key]; record != nil {
+		return record, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// LoadMany loads the records of keys in a single batch, nil for the keys without record
+func (l *Loader[K, T]) LoadMany(ctx context.Context, keys []K) ([]*T, error) {
+	records := make([]*T, len(keys))
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func(i int, key K) {
+			defer wg.Done()
+			records[i], errs[i] = l.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil && !orm.IsRecordNotFound(err) {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Clear drops key from the cache, to load it again after it changed
+func (l *Loader[K, T]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// Run the query of b, once, and cache its records unless it failed
+func (l *Loader[K, T]) dispatch(b *batch[K, T]) {
+	l.mu.Lock()
+	if l.batch != b {
+		l.mu.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mu.Unlock()
+
+	b.records, b.err = l.query(b.ctx, b.keys)
+	if b.err == nil {
+		l.mu.Lock()
+		for _, key := range b.keys {
+			l.cache[key] = b.records[key]
+		}
+		l.mu.Unlock()
+	}
+	close(b.done)
+}
+
+func (l *Loader[K, T]) query(ctx context.Context, keys []K) (map[K]*T, error) {
+	db := l.db
+	if db == nil {
+		if db = orm.FromContext(ctx); db == nil {
+			return nil, errors.New("orm/loader: no database to load from, see DBOpt")
+		}
+	} else {
+		db = db.WithContext(ctx)
+	}
+
+	var found []*T
+	scope := db.NewScope(new(T))
+	field, ok := scope.FieldByName(l.column)
+	if !ok {
+		return nil, fmt.Errorf("orm/loader: unknown column %s of %s", l.column, scope.TableName())
+	}
+	if err := db.Where(scope.Quote(field.DBName)+" IN (?)", keys).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byValue := make(map[string]*T, len(found))
+	for _, record := range found {
+		value, _ := db.NewScope(record).FieldByName(field.Name)
+		if _, ok := byValue[fmt.Sprint(value.Field.Interface())]; !ok {
+			byValue[fmt.Sprint(value.Field.Interface())] = record
+		}
+	}
+	records := make(map[K]*T, len(keys))
+	for _, key := range keys {
+		records[key] = byValue[fmt.Sprint(key)]
+	}
+	return records, nil
+}
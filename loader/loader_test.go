@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm"
+)
+
+type user struct {
+	ID    int
+	Email string
+}
+
+func TestLoader_Load(t *testing.T) {
+	db, err := orm.New("sqlite3://" + filepath.Join(t.TempDir(), "loader.db"))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&user{}).Error)
+	for id := 1; id <= 3; id++ {
+		assert.NoError(t, db.Create(&user{ID: id, Email: string(rune('a'+id-1)) + "@example.com"}).Error)
+	}
+
+	var queries int
+	db.Callback().Query().Before("gorm:query").Register("test:count", func(*gorm.Scope) { queries++ })
+	defer db.Callback().Query().Remove("test:count")
+
+	ctx := WithLoaders(context.Background())
+	byID := For[int, user](ctx, "id", DBOpt(db), WaitOpt(20*time.Millisecond))
+	assert.Same(t, byID, For[int, user](ctx, "id"))
+
+	var wg sync.WaitGroup
+	emails := make([]string, 4)
+	for i, id := range []int{3, 1, 3, 2} {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			record, err := byID.Load(ctx, id)
+			assert.NoError(t, err)
+			emails[i] = record.Email
+		}(i, id)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"c@example.com", "a@example.com", "c@example.com", "b@example.com"}, emails)
+	assert.Equal(t, 1, queries)
+
+	record, err := byID.Load(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "a@example.com", record.Email)
+	assert.Equal(t, 1, queries)
+
+	records, err := byID.LoadMany(ctx, []int{2, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, "b@example.com", records[0].Email)
+	assert.Nil(t, records[1])
+	assert.Equal(t, 2, queries)
+	_, err = byID.Load(ctx, 4)
+	assert.True(t, orm.IsRecordNotFound(err))
+	assert.Equal(t, 2, queries)
+
+	byEmail := For[string, user](ctx, "email", DBOpt(db), MaxBatchOpt(1), WaitOpt(time.Hour))
+	record, err = byEmail.Load(ctx, "c@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, record.ID)
+
+	_, err = For[int, user](ctx, "missing", DBOpt(db), WaitOpt(0)).Load(ctx, 1)
+	assert.Error(t, err)
+}
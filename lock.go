@@ -0,0 +1,91 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+)
+
+const rowLockKey = "orm:row_lock"
+
+// Row lock of the queries of a handle, see ForUpdate
+type rowLock struct {
+	mode     string // UPDATE or SHARE
+	modifier string // Empty, NOWAIT or SKIP LOCKED
+}
+
+// ForUpdate returns db locking the rows its queries select until the end of the transaction, against updates
+// and other locks, with FOR UPDATE on postgres and mysql. SQLite locks the whole database instead and takes no clause,
+// other dialects fail.
+func (db *DB) ForUpdate() *DB {
+	return db.withRowLock(rowLock{mode: "UPDATE"})
+}
+
+// ForShare returns db locking the rows its queries select against updates, FOR SHARE on postgres and
+// LOCK IN SHARE MODE on mysql, see ForUpdate
+func (db *DB) ForShare() *DB {
+	return db.withRowLock(rowLock{mode: "SHARE"})
+}
+
+// NoWait makes the lock of ForUpdate or ForShare fail at once on rows locked by another transaction rather than wait.
+// It requires mysql 8.
+func (db *DB) NoWait() *DB {
+	return db.withLockModifier("NOWAIT")
+}
+
+// SkipLocked makes the lock of ForUpdate or ForShare leave out the rows locked by another transaction,
+// for queues whose workers pick distinct rows. It requires mysql 8.
+func (db *DB) SkipLocked() *DB {
+	return db.withLockModifier("SKIP LOCKED")
+}
+
+// ForUpdate returns the DB of tx locking the rows its queries select, see DB.ForUpdate
+func (tx *TX) ForUpdate() *DB {
+	return tx.asDB().ForUpdate()
+}
+
+// ForShare returns the DB of tx share locking the rows its queries select, see DB.ForShare
+func (tx *TX) ForShare() *DB {
+	return tx.asDB().ForShare()
+}
+
+func (db *DB) withLockModifier(modifier string) *DB {
+	value, ok := db.DB.Get(rowLockKey)
+	if !ok {
+		return db.withError(fmt.Errorf("orm: %s needs ForUpdate or ForShare", modifier))
+	}
+	lock := value.(rowLock)
+	lock.modifier = modifier
+	return db.withRowLock(lock)
+}
+
+func (db *DB) withRowLock(lock rowLock) *DB {
+	clause, err := rowLockClause(db.Dialect().GetName(), lock)
+	if err != nil {
+		return db.withError(err)
+	}
+	derived := *db
+	derived.DB = db.DB.Set(rowLockKey, lock)
+	if clause != "" {
+		derived.DB = derived.DB.Set("gorm:query_option", clause)
+	}
+	return &derived
+}
+
+// Clause appended to the queries of dialect to take lock
+func rowLockClause(dialect string, lock rowLock) (string, error) {
+	switch dialect {
+	case "postgres":
+	case "mysql":
+		if lock.mode == "SHARE" && lock.modifier == "" {
+			return "LOCK IN SHARE MODE", nil
+		}
+	case "sqlite3":
+		return "", nil
+	default:
+		return "", errors.New("orm: row locks are not supported by " + dialect)
+	}
+	if lock.modifier != "" {
+		return "FOR " + lock.mode + " " + lock.modifier, nil
+	}
+	return "FOR " + lock.mode, nil
+}
@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rowLockClause(t *testing.T) {
+	for _, c := range []struct {
+		dialect string
+		lock    rowLock
+		clause  string
+	}{
+		{"postgres", rowLock{mode: "UPDATE"}, "FOR UPDATE"},
+		{"postgres", rowLock{mode: "SHARE", modifier: "NOWAIT"}, "FOR SHARE NOWAIT"},
+		{"postgres", rowLock{mode: "UPDATE", modifier: "SKIP LOCKED"}, "FOR UPDATE SKIP LOCKED"},
+		{"mysql", rowLock{mode: "SHARE"}, "LOCK IN SHARE MODE"},
+		{"mysql", rowLock{mode: "SHARE", modifier: "SKIP LOCKED"}, "FOR SHARE SKIP LOCKED"},
+		{"mysql", rowLock{mode: "UPDATE", modifier: "NOWAIT"}, "FOR UPDATE NOWAIT"},
+		{"sqlite3", rowLock{mode: "UPDATE", modifier: "NOWAIT"}, ""},
+	} {
+		clause, err := rowLockClause(c.dialect, c.lock)
+		assert.NoError(t, err)
+		assert.Equal(t, c.clause, clause, c.dialect)
+	}
+	_, err := rowLockClause("mssql", rowLock{mode: "UPDATE"})
+	assert.Error(t, err)
+}
+
+func TestDB_ForUpdate(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.Create(&transactionDB{ID: 1, Name: "a"}).Error)
+
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		var locked transactionDB
+		assert.NoError(t, tx.ForUpdate().SkipLocked().First(&locked, 1).Error)
+		assert.Equal(t, "a", locked.Name)
+		assert.NoError(t, tx.ForShare().NoWait().First(&locked, 1).Error)
+		return nil
+	}))
+	assert.Error(t, db.NoWait().First(&transactionDB{}).Error)
+}
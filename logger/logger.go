@@ -0,0 +1,15 @@
+// Package logger adapts structured loggers to orm.Logger.
+// Statements are logged at debug level, failed ones at error level.
+package logger
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// Message of every logged statement
+const Message = "sql"
+
+// Whether err is a failure rather than an empty result
+func failed(err error) bool {
+	return err != nil && !gorm.IsRecordNotFoundError(err)
+}
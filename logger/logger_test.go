@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var args = []interface{}{"alice"}
+
+func TestZapLogger_Query(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := NewZap(zap.New(core))
+
+	l.Query("SELECT * FROM users WHERE name = ?", args, time.Millisecond, 1, nil)
+	l.Query("SELECT * FROM users WHERE name = ?", args, time.Millisecond, 0, gorm.ErrRecordNotFound)
+	l.Query("INSERT INTO users (name) VALUES (?)", args, time.Millisecond, 0, errors.New("duplicate"))
+
+	entries := logs.All()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, zapcore.DebugLevel, entries[1].Level)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	fields := entries[2].ContextMap()
+	assert.Equal(t, "INSERT INTO users (name) VALUES (?)", fields["sql"])
+	assert.Equal(t, int64(0), fields["rows"])
+	assert.Equal(t, time.Millisecond, fields["duration"])
+	assert.Equal(t, "duplicate", fields["error"])
+}
+
+func TestLogrusLogger_Query(t *testing.T) {
+	base, hook := test.NewNullLogger()
+	base.SetLevel(logrus.DebugLevel)
+	l := NewLogrus(base)
+
+	l.Query("SELECT * FROM users WHERE name = ?", args, time.Millisecond, 1, nil)
+	assert.Equal(t, logrus.DebugLevel, hook.LastEntry().Level)
+	assert.Equal(t, int64(1), hook.LastEntry().Data["rows"])
+
+	l.Query("INSERT INTO users (name) VALUES (?)", args, time.Millisecond, 0, errors.New("duplicate"))
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, Message, hook.LastEntry().Message)
+	assert.Equal(t, "INSERT INTO users (name) VALUES (?)", hook.LastEntry().Data["sql"])
+	assert.EqualError(t, hook.LastEntry().Data[logrus.ErrorKey].(error), "duplicate")
+}
+
+func TestSlogLogger_Query(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	l.Query("INSERT INTO users (name) VALUES (?)", args, time.Millisecond, 0, errors.New("duplicate"))
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, Message, record["msg"])
+	assert.Equal(t, "INSERT INTO users (name) VALUES (?)", record["sql"])
+	assert.Equal(t, []interface{}{"alice"}, record["args"])
+	assert.Equal(t, "duplicate", record["error"])
+}
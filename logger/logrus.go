@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type LogrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrus logs statements with the fields sql, args, duration, rows and error
+func NewLogrus(logger logrus.FieldLogger) *LogrusLogger {
+	return &LogrusLogger{logger: logger}
+}
+
+func (l *LogrusLogger) Query(sql string, args []interface{}, duration time.Duration, rows int64, err error) {
+	entry := l.logger.WithFields(logrus.Fields{
+		"sql":      sql,
+		"args":     args,
+		"duration": duration,
+		"rows":     rows,
+	})
+	if failed(err) {
+		entry.WithError(err).Error(Message)
+		return
+	}
+	entry.Debug(Message)
+}
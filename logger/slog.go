@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlog logs statements with the attributes sql, args, duration, rows and error
+func NewSlog(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: logger}
+}
+
+func (l *SlogLogger) Query(sql string, args []interface{}, duration time.Duration, rows int64, err error) {
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Any("args", args),
+		slog.Duration("duration", duration),
+		slog.Int64("rows", rows),
+	}
+	if failed(err) {
+		l.logger.LogAttrs(context.Background(), slog.LevelError, Message, append(attrs, slog.Any("error", err))...)
+		return
+	}
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, Message, attrs...)
+}
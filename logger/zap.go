@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type ZapLogger struct {
+	logger *zap.Logger
+}
+
+// NewZap logs statements with the fields sql, args, duration, rows and error
+func NewZap(logger *zap.Logger) *ZapLogger {
+	return &ZapLogger{logger: logger}
+}
+
+func (l *ZapLogger) Query(sql string, args []interface{}, duration time.Duration, rows int64, err error) {
+	fields := []zap.Field{
+		zap.String("sql", sql),
+		zap.Any("args", args),
+		zap.Duration("duration", duration),
+		zap.Int64("rows", rows),
+	}
+	if failed(err) {
+		l.logger.Error(Message, append(fields, zap.Error(err))...)
+		return
+	}
+	l.logger.Debug(Message, fields...)
+}
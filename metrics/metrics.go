@@ -0,0 +1,137 @@
+// Package metrics exports Prometheus metrics about the statements and connection pools of an orm.DB.
+package metrics
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cochainio/orm"
+)
+
+const namespace = "orm"
+
+// Collector counts and times statements by operation and transactions by outcome,
+// and reports the pool stats on every scrape
+type Collector struct {
+	db       *orm.DB
+	queries  *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	transactions *prometheus.CounterVec
+	txDuration   *prometheus.HistogramVec
+
+	openConnections  *prometheus.Desc
+	inUseConnections *prometheus.Desc
+	idleConnections  *prometheus.Desc
+	maxOpen          *prometheus.Desc
+	waitCount        *prometheus.Desc
+	waitDuration     *prometheus.Desc
+}
+
+// Register creates the collector of db, registers it with registerer and starts observing the statements of db
+func Register(db *orm.DB, registerer prometheus.Registerer) (*Collector, error) {
+	c := New(db)
+	if err := registerer.Register(c); err != nil {
+		return nil, err
+	}
+	db.AddQueryObserver(c)
+	return c, nil
+}
+
+// New creates the collector of db without registering it anywhere
+func New(db *orm.DB) *Collector {
+	poolDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pool", name), help, []string{"pool"}, nil)
+	}
+
+	return &Collector{
+		db: db,
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queries_total",
+			Help:      "Statements run, bulk operations counting once.",
+		}, []string{"operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "query_errors_total",
+			Help:      "Statements that failed, record not found excepted.",
+		}, []string{"operation"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "query_duration_seconds",
+			Help:      "Duration of statements.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transactions_total",
+			Help:      "Transactions ended, by outcome.",
+		}, []string{"outcome"}),
+		txDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "transaction_duration_seconds",
+			Help:      "Time transactions stayed open, by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+
+		openConnections:  poolDesc("open_connections", "Established connections, in use or idle."),
+		inUseConnections: poolDesc("in_use_connections", "Connections currently in use."),
+		idleConnections:  poolDesc("idle_connections", "Idle connections."),
+		maxOpen:          poolDesc("max_open_connections", "Maximum number of open connections, zero when unlimited."),
+		waitCount:        poolDesc("wait_count_total", "Connections waited for."),
+		waitDuration:     poolDesc("wait_duration_seconds_total", "Time spent waiting for a connection."),
+	}
+}
+
+// ObserveQuery implements orm.QueryObserver
+func (c *Collector) ObserveQuery(e orm.QueryEvent) {
+	c.queries.WithLabelValues(e.Operation).Inc()
+	c.duration.WithLabelValues(e.Operation).Observe(e.Duration.Seconds())
+	if e.Err != nil && !gorm.IsRecordNotFoundError(e.Err) {
+		c.errors.WithLabelValues(e.Operation).Inc()
+	}
+}
+
+// ObserveTx implements orm.TxObserver
+func (c *Collector) ObserveTx(stats orm.TxStats) {
+	outcome := "rolled_back"
+	if stats.Committed {
+		outcome = "committed"
+	}
+	c.transactions.WithLabelValues(outcome).Inc()
+	c.txDuration.WithLabelValues(outcome).Observe(stats.Duration.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.queries.Describe(ch)
+	c.errors.Describe(ch)
+	c.duration.Describe(ch)
+	c.transactions.Describe(ch)
+	c.txDuration.Describe(ch)
+	ch <- c.openConnections
+	ch <- c.inUseConnections
+	ch <- c.idleConnections
+	ch <- c.maxOpen
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.queries.Collect(ch)
+	c.errors.Collect(ch)
+	c.duration.Collect(ch)
+	c.transactions.Collect(ch)
+	c.txDuration.Collect(ch)
+
+	for pool, stats := range c.db.PoolStats() {
+		ch <- prometheus.MustNewConstMetric(c.openConnections, prometheus.GaugeValue, float64(stats.OpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(c.inUseConnections, prometheus.GaugeValue, float64(stats.InUse), pool)
+		ch <- prometheus.MustNewConstMetric(c.idleConnections, prometheus.GaugeValue, float64(stats.Idle), pool)
+		ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections), pool)
+		ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount), pool)
+		ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds(), pool)
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm"
+)
+
+type metricDB struct {
+	ID   int
+	Name string
+}
+
+func TestRegister(t *testing.T) {
+	gormDB, err := gorm.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer gormDB.Close()
+	assert.NoError(t, gormDB.AutoMigrate(&metricDB{}).Error)
+	db := &orm.DB{DB: gormDB}
+
+	registry := prometheus.NewRegistry()
+	c, err := Register(db, registry)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Create(&metricDB{Name: "a"}).Error)
+	assert.NoError(t, db.Find(&[]metricDB{}).Error)
+	assert.True(t, orm.IsRecordNotFound(db.First(&metricDB{}, 2).Error))
+	assert.Error(t, db.Table("missing").Find(&[]metricDB{}).Error)
+	assert.NoError(t, db.BulkCreate([]metricDB{{Name: "b"}, {Name: "c"}}))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.queries.WithLabelValues("insert")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(c.queries.WithLabelValues("select")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.errors.WithLabelValues("select")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.queries.WithLabelValues("bulk")))
+
+	tx := db.Begin()
+	assert.NoError(t, tx.Create(&metricDB{Name: "d"}).Error)
+	assert.NoError(t, tx.CommitE())
+	db.Begin().End()
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.transactions.WithLabelValues("committed")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(c.transactions.WithLabelValues("rolled_back")))
+
+	expected := `
+# HELP orm_pool_max_open_connections Maximum number of open connections, zero when unlimited.
+# TYPE orm_pool_max_open_connections gauge
+orm_pool_max_open_connections{pool="primary"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "orm_pool_max_open_connections"))
+}
@@ -0,0 +1,15 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/cochainio/orm"
+)
+
+// ErrLocked is returned when the lock of the migrations is not acquired before ctx is done
+var ErrLocked = orm.ErrMigrationLocked
+
+// Run fn holding the migration lock of the database, see orm.WithMigrationLock
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	return orm.WithMigrationLock(ctx, m.db, fn)
+}
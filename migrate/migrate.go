@@ -0,0 +1,265 @@
+// Package migrate runs versioned schema migrations registered in Go, recording the applied versions
+// in a schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Migration changes the schema from the previous version to Version, and back with Down.
+// Up and Down run in a transaction, falling back to UpSQL and DownSQL when nil. The SQL may hold several
+// statements when the driver accepts them, like multiStatements=true for mysql.
+// MySQL commits DDL statements as they run, a failed migration may have to be cleaned up by hand.
+type Migration struct {
+	Version int64
+	Name    string
+
+	Up      func(ctx context.Context, tx *gorm.DB) error
+	Down    func(ctx context.Context, tx *gorm.DB) error
+	UpSQL   string
+	DownSQL string
+
+	// Run Up and Down outside of a transaction, as CREATE INDEX CONCURRENTLY needs on postgres, see orm.Index.
+	// The version is recorded once they succeed, a failure may leave partial changes.
+	NoTransaction bool
+}
+
+var registered struct {
+	sync.Mutex
+	migrations []Migration
+}
+
+// Register adds migrations to the ones a Migrator runs by default
+func Register(migrations ...Migration) {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.migrations = append(registered.migrations, migrations...)
+}
+
+// Record of an applied migration
+type record struct {
+	Version   int64     `gorm:"column:version;primary_key;auto_increment:false"`
+	Name      string    `gorm:"column:name;size:255"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+// Status of a migration, known by the code or only by the database
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil while pending
+	Unknown   bool       // Applied but not registered, like a migration of a newer release
+}
+
+// Migrator applies and reverts migrations, one instance at a time
+type Migrator struct {
+	db         *gorm.DB
+	table      string
+	migrations []Migration
+}
+
+type Opt func(*Migrator)
+
+// TableOpt sets the table recording the applied versions, schema_migrations by default
+func TableOpt(table string) Opt {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// MigrationsOpt runs migrations instead of the registered ones
+func MigrationsOpt(migrations ...Migration) Opt {
+	return func(m *Migrator) {
+		m.migrations = migrations
+	}
+}
+
+func New(db *gorm.DB, opts ...Opt) *Migrator {
+	registered.Lock()
+	m := &Migrator{
+		db:         db,
+		table:      "schema_migrations",
+		migrations: append([]Migration(nil), registered.migrations...),
+	}
+	registered.Unlock()
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Migrations sorted by version, checked for duplicates and missing functions
+func (m *Migrator) sorted() ([]Migration, error) {
+	migrations := append([]Migration(nil), m.migrations...)
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, migration := range migrations {
+		if migration.Version <= 0 {
+			return nil, fmt.Errorf("migrate: version of %q must be positive", migration.Name)
+		}
+		if i > 0 && migrations[i-1].Version == migration.Version {
+			return nil, fmt.Errorf("migrate: version %d is registered twice", migration.Version)
+		}
+		if migration.Up == nil && migration.UpSQL == "" {
+			return nil, fmt.Errorf("migrate: migration %d has no Up", migration.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// Create the table of versions and read the applied ones
+func (m *Migrator) applied() (map[int64]record, error) {
+	if err := m.db.Table(m.table).AutoMigrate(&record{}).Error; err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := m.db.Table(m.table).Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// Migrate applies the pending migrations in order of version, each in its own transaction.
+// A pending migration older than the last applied one is an error: versions are applied in sequence.
+// Other instances migrating the same database are waited for, see orm.WithMigrationLock.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	return m.withLock(ctx, func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		var last int64
+		for version := range applied {
+			if version > last {
+				last = version
+			}
+		}
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if migration.Version < last {
+				return fmt.Errorf("migrate: migration %d is older than the applied version %d", migration.Version, last)
+			}
+			err := m.run(ctx, migration.Up, migration.UpSQL, migration.NoTransaction, func(tx *gorm.DB) error {
+				return tx.Table(m.table).Create(&record{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: migration %d %s: %v", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback reverts the last n applied migrations, most recent first
+func (m *Migrator) Rollback(ctx context.Context, n int) error {
+	migrations, err := m.sorted()
+	if err != nil {
+		return err
+	}
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+	return m.withLock(ctx, func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if n < len(versions) {
+			versions = versions[:n]
+		}
+
+		for _, version := range versions {
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("migrate: applied migration %d is not registered", version)
+			}
+			if migration.Down == nil && migration.DownSQL == "" {
+				return fmt.Errorf("migrate: migration %d %s can not be reverted", version, migration.Name)
+			}
+			err := m.run(ctx, migration.Down, migration.DownSQL, migration.NoTransaction, func(tx *gorm.DB) error {
+				return tx.Table(m.table).Where("version = ?", version).Delete(&record{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate: reverting %d %s: %v", version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Run fn, or sql when nil, then record, in a transaction unless noTx
+func (m *Migrator) run(ctx context.Context, fn func(context.Context, *gorm.DB) error, sql string, noTx bool, record func(tx *gorm.DB) error) error {
+	if fn == nil {
+		fn = func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec(sql).Error
+		}
+	}
+	if noTx {
+		if err := fn(ctx, m.db); err != nil {
+			return err
+		}
+		return record(m.db)
+	}
+
+	tx := m.db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := record(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// Status lists the migrations by version, the applied ones with their time, including those not registered
+func (m *Migrator) Status() ([]Status, error) {
+	migrations, err := m.sorted()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(migrations))
+	for _, migration := range migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if r, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &r.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, r := range applied {
+		r := r
+		statuses = append(statuses, Status{Version: r.Version, Name: r.Name, AppliedAt: &r.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
@@ -0,0 +1,124 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db"))
+	assert.NoError(t, err)
+	return db
+}
+
+var migrations = []Migration{
+	{
+		Version: 2,
+		Name:    "add users.email",
+		UpSQL:   "ALTER TABLE users ADD COLUMN email text",
+		DownSQL: "ALTER TABLE users DROP COLUMN email",
+	},
+	{
+		Version: 1,
+		Name:    "create users",
+		Up: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("CREATE TABLE users (id integer primary key)").Error
+		},
+		Down: func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("DROP TABLE users").Error
+		},
+	},
+}
+
+func TestMigrator(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	ctx := context.Background()
+	m := New(db, MigrationsOpt(migrations...))
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, int64(1), statuses[0].Version)
+	assert.Nil(t, statuses[0].AppliedAt)
+
+	assert.NoError(t, m.Migrate(ctx))
+	assert.True(t, db.Dialect().HasColumn("users", "email"))
+	statuses, err = m.Status()
+	assert.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.NotNil(t, statuses[1].AppliedAt)
+	assert.NoError(t, m.Migrate(ctx))
+
+	assert.NoError(t, m.Rollback(ctx, 1))
+	assert.False(t, db.Dialect().HasColumn("users", "email"))
+	assert.True(t, db.Dialect().HasTable("users"))
+	assert.NoError(t, m.Rollback(ctx, 5))
+	assert.False(t, db.Dialect().HasTable("users"))
+
+	statuses, err = m.Status()
+	assert.NoError(t, err)
+	assert.Nil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+}
+
+func TestMigrator_Migrate_failure(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	failing := Migration{Version: 3, Name: "fail", Up: func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Exec("CREATE TABLE partial (id integer)").Error; err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}}
+	m := New(db, MigrationsOpt(append(migrations, failing)...))
+	assert.EqualError(t, m.Migrate(ctx), "migrate: migration 3 fail: boom")
+	assert.False(t, db.Dialect().HasTable("partial"))
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.NotNil(t, statuses[1].AppliedAt)
+	assert.Nil(t, statuses[2].AppliedAt)
+
+	// Out of sequence or unknown versions
+	older := New(db, MigrationsOpt(append(migrations, Migration{Version: 1, Name: "again", UpSQL: "SELECT 1"})...))
+	assert.EqualError(t, older.Migrate(ctx), "migrate: version 1 is registered twice")
+	skipped := New(db, MigrationsOpt(migrations[1]))
+	statuses, err = skipped.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[1].Unknown)
+	assert.EqualError(t, skipped.Rollback(ctx, 1), "migrate: applied migration 2 is not registered")
+}
+
+func TestMigrator_Migrate_noTransaction(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+
+	index := Migration{
+		Version:       3,
+		Name:          "index users.email",
+		UpSQL:         "CREATE INDEX idx_users_email ON users (email)",
+		DownSQL:       "DROP INDEX idx_users_email",
+		NoTransaction: true,
+	}
+	m := New(db, MigrationsOpt(append(migrations, index)...))
+	assert.NoError(t, m.Migrate(context.Background()))
+	assert.True(t, db.Dialect().HasIndex("users", "idx_users_email"))
+	assert.NoError(t, m.Rollback(context.Background(), 1))
+	assert.False(t, db.Dialect().HasIndex("users", "idx_users_email"))
+}
+
+func TestRegister(t *testing.T) {
+	defer func() { registered.migrations = nil }()
+	Register(migrations...)
+	assert.Len(t, New(nil).migrations, 2)
+	assert.Len(t, New(nil, MigrationsOpt(migrations[0])).migrations, 1)
+}
@@ -0,0 +1,119 @@
+package orm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrMigrationLocked is returned when the migration lock is not acquired before ctx is done
+var ErrMigrationLocked = errors.New("orm: migrations are locked by another instance")
+
+const migrationLockName = "orm_migrations"
+
+// Lock of the schema_lock table, for dialects without advisory locks
+type migrationLock struct {
+	Name     string    `gorm:"column:name;primary_key;size:64"`
+	Owner    string    `gorm:"column:owner;size:32"`
+	LockedAt time.Time `gorm:"column:locked_at"`
+}
+
+// Polling interval and expiry of the locks of the schema_lock table,
+// held locks being refreshed every third of the expiry
+var (
+	migrationLockPoll   = 500 * time.Millisecond
+	migrationLockExpiry = 5 * time.Minute
+)
+
+// WithMigrationLock runs fn holding the migration lock of the database of db, waiting for it until ctx is done,
+// so that a single instance changes the schema at a time. The migrate package takes it, other tooling can as well.
+// The lock is a session advisory lock on postgres and GET_LOCK on mysql, held by a dedicated connection,
+// and a row of the schema_lock table elsewhere, refreshed while fn runs and taken over once it expires
+// when its holder died.
+func WithMigrationLock(ctx context.Context, db *gorm.DB, fn func() error) error {
+	var lock, unlock string
+	var key interface{}
+	switch db.Dialect().GetName() {
+	case "postgres":
+		hash := fnv.New64a()
+		hash.Write([]byte(migrationLockName))
+		lock, unlock, key = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", int64(hash.Sum64())
+	case "mysql":
+		lock, unlock, key = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)", migrationLockName
+	default:
+		return withTableLock(ctx, db, fn)
+	}
+
+	pool := primaryPool(db.CommonDB())
+	if pool == nil {
+		return errors.New("migration lock requires a *sql.DB connection")
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, lock, key); err != nil {
+		if ctx.Err() != nil {
+			return ErrMigrationLocked
+		}
+		return err
+	}
+	defer conn.ExecContext(context.Background(), unlock, key)
+	return fn()
+}
+
+// Run fn holding the lock row of the schema_lock table
+func withTableLock(ctx context.Context, db *gorm.DB, fn func() error) error {
+	table := db.New().Table("schema_lock")
+	if err := table.AutoMigrate(&migrationLock{}).Error; err != nil {
+		return err
+	}
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return err
+	}
+	held := migrationLock{Name: migrationLockName, Owner: hex.EncodeToString(owner)}
+
+	for {
+		held.LockedAt = time.Now()
+		if table.Create(&held).Error == nil {
+			break
+		}
+		expired := table.Where("name = ? AND locked_at < ?", held.Name, time.Now().Add(-migrationLockExpiry)).Delete(&migrationLock{})
+		if expired.Error != nil {
+			return expired.Error
+		}
+		if expired.RowsAffected > 0 {
+			continue
+		}
+		select {
+		case <-time.After(migrationLockPoll):
+		case <-ctx.Done():
+			return ErrMigrationLocked
+		}
+	}
+	defer table.Where("name = ? AND owner = ?", held.Name, held.Owner).Delete(&migrationLock{})
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(migrationLockExpiry / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				table.Model(&migrationLock{}).Where("name = ? AND owner = ?", held.Name, held.Owner).
+					UpdateColumn("locked_at", time.Now())
+			case <-done:
+				return
+			}
+		}
+	}()
+	return fn()
+}
@@ -0,0 +1,85 @@
+package orm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMigrationLock(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	defer func(poll, expiry time.Duration) { migrationLockPoll, migrationLockExpiry = poll, expiry }(migrationLockPoll, migrationLockExpiry)
+	migrationLockPoll = 10 * time.Millisecond
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var running, overlaps int
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, WithMigrationLock(ctx, db.DB, func() error {
+				mu.Lock()
+				running++
+				if running > 1 {
+					overlaps++
+				}
+				mu.Unlock()
+				time.Sleep(30 * time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+	assert.Zero(t, overlaps)
+
+	// Held elsewhere until it expires
+	err := WithMigrationLock(ctx, db.DB, func() error {
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		return WithMigrationLock(timeout, db.DB, func() error { return nil })
+	})
+	assert.Equal(t, ErrMigrationLocked, err)
+
+	assert.NoError(t, db.DB.Table("schema_lock").Create(&migrationLock{Name: migrationLockName, Owner: "dead", LockedAt: time.Now()}).Error)
+	migrationLockExpiry = 20 * time.Millisecond
+	ran := false
+	assert.NoError(t, WithMigrationLock(ctx, db.DB, func() error {
+		ran = true
+		return nil
+	}))
+	assert.True(t, ran)
+	var count int
+	assert.NoError(t, db.DB.Table("schema_lock").Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestWithMigrationLock_pool(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	pool := db.DB.DB()
+
+	assert.Equal(t, pool, primaryPool(&resolver{primary: pool}))
+	assert.Equal(t, pool, primaryPool(db.WithContext(context.Background()).DB.CommonDB()))
+
+	tx, err := pool.Begin()
+	assert.NoError(t, err)
+	defer tx.Rollback()
+	assert.Nil(t, primaryPool(tx))
+
+	postgres, err := gorm.Open("postgres", tx)
+	assert.NoError(t, err)
+	err = WithMigrationLock(context.Background(), postgres, func() error { return nil })
+	assert.EqualError(t, err, "migration lock requires a *sql.DB connection")
+}
@@ -0,0 +1,207 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type options struct {
+	logMode          bool
+	namingStrategy   *gorm.NamingStrategy
+	maxOpenConns     int
+	maxIdleConns     int
+	connMaxLifetime  time.Duration
+	connMaxIdleTime  time.Duration
+	replicas         []string
+	connectAttempts  int
+	connectBackoff   time.Duration
+	connectTimeout   time.Duration
+	logger           Logger
+	slowThreshold    time.Duration
+	slowHandler      func(SlowQuery)
+	redactArgs       bool
+	tracerProvider   trace.TracerProvider
+	maxStatement     int
+	redactStatement  func(sql string) string
+	txRetries        int
+	pragmas          []pragma
+	sqliteSingleConn bool
+	longTxThreshold  time.Duration
+	longTxHandler    func(TxStats)
+	archiveDeletes   bool
+	location         *time.Location
+}
+
+type Option func(*options)
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		// Negative keeps the database/sql default
+		maxIdleConns: -1,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Naming strategy keeping table and column names exactly as declared
+func declaredNames() *gorm.NamingStrategy {
+	return &gorm.NamingStrategy{
+		DB: func(name string) string {
+			return name
+		},
+		Table: func(name string) string {
+			return name
+		},
+		Column: func(name string) string {
+			return name
+		},
+	}
+}
+
+func LogModeOpt(enable bool) Option {
+	return func(o *options) {
+		o.logMode = enable
+	}
+}
+
+// LoggerOpt sends every statement to logger, independently of the log mode, see DB.SetQueryLogger
+func LoggerOpt(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// NamingStrategyOpt sets gorm's global naming strategy, nil fields fall back to gorm's snake case.
+// Instantiate defaults to keeping names as declared.
+func NamingStrategyOpt(ns *gorm.NamingStrategy) Option {
+	return func(o *options) {
+		o.namingStrategy = ns
+	}
+}
+
+// MaxOpenConnsOpt limits open connections to the database, zero means unlimited
+func MaxOpenConnsOpt(n int) Option {
+	return func(o *options) {
+		o.maxOpenConns = n
+	}
+}
+
+// MaxIdleConnsOpt limits idle connections kept in the pool, zero keeps none
+func MaxIdleConnsOpt(n int) Option {
+	return func(o *options) {
+		o.maxIdleConns = n
+	}
+}
+
+// ConnMaxLifetimeOpt closes connections once they have been open for d
+func ConnMaxLifetimeOpt(d time.Duration) Option {
+	return func(o *options) {
+		o.connMaxLifetime = d
+	}
+}
+
+// ConnMaxIdleTimeOpt closes connections once they have been idle for d
+func ConnMaxIdleTimeOpt(d time.Duration) Option {
+	return func(o *options) {
+		o.connMaxIdleTime = d
+	}
+}
+
+// ReplicasOpt spreads reads over the replicas of dsns, written as driver://source like the primary
+func ReplicasOpt(dsns ...string) Option {
+	return func(o *options) {
+		o.replicas = dsns
+	}
+}
+
+// ConnectRetryOpt waits for the database to become reachable instead of failing on the first ping.
+// [attempts] Maximum number of pings including the first one, unlimited when not positive and a timeout is set.
+// [backoff]  Delay before the first retry, doubled for every following one up to a minute.
+func ConnectRetryOpt(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.connectAttempts = attempts
+		o.connectBackoff = backoff
+	}
+}
+
+// ConnectTimeoutOpt gives up connecting once d has passed since the first ping
+func ConnectTimeoutOpt(d time.Duration) Option {
+	return func(o *options) {
+		o.connectTimeout = d
+	}
+}
+
+const maxConnectBackoff = time.Minute
+
+// Ping pool until it answers, following the retry policy
+func (o *options) connect(pool *sql.DB) error {
+	ctx := context.Background()
+	if o.connectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.connectTimeout)
+		defer cancel()
+	}
+
+	backoff := o.connectBackoff
+	for attempt := 1; ; attempt++ {
+		err := pool.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if o.connectAttempts > 0 && attempt >= o.connectAttempts || o.connectAttempts <= 0 && o.connectTimeout <= 0 {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+// Apply the gorm settings and callbacks to db
+func (o *options) configure(db *gorm.DB, lc *lifecycle, in *instrument) {
+	db.SingularTable(true)
+	db.LogMode(o.logMode)
+	db.Callback().Create().Before("gorm:before_create").Register("before_create_callback", beforeCreateCallback)
+	lc.register(db)
+	in.register(db)
+	registerReadOnly(db)
+	registerVersion(db)
+	registerAudit(db)
+	registerTenant(db)
+	registerEnums(db)
+	registerImmutable(db)
+	registerDialectValues(db)
+	if o.archiveDeletes {
+		registerArchiveDeletes(db)
+	}
+	if o.location != nil {
+		registerUTC(db, o.location)
+	}
+}
+
+// Apply the pool settings to the connection pool of db
+func (o *options) configurePool(db *gorm.DB) {
+	pool := db.DB()
+	if o.sqliteSingleConn && db.Dialect().GetName() == "sqlite3" {
+		pool.SetMaxOpenConns(1)
+	} else {
+		pool.SetMaxOpenConns(o.maxOpenConns)
+	}
+	if o.maxIdleConns >= 0 {
+		pool.SetMaxIdleConns(o.maxIdleConns)
+	}
+	pool.SetConnMaxLifetime(o.connMaxLifetime)
+	pool.SetConnMaxIdleTime(o.connMaxIdleTime)
+}
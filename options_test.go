@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstantiate(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	Instantiate("sqlite3://:memory:",
+		MaxOpenConnsOpt(1),
+		MaxIdleConnsOpt(1),
+		ConnMaxLifetimeOpt(time.Minute),
+		LogModeOpt(true),
+		NamingStrategyOpt(&gorm.NamingStrategy{}),
+	)
+	defer Singleton.Close(context.Background())
+
+	assert.Equal(t, 1, Singleton.DB.DB().Stats().MaxOpenConnections)
+	assert.Equal(t, "created_at", gorm.ToColumnName("CreatedAt"))
+	assert.Panics(t, func() { Instantiate("sqlite3://:memory:") })
+}
+
+// flakyDriver refuses connections until failures reaches zero
+type flakyDriver struct {
+	failures int
+}
+
+func (d *flakyDriver) Open(name string) (driver.Conn, error) {
+	if d.failures > 0 {
+		d.failures--
+		return nil, errors.New("connection refused")
+	}
+	return flakyConn{}, nil
+}
+
+type flakyConn struct{}
+
+func (flakyConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (flakyConn) Close() error                              { return nil }
+func (flakyConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func Test_options_connect(t *testing.T) {
+	flaky := &flakyDriver{}
+	sql.Register("orm_flaky", flaky)
+	pool, err := sql.Open("orm_flaky", "")
+	assert.NoError(t, err)
+	defer pool.Close()
+	// Every ping dials again
+	pool.SetMaxIdleConns(0)
+
+	flaky.failures = 2
+	assert.Error(t, newOptions().connect(pool))
+
+	flaky.failures = 2
+	assert.Error(t, newOptions(ConnectRetryOpt(2, time.Millisecond)).connect(pool))
+
+	flaky.failures = 2
+	assert.NoError(t, newOptions(ConnectRetryOpt(3, time.Millisecond)).connect(pool))
+
+	flaky.failures = 1000
+	start := time.Now()
+	assert.Error(t, newOptions(ConnectRetryOpt(0, time.Millisecond), ConnectTimeoutOpt(50*time.Millisecond)).connect(pool))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("sqlite3:" + t.TempDir())
+	assert.Error(t, err)
+
+	_, err = New("unknown://source")
+	assert.Error(t, err)
+
+	db, err := New("sqlite3://:memory:", MaxOpenConnsOpt(1))
+	assert.NoError(t, err)
+	assert.Nil(t, Singleton)
+	assert.NoError(t, db.Exec("SELECT 1").Error)
+	assert.NoError(t, db.Close(context.Background()))
+}
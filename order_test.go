@@ -0,0 +1,40 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_OrderBySafe(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&filterDB{}).Error)
+	for _, row := range []filterDB{
+		{ID: 1, Name: "b", Age: 30},
+		{ID: 2, Name: "a", Age: 30},
+		{ID: 3, Name: "c", Age: 20},
+	} {
+		assert.NoError(t, db.Create(&row).Error)
+	}
+
+	allowed := map[string]string{"name": "name", "age": "age", "id": "id"}
+	ids := func(input string) []int {
+		var ids []int
+		assert.NoError(t, db.OrderBySafe(input, allowed).Model(&filterDB{}).Order("id").Pluck("id", &ids).Error)
+		return ids
+	}
+	assert.Equal(t, []int{1, 2, 3}, ids(""))
+	assert.Equal(t, []int{2, 1, 3}, ids("name"))
+	assert.Equal(t, []int{3, 1, 2}, ids("-name"))
+	assert.Equal(t, []int{3, 2, 1}, ids("age, name"))
+	assert.Equal(t, []int{1, 2, 3}, ids("age desc,id ASC"))
+
+	for _, input := range []string{"email", "name; DROP TABLE filter_dbs", "name sideways", "-name desc", "(CASE WHEN 1 THEN id END)"} {
+		err := db.OrderBySafe(input, allowed).Find(&[]filterDB{}).Error
+		assert.True(t, IsSortError(err), input)
+	}
+}
@@ -1,6 +1,9 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"strings"
 	"time"
 
@@ -9,9 +12,11 @@ import (
 	_ "github.com/jinzhu/gorm/dialects/mysql"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
-	"github.com/rs/xid"
 
 	"github.com/cochainio/orm/bulk_insert"
+	_ "github.com/cochainio/orm/dialects/clickhouse"
+	"github.com/cochainio/orm/idgen"
+	"github.com/cochainio/orm/outbox"
 )
 
 var Singleton *DB
@@ -31,93 +36,431 @@ type Model struct {
 	UpdatedAt time.Time `gorm:"index"`
 }
 
-func Instantiate(dsn string, enableLog bool) {
+// Instantiate opens the database of dsn into Singleton, panicking on failure.
+// Table and column names are kept as declared unless NamingStrategyOpt says otherwise.
+func Instantiate(dsn string, opts ...Option) {
 	if Singleton != nil {
 		panic("orm has been instantiated")
 	}
 
-	args := strings.Split(dsn, "://")
-	db, err := gorm.Open(args[0], args[1])
+	db, err := New(dsn, append([]Option{NamingStrategyOpt(declaredNames())}, opts...)...)
 	if err != nil {
 		panic(err.Error())
 	}
+	Singleton = db
+}
 
-	db.SingularTable(true)
-	db.LogMode(enableLog)
+// New opens the database of dsn, written as driver://source.
+// Unlike Instantiate it neither sets Singleton nor changes gorm's naming strategy unless NamingStrategyOpt is given.
+func New(dsn string, opts ...Option) (*DB, error) {
+	o := newOptions(opts...)
+	if o.namingStrategy != nil {
+		gorm.AddNamingStrategy(o.namingStrategy)
+	}
 
-	gorm.AddNamingStrategy(&gorm.NamingStrategy{
-		DB: func(name string) string {
-			return name
-		},
-		Table: func(name string) string {
-			return name
-		},
-		Column: func(name string) string {
-			return name
-		},
-	})
+	lc := &lifecycle{}
+	in := &instrument{
+		logger:        o.logger,
+		slowThreshold: o.slowThreshold,
+		slowHandler:   o.slowHandler,
+		redactArgs:    o.redactArgs,
+		tracing:       newTracing(o),
 
-	beforeCreateCallback := func(scope *gorm.Scope) {
-		if !strings.HasSuffix(scope.TableName(), "deleted") {
-			pf := scope.PrimaryField()
-			if pf != nil && (pf.Name == "ID" || pf.DBName == "ID") && pf.IsBlank {
-				scope.SetColumn("ID", xid.New().String())
-			}
-		} else {
-			if scope.HasColumn("At") {
-				scope.SetColumn("At", gorm.NowFunc())
-			}
+		longTxThreshold: o.longTxThreshold,
+		longTxHandler:   o.longTxHandler,
+	}
+	db, err := open(dsn, o, lc, in)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.replicas) == 0 {
+		opened := &DB{DB: db, lifecycle: lc, instrument: in, txRetries: o.txRetries}
+		register(opened)
+		return opened, nil
+	}
+
+	replicas := make([]*gorm.DB, 0, len(o.replicas))
+	pools := make([]*sql.DB, 0, len(o.replicas))
+	closeAll := func() {
+		db.Close()
+		for _, replica := range replicas {
+			replica.Close()
+		}
+	}
+	for _, replicaDSN := range o.replicas {
+		replica, err := open(replicaDSN, o, lc, in)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		replicas = append(replicas, replica)
+		pools = append(pools, replica.DB())
+	}
+
+	router := &resolver{primary: db.DB(), replicas: pools}
+	routed, err := gorm.Open(db.Dialect().GetName(), router)
+	if err != nil {
+		closeAll()
+		return nil, err
+	}
+	o.configure(routed, lc, in)
+
+	opened := &DB{
+		DB:         routed,
+		primary:    db,
+		replicas:   replicas,
+		resolver:   router,
+		lifecycle:  lc,
+		instrument: in,
+		txRetries:  o.txRetries,
+	}
+	register(opened)
+	return opened, nil
+}
+
+// Open the database of dsn once it is reachable and configure it
+func open(dsn string, o *options, lc *lifecycle, in *instrument) (*gorm.DB, error) {
+	driver, source, err := splitDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if o.location != nil {
+		if source, err = utcSource(driver, source); err != nil {
+			return nil, err
 		}
 	}
+	pool, err := o.openPool(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.connect(pool); err != nil {
+		pool.Close()
+		return nil, err
+	}
 
-	db.Callback().Create().Before("gorm:before_create").Register("before_create_callback", beforeCreateCallback)
+	db, err := gorm.Open(driver, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	o.configurePool(db)
+	o.configure(db, lc, in)
+	return db, nil
+}
 
-	Singleton = &DB{
-		DB: db,
+// Split dsn written as driver://source
+func splitDSN(dsn string) (driver, source string, err error) {
+	i := strings.Index(dsn, "://")
+	if i < 0 {
+		return "", "", fmt.Errorf("dsn must be written as driver://source, got %q", dsn)
+	}
+	return dsn[:i], dsn[i+len("://"):], nil
+}
+
+// SetIDGenerator makes generate the generator of the IDs assigned on creation, by the create callback
+// and the bulk operations, nil restoring xid. Models implementing idgen.Model generate their own.
+// See idgen for ULID, UUIDv7 and Snowflake generators.
+func SetIDGenerator(generate func(scope *gorm.Scope) string) {
+	idgen.Set(generate)
+}
+
+func beforeCreateCallback(scope *gorm.Scope) {
+	if !strings.HasSuffix(scope.TableName(), "deleted") {
+		pf := scope.PrimaryField()
+		if pf != nil && (pf.Name == "ID" || pf.DBName == "ID") && pf.IsBlank {
+			scope.SetColumn("ID", idgen.New(scope))
+		}
+	} else {
+		if scope.HasColumn("At") {
+			scope.SetColumn("At", gorm.NowFunc())
+		}
 	}
 }
 
 type DB struct {
 	*gorm.DB
+
+	// Set when reads are spread over replicas
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	resolver *resolver
+
+	lifecycle  *lifecycle
+	instrument *instrument
+	ctx        context.Context
+	txRetries  int // Retries of ExecuteTx in cockroach mode
+	tx         *TX // Set on the DB of a transaction, see FromContext
+}
+
+// Primary returns db bound to the primary, for reads that must see preceding writes
+func (db *DB) Primary() *DB {
+	if db.primary == nil {
+		return db
+	}
+	return db.derive(db.primary)
+}
+
+// Replica returns db bound to the next replica, or to the primary when there is none
+func (db *DB) Replica() *DB {
+	if len(db.replicas) == 0 {
+		return db.Primary()
+	}
+	return db.derive(db.replicas[db.resolver.nextReplica()])
+}
+
+// DB of the same database as db bound to another connection
+func (db *DB) derive(conn *gorm.DB) *DB {
+	for _, key := range []string{readOnlyKey, tenantKey} {
+		if value, ok := db.DB.Get(key); ok {
+			conn = conn.Set(key, value)
+		}
+	}
+	return &DB{DB: withContext(conn, db.ctx), lifecycle: db.lifecycle, instrument: db.instrument, ctx: db.ctx, txRetries: db.txRetries}
+}
+
+// Run the bulk operation fn, refused by a read-only or tenant handle and once the DB is closing
+func (db *DB) observeBulk(fn func() error) error {
+	if isReadOnly(db.DB) {
+		return &ReadOnlyError{Operation: "bulk"}
+	}
+	if isTenantScoped(db.DB) {
+		return ErrTenantBulk
+	}
+	release, ok := db.acquire()
+	if !ok {
+		return ErrClosed
+	}
+	defer release()
+	return db.instrument.observeBulk(db.context(), fn)
 }
 
 func (db *DB) BulkCreate(objects interface{}, opts ...bulk_insert.BuilderOpt) error {
-	return bulk_insert.NewBuilder(opts...).Exec(db.DB, objects)
+	return db.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecContext(db.context(), db.DB, objects)
+	})
+}
+
+func (db *DB) BulkCreateMaps(table string, rows []map[string]interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return db.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecMaps(db.DB, table, rows)
+	})
+}
+
+func (db *DB) BulkUpdate(objects interface{}, columns ...string) error {
+	return db.observeBulk(func() error {
+		return bulk_insert.NewBuilder().ExecUpdate(db.DB, objects, columns...)
+	})
+}
+
+func (db *DB) BulkSave(objects interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return db.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecSave(db.DB, objects)
+	})
+}
+
+func (db *DB) BulkDelete(model interface{}, ids interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return db.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecDelete(db.DB, model, ids)
+	})
 }
 
 type TX struct {
 	*gorm.DB
+	*txState
+
+	lifecycle  *lifecycle
+	instrument *instrument
+	ctx        context.Context
+	depth      int    // Number of transactions tx is nested in
+	savepoint  string // Savepoint a nested transaction started at
+}
+
+// txState is shared by a TX and the copies WithContext makes of it
+type txState struct {
 	committed bool
+	ended     bool
+	stats     *txStats           // Shared with nested transactions
+	lifetime  context.Context    // Done once the timeout of TxTimeoutOpt elapsed, shared with nested transactions
+	cancel    context.CancelFunc // Releases lifetime when the transaction ends
 }
 
+// Begin starts a transaction, which fails with ErrClosed once the DB is closing
 func (db *DB) Begin() *TX {
-	return &TX{
-		DB: db.DB.Begin(),
+	return db.begin(sql.TxOptions{})
+}
+
+// BeginTx starts a transaction bound to ctx like WithContext, with the isolation level and read only mode of opts.
+// Levels a dialect does not provide fail, or map to a stronger one:
+//   - postgres and mysql: snapshot is repeatable read, which reads from a snapshot on both
+//   - mssql: read only is ignored, the driver has no read only transactions
+//   - sqlite3: every level is serializable and read only is ignored
+//   - clickhouse: there is neither isolation nor read only
+//
+// txOpts further configure the transaction, see TxTimeoutOpt.
+func (db *DB) BeginTx(ctx context.Context, opts sql.TxOptions, txOpts ...TxOpt) *TX {
+	return db.WithContext(ctx).begin(opts, txOpts...)
+}
+
+func (db *DB) begin(opts sql.TxOptions, txOpts ...TxOpt) *TX {
+	var cfg txConfig
+	for _, opt := range txOpts {
+		opt(&cfg)
+	}
+
+	if db.tx != nil {
+		return db.tx.Begin()
+	}
+	opts, err := txOptions(db.Dialect().GetName(), opts)
+	if err == nil && !db.lifecycle.acquire() {
+		err = ErrClosed
+	}
+	if err != nil {
+		failed := db.DB.New()
+		failed.AddError(err)
+		return &TX{DB: failed, txState: &txState{ended: true}}
 	}
+	tx := &TX{
+		txState:    &txState{},
+		lifecycle:  db.lifecycle,
+		instrument: db.instrument,
+		ctx:        db.ctx,
+	}
+	if cfg.timeout > 0 {
+		tx.startTimeout(cfg.timeout)
+	}
+	db.instrument.trace(tx.context(), "orm.Begin", func() error {
+		tx.DB = db.DB.BeginTx(tx.context(), &opts)
+		return tx.DB.Error
+	})
+	if tx.DB.Error != nil {
+		// Nothing to commit nor roll back
+		tx.end()
+	} else {
+		tx.startStats()
+		if cfg.timeout > 0 {
+			if err := tx.limitIdle(cfg.timeout); err != nil {
+				tx.Rollback()
+				tx.DB = db.DB.New()
+				tx.DB.AddError(err)
+			}
+		}
+	}
+	tx.DB = withContext(tx.DB, tx.ctx)
+	return tx
 }
 
+// End rolls back the transaction unless it was committed, meant to be deferred right after Begin
 func (tx *TX) End() {
-	if !tx.committed {
-		tx.Rollback()
+	tx.EndE()
+}
+
+// EndE is End returning the error of the rollback, nil when the transaction was already committed or rolled back
+func (tx *TX) EndE() error {
+	if tx.committed || tx.ended {
+		return nil
 	}
+	return tx.Rollback().Error
+}
+
+// Rollback aborts the transaction, or undoes what a nested transaction did
+func (tx *TX) Rollback() *gorm.DB {
+	defer tx.end()
+	var rolledBack *gorm.DB
+	tx.instrument.trace(tx.context(), "orm.Rollback", func() error {
+		if tx.savepoint != "" {
+			rolledBack = tx.rollbackNested()
+		} else {
+			rolledBack = tx.DB.Rollback()
+		}
+		return rolledBack.Error
+	})
+	return rolledBack
 }
 
+// Let Close know the transaction is no longer in flight and report its stats
+func (tx *TX) end() {
+	if !tx.ended {
+		tx.ended = true
+		tx.lifecycle.release()
+		if tx.cancel != nil {
+			tx.cancel()
+		}
+		if tx.savepoint != "" {
+			return
+		}
+		if stats, ok := tx.stats.finish(tx.committed); ok {
+			tx.instrument.reportTx(stats)
+		}
+	}
+}
+
+// Commit commits the transaction, or releases the savepoint of a nested transaction.
+// It panics on failure unless noPanic is true, see CommitE.
 func (tx *TX) Commit(noPanic ...bool) error {
-	tx.DB.Commit()
+	var err error
+	tx.instrument.trace(tx.context(), "orm.Commit", func() error {
+		if tx.savepoint != "" {
+			err = tx.releaseSavepoint(tx.savepoint).Error
+		} else {
+			err = tx.DB.Commit().Error
+		}
+		return err
+	})
+	if err != nil {
+		if timeout := tx.timeoutErr(); timeout != nil {
+			err = timeout
+		}
+	}
+	tx.committed = err == nil
+	tx.end()
 
-	if tx.DB.Error != nil {
+	if err != nil {
 		if len(noPanic) > 0 && noPanic[0] {
-			return tx.DB.Error
+			return err
 		}
-		panic(tx.DB.Error.Error())
+		panic(err.Error())
 	}
-
-	tx.committed = true
 	return nil
 }
 
+// CommitE is Commit returning its error instead of panicking
+func (tx *TX) CommitE() error {
+	return tx.Commit(true)
+}
+
 func (tx *TX) BulkCreate(objects interface{}, opts ...bulk_insert.BuilderOpt) error {
-	return bulk_insert.NewBuilder(opts...).Exec(tx.DB, objects)
+	return tx.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecContext(tx.context(), tx.DB, objects)
+	})
+}
+
+func (tx *TX) BulkCreateMaps(table string, rows []map[string]interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return tx.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecMaps(tx.DB, table, rows)
+	})
+}
+
+func (tx *TX) BulkUpdate(objects interface{}, columns ...string) error {
+	return tx.observeBulk(func() error {
+		return bulk_insert.NewBuilder().ExecUpdate(tx.DB, objects, columns...)
+	})
+}
+
+func (tx *TX) BulkSave(objects interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return tx.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecSave(tx.DB, objects)
+	})
+}
+
+func (tx *TX) BulkDelete(model interface{}, ids interface{}, opts ...bulk_insert.BuilderOpt) error {
+	return tx.observeBulk(func() error {
+		return bulk_insert.NewBuilder(opts...).ExecDelete(tx.DB, model, ids)
+	})
+}
+
+// Publish writes a message to the outbox in the transaction, see outbox.Publish
+func (tx *TX) Publish(topic string, payload interface{}) error {
+	return outbox.Publish(tx.DB, topic, payload)
 }
 
 func IsRecordNotFound(err error) bool {
@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type idDB struct {
+	IDModel
+	Name string
+}
+
+func Test_SetIDGenerator(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "id.db"), NamingStrategyOpt(&gorm.NamingStrategy{}))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&idDB{}).Error)
+
+	created := &idDB{Name: "xid"}
+	assert.NoError(t, db.Create(created).Error)
+	assert.Len(t, created.ID, 20)
+
+	SetIDGenerator(func(scope *gorm.Scope) string { return "generated" })
+	defer SetIDGenerator(nil)
+	created = &idDB{Name: "generated"}
+	assert.NoError(t, db.Create(created).Error)
+	assert.Equal(t, "generated", created.ID)
+
+	bulk := []*idDB{{Name: "bulk"}}
+	SetIDGenerator(func(scope *gorm.Scope) string { return "bulk" })
+	assert.NoError(t, db.BulkCreate(bulk))
+	assert.Equal(t, "bulk", bulk[0].ID)
+}
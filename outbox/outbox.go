@@ -0,0 +1,45 @@
+// Package outbox publishes events along with database changes: messages are written to an outbox table
+// in the transaction making the changes, then delivered by a Relay once committed.
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Message is a row of the outbox table
+type Message struct {
+	ID        int64      `gorm:"column:id;primary_key"`
+	Topic     string     `gorm:"column:topic;size:255;not null"`
+	Payload   []byte     `gorm:"column:payload"`
+	CreatedAt time.Time  `gorm:"column:created_at"`
+	SentAt    *time.Time `gorm:"column:sent_at;index"`
+	Attempts  int        `gorm:"column:attempts"`
+	LastError string     `gorm:"column:last_error;size:1024"`
+}
+
+// TableName is fixed, whatever the naming strategy
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// Migrate creates or updates the outbox table
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Message{}).Error
+}
+
+// Publish writes a message to the outbox with db, which should be the transaction making the changes the message
+// tells about, so that it is delivered if and only if they are committed.
+// payload is sent as is when it is a []byte, encoded as JSON otherwise.
+func Publish(db *gorm.DB, topic string, payload interface{}) error {
+	data, ok := payload.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	return db.Create(&Message{Topic: topic, Payload: data}).Error
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "outbox.db"))
+	assert.NoError(t, err)
+	assert.NoError(t, Migrate(db))
+	return db
+}
+
+type recorder struct {
+	messages []Message
+	fail     error
+}
+
+func (r *recorder) Publish(ctx context.Context, m Message) error {
+	if r.fail != nil {
+		return r.fail
+	}
+	r.messages = append(r.messages, m)
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+
+	tx := db.Begin()
+	assert.NoError(t, Publish(tx, "orders", map[string]int{"id": 1}))
+	assert.NoError(t, tx.Rollback().Error)
+
+	tx = db.Begin()
+	assert.NoError(t, Publish(tx, "orders", map[string]int{"id": 2}))
+	assert.NoError(t, Publish(tx, "raw", []byte("payload")))
+	assert.NoError(t, tx.Commit().Error)
+
+	var messages []Message
+	assert.NoError(t, db.Order("id").Find(&messages).Error)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "orders", messages[0].Topic)
+	assert.Equal(t, `{"id":2}`, string(messages[0].Payload))
+	assert.Equal(t, "payload", string(messages[1].Payload))
+	assert.Nil(t, messages[0].SentAt)
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	for _, topic := range []string{"a", "b", "c"} {
+		assert.NoError(t, Publish(db, topic, []byte(topic)))
+	}
+
+	publisher := &recorder{fail: errors.New("broker unavailable")}
+	relay := NewRelay(db, publisher, BatchSizeOpt(2))
+
+	// Failures are recorded and stop the batch
+	sent, err := relay.RelayOnce(context.Background())
+	assert.EqualError(t, err, "broker unavailable")
+	assert.Equal(t, 0, sent)
+	var failed Message
+	assert.NoError(t, db.First(&failed).Error)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, "broker unavailable", failed.LastError)
+
+	publisher.fail = nil
+	sent, err = relay.RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	sent, err = relay.RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = relay.RelayOnce(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	var topics []string
+	for _, m := range publisher.messages {
+		topics = append(topics, m.Topic)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, topics)
+	var pending int
+	assert.NoError(t, db.Model(&Message{}).Where("sent_at IS NULL").Count(&pending).Error)
+	assert.Equal(t, 0, pending)
+}
+
+func TestRelay_Run(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+
+	delivered := make(chan Message, 1)
+	relay := NewRelay(db, PublisherFunc(func(ctx context.Context, m Message) error {
+		delivered <- m
+		return nil
+	}), IntervalOpt(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- relay.Run(ctx) }()
+
+	assert.NoError(t, Publish(db, "late", []byte("late")))
+	select {
+	case m := <-delivered:
+		assert.Equal(t, "late", m.Topic)
+	case <-time.After(5 * time.Second):
+		t.Fatal("message not delivered")
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
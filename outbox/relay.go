@@ -0,0 +1,142 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Publisher delivers messages to a broker, like Kafka, NATS or an HTTP endpoint
+type Publisher interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+// PublisherFunc adapts a function to Publisher
+type PublisherFunc func(ctx context.Context, m Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, m Message) error {
+	return f(ctx, m)
+}
+
+// Relay delivers the messages of the outbox in the order they were written and marks them sent.
+// A message is delivered at least once: it is sent again when marking it fails.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	batchSize int
+	interval  time.Duration
+	onError   func(error)
+}
+
+type RelayOpt func(*Relay)
+
+// BatchSizeOpt sets the number of messages locked and delivered at a time, 100 by default
+func BatchSizeOpt(size int) RelayOpt {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// IntervalOpt sets how long Run waits before polling again once the outbox is drained, a second by default
+func IntervalOpt(d time.Duration) RelayOpt {
+	return func(r *Relay) {
+		r.interval = d
+	}
+}
+
+// OnErrorOpt reports the errors Run goes on after, like failed deliveries
+func OnErrorOpt(fn func(error)) RelayOpt {
+	return func(r *Relay) {
+		r.onError = fn
+	}
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, opts ...RelayOpt) *Relay {
+	r := &Relay{
+		db:        db,
+		publisher: publisher,
+		batchSize: 100,
+		interval:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Run delivers messages until ctx is done, polling the outbox every interval once it is drained
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		sent, err := r.RelayOnce(ctx)
+		if err != nil && r.onError != nil {
+			r.onError(err)
+		}
+		if sent == r.batchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-time.After(r.interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// RelayOnce delivers a batch of pending messages and returns how many were sent.
+// The batch is locked with FOR UPDATE SKIP LOCKED on Postgres and MySQL, so that relays can run side by side.
+// Delivery stops at the first failure, recorded on the message, to keep the order of the following ones.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	tx := r.db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	var batch []Message
+	query := tx.Where("sent_at IS NULL").Order("id").Limit(r.batchSize)
+	switch tx.Dialect().GetName() {
+	case "postgres", "mysql":
+		query = query.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED")
+	}
+	if err := query.Find(&batch).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var deliveryErr error
+	for _, m := range batch {
+		if deliveryErr = r.publisher.Publish(ctx, m); deliveryErr != nil {
+			failed := tx.Model(&m).UpdateColumns(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": truncate(deliveryErr.Error(), 1024),
+			})
+			if failed.Error != nil {
+				return sent, failed.Error
+			}
+			break
+		}
+		if err := tx.Model(&m).UpdateColumn("sent_at", time.Now()).Error; err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	if err := tx.Commit().Error; err != nil {
+		return 0, err
+	}
+	committed = true
+	return sent, deliveryErr
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,214 @@
+package orm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrInvalidCursor is returned for a cursor CursorPaginate did not issue for the same ordering
+var ErrInvalidCursor = errors.New("orm: invalid cursor")
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+type cursorConfig struct {
+	keys []sortKey
+	desc bool
+}
+
+type CursorOpt func(*cursorConfig)
+
+// SortKeyOpt orders the pages by column before the primary key, in the order of the options.
+// The columns must not be NULL.
+func SortKeyOpt(column string, desc bool) CursorOpt {
+	return func(c *cursorConfig) {
+		c.keys = append(c.keys, sortKey{column: column, desc: desc})
+	}
+}
+
+// DescOpt orders the pages by descending primary key, the newest records first with xid IDs
+func DescOpt() CursorOpt {
+	return func(c *cursorConfig) {
+		c.desc = true
+	}
+}
+
+// Content of a cursor, the sort columns and the values of the last record of a page
+type cursor struct {
+	Columns []string          `json:"c"`
+	Values  []json.RawMessage `json:"v"`
+}
+
+// CursorPaginate finds the page of at most limit records of query, nil for every record, following the cursor after
+// into results, a pointer to a slice. An empty cursor starts from the first page.
+// The records are ordered by primary key, k-sortable xid IDs giving the order of creation, after the sort keys of opts.
+// Pages are found by key rather than with OFFSET, so deep pages cost as much as the first.
+// The returned cursor leads to the next page, empty after the last one.
+func (db *DB) CursorPaginate(query *gorm.DB, after string, limit int, results interface{}, opts ...CursorOpt) (string, error) {
+	if limit <= 0 {
+		return "", fmt.Errorf("orm: page limit must be positive, got %d", limit)
+	}
+	if query == nil {
+		query = db.DB
+	}
+	slice := reflect.ValueOf(results)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return "", fmt.Errorf("orm: paginating needs a pointer to a slice, got %T", results)
+	}
+	var cfg cursorConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	scope := query.NewScope(reflect.New(modelType(slice.Type())).Interface())
+	primary := scope.PrimaryField()
+	if primary == nil {
+		return "", fmt.Errorf("orm: paginating %s needs a primary key", scope.TableName())
+	}
+	keys := append(cfg.keys, sortKey{column: primary.DBName, desc: cfg.desc})
+	fields := make([]*gorm.Field, len(keys))
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		field, ok := scope.FieldByName(key.column)
+		if !ok {
+			return "", fmt.Errorf("orm: unknown sort key %s", key.column)
+		}
+		fields[i], columns[i] = field, field.DBName
+	}
+
+	if after != "" {
+		values, err := decodeCursor(after, columns, fields)
+		if err != nil {
+			return "", err
+		}
+		query = query.Where(keysetCondition(scope, keys, columns), keysetArgs(values)...)
+	}
+	for i, key := range keys {
+		order := scope.Quote(columns[i])
+		if key.desc {
+			order += " DESC"
+		}
+		query = query.Order(order)
+	}
+	if err := query.Limit(limit + 1).Find(results).Error; err != nil {
+		return "", err
+	}
+
+	page := slice.Elem()
+	if page.Len() <= limit {
+		return "", nil
+	}
+	page.Set(page.Slice(0, limit))
+	last := reflect.Indirect(page.Index(limit - 1))
+	next := cursor{Columns: columns}
+	for _, field := range fields {
+		value, err := json.Marshal(last.FieldByIndex(fieldIndex(last.Type(), field)).Interface())
+		if err != nil {
+			return "", err
+		}
+		next.Values = append(next.Values, value)
+	}
+	encoded, err := json.Marshal(next)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// Index of field in the struct typ, embedded structs included
+func fieldIndex(typ reflect.Type, field *gorm.Field) []int {
+	if structField, ok := typ.FieldByName(field.Name); ok {
+		return structField.Index
+	}
+	return field.Struct.Index
+}
+
+// Values of encoded, checked against the sort columns and decoded into the types of fields
+func decodeCursor(encoded string, columns []string, fields []*gorm.Field) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || len(c.Values) != len(columns) ||
+		strings.Join(c.Columns, ",") != strings.Join(columns, ",") {
+		return nil, ErrInvalidCursor
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		value := reflect.New(field.Struct.Type)
+		if err := json.Unmarshal(c.Values[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+// Condition of the records after the key values, (k1 > ?) OR (k1 = ? AND k2 > ?) and so on
+func keysetCondition(scope *gorm.Scope, keys []sortKey, columns []string) string {
+	var alternatives []string
+	for i, key := range keys {
+		var terms []string
+		for _, column := range columns[:i] {
+			terms = append(terms, scope.Quote(column)+" = ?")
+		}
+		operator := " > ?"
+		if key.desc {
+			operator = " < ?"
+		}
+		terms = append(terms, scope.Quote(columns[i])+operator)
+		alternatives = append(alternatives, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")"
+}
+
+// Arguments of keysetCondition
+func keysetArgs(values []interface{}) []interface{} {
+	var args []interface{}
+	for i := range values {
+		args = append(args, values[:i+1]...)
+	}
+	return args
+}
+
+// FindInBatches calls fn with the records of query, batchSize at a time in order of primary key,
+// holding a single batch in memory. query names its model, like db.Model(&User{}).Where(...), and batch is
+// a pointer to a slice of the type of the model. batchNo counts from 1.
+// Every call runs in its own transaction, an error stopping the iteration and being returned.
+func (db *DB) FindInBatches(query *gorm.DB, batchSize int, fn func(tx *TX, batch interface{}, batchNo int) error) error {
+	if query == nil || query.Value == nil {
+		return errors.New("orm: finding in batches needs the model of the query")
+	}
+	sliceType := reflect.SliceOf(reflect.TypeOf(query.Value))
+	if sliceType.Elem().Kind() == reflect.Ptr {
+		sliceType = reflect.SliceOf(sliceType.Elem().Elem())
+	}
+
+	after := ""
+	for batchNo := 1; ; batchNo++ {
+		batch := reflect.New(sliceType)
+		next, err := db.CursorPaginate(query, after, batchSize, batch.Interface())
+		if err != nil {
+			return err
+		}
+		if batch.Elem().Len() == 0 {
+			return nil
+		}
+		err = db.Transaction(db.context(), func(tx *TX) error {
+			return fn(tx, batch.Interface(), batchNo)
+		})
+		if err != nil || next == "" {
+			return err
+		}
+		after = next
+	}
+}
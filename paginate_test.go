@@ -0,0 +1,128 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type paginateDB struct {
+	Model
+	Score int
+	Group string
+}
+
+func TestDB_CursorPaginate(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&paginateDB{}).Error)
+	var ids []string
+	for i := 0; i < 25; i++ {
+		record := paginateDB{Score: i % 4, Group: []string{"a", "b"}[i%2]}
+		assert.NoError(t, db.Create(&record).Error)
+		ids = append(ids, record.ID)
+	}
+	assert.True(t, sort.StringsAreSorted(ids))
+
+	all := func(query *gorm.DB, limit int, opts ...CursorOpt) (pages [][]paginateDB) {
+		cursor := ""
+		for {
+			var page []paginateDB
+			next, err := db.CursorPaginate(query, cursor, limit, &page, opts...)
+			assert.NoError(t, err)
+			pages = append(pages, page)
+			if next == "" {
+				return pages
+			}
+			cursor = next
+		}
+	}
+	flatten := func(pages [][]paginateDB) (ids []string) {
+		for _, page := range pages {
+			for _, record := range page {
+				ids = append(ids, record.ID)
+			}
+		}
+		return ids
+	}
+
+	pages := all(nil, 10)
+	assert.Len(t, pages, 3)
+	assert.Len(t, pages[2], 5)
+	assert.Equal(t, ids, flatten(pages))
+
+	desc := flatten(all(nil, 7, DescOpt()))
+	assert.Len(t, desc, 25)
+	assert.Equal(t, ids[24], desc[0])
+	assert.Equal(t, ids[0], desc[24])
+
+	pages = all(db.Where(`"group" = ?`, "a"), 4, SortKeyOpt("score", true))
+	var scored []paginateDB
+	for _, page := range pages {
+		scored = append(scored, page...)
+	}
+	assert.Len(t, scored, 13)
+	assert.True(t, sort.SliceIsSorted(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].ID < scored[j].ID
+	}))
+
+	// Exact fit, no empty page after
+	assert.Len(t, all(nil, 25), 1)
+
+	var page []paginateDB
+	_, err := db.CursorPaginate(nil, "garbage", 10, &page)
+	assert.Equal(t, ErrInvalidCursor, err)
+	next, err := db.CursorPaginate(nil, "", 10, &page)
+	assert.NoError(t, err)
+	_, err = db.CursorPaginate(nil, next, 10, &page, DescOpt(), SortKeyOpt("score", false))
+	assert.Equal(t, ErrInvalidCursor, err)
+	_, err = db.CursorPaginate(nil, "", 10, page)
+	assert.Error(t, err)
+}
+
+func TestDB_FindInBatches(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&paginateDB{}).Error)
+	for i := 0; i < 23; i++ {
+		assert.NoError(t, db.Create(&paginateDB{Score: i}).Error)
+	}
+
+	var sizes, numbers []int
+	err := db.FindInBatches(db.Model(&paginateDB{}).Where("score >= ?", 3), 5, func(tx *TX, batch interface{}, batchNo int) error {
+		records := *batch.(*[]paginateDB)
+		sizes, numbers = append(sizes, len(records)), append(numbers, batchNo)
+		for _, record := range records {
+			if err := tx.Model(&record).UpdateColumn("group", "done").Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 5, 5, 5}, sizes)
+	assert.Equal(t, []int{1, 2, 3, 4}, numbers)
+	var count int
+	assert.NoError(t, db.Model(&paginateDB{}).Where(`"group" = ?`, "done").Count(&count).Error)
+	assert.Equal(t, 20, count)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = db.FindInBatches(db.Model(&paginateDB{}), 10, func(tx *TX, batch interface{}, batchNo int) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+	assert.Error(t, db.FindInBatches(db.Where("score > 0"), 10, nil))
+	assert.NoError(t, db.FindInBatches(db.Model(&paginateDB{}).Where("score > 100"), 10, nil))
+}
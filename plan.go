@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// MigrationPlan is what AutoMigrate would do to the schema, see PlanMigration
+type MigrationPlan struct {
+	// DDL AutoMigrate would run, in order
+	Statements []string
+	// Changes AutoMigrate would leave out: type changes and columns to drop
+	Destructive []SchemaDrift
+}
+
+// DestructiveChangeError is the error of a strict migration whose models differ from the schema
+// in ways AutoMigrate does not apply
+type DestructiveChangeError struct {
+	Changes []SchemaDrift
+}
+
+func (e *DestructiveChangeError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, change := range e.Changes {
+		changes[i] = change.String()
+	}
+	return "orm: destructive schema changes: " + strings.Join(changes, ", ")
+}
+
+// PlanMigration compares models, the registered ones when none is given, with the schema without modifying it.
+// It returns the statements AutoMigrate would run, and the differences it would silently leave,
+// which need a migration, see the migrate package.
+func (db *DB) PlanMigration(models ...interface{}) (*MigrationPlan, error) {
+	if len(models) == 0 {
+		models = registeredModels(db.DB)
+	}
+	recorder := &recordingCommon{SQLCommon: db.DB.CommonDB()}
+	dry := db.DB.New()
+	setCommon(dry, recorder)
+	if err := dry.AutoMigrate(models...).Error; err != nil {
+		return nil, err
+	}
+
+	drifts, err := db.schemaDrifts(models)
+	if err != nil {
+		return nil, err
+	}
+	plan := &MigrationPlan{Statements: recorder.statements}
+	for _, drift := range drifts {
+		if drift.Kind == TypeChange || drift.Kind == ExtraColumn {
+			plan.Destructive = append(plan.Destructive, drift)
+		}
+	}
+	return plan, nil
+}
+
+// AutoMigrateStrict runs AutoMigrate on models, the registered ones when none is given, unless the schema differs
+// from them in ways AutoMigrate does not apply, type changes or columns to drop, failing then with
+// a DestructiveChangeError without modifying anything
+func (db *DB) AutoMigrateStrict(models ...interface{}) error {
+	if len(models) == 0 {
+		models = registeredModels(db.DB)
+	}
+	plan, err := db.PlanMigration(models...)
+	if err != nil {
+		return err
+	}
+	if len(plan.Destructive) > 0 {
+		return &DestructiveChangeError{Changes: plan.Destructive}
+	}
+	if err := db.AutoMigrate(models...).Error; err != nil {
+		return fmt.Errorf("orm: migrating: %v", err)
+	}
+	return nil
+}
+
+// recordingCommon records the statements run with Exec instead of running them, queries reach the database
+type recordingCommon struct {
+	gorm.SQLCommon
+	statements []string
+}
+
+func (c *recordingCommon) Exec(query string, args ...interface{}) (sql.Result, error) {
+	c.statements = append(c.statements, strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	return driver.RowsAffected(0), nil
+}
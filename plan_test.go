@@ -0,0 +1,83 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type planV1 struct {
+	ID    int
+	Name  string
+	Score int
+}
+
+func (planV1) TableName() string { return "plan" }
+
+type planV2 struct {
+	ID    int
+	Name  string
+	Score int
+	Email string `gorm:"index"`
+}
+
+func (planV2) TableName() string { return "plan" }
+
+type planV3 struct {
+	ID    int
+	Score string
+}
+
+func (planV3) TableName() string { return "plan" }
+
+func TestDB_PlanMigration(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	plan, err := db.PlanMigration(&planV1{})
+	assert.NoError(t, err)
+	assert.Len(t, plan.Statements, 1)
+	assert.Contains(t, plan.Statements[0], `CREATE TABLE "plan"`)
+	assert.False(t, db.HasTable("plan"))
+	assert.NoError(t, db.AutoMigrateStrict(&planV1{}))
+
+	plan, err = db.PlanMigration(&planV2{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`ALTER TABLE "plan" ADD "email" varchar(255)`,
+		`CREATE INDEX idx_plan_email ON "plan"("email")`,
+	}, plan.Statements)
+	assert.Empty(t, plan.Destructive)
+	assert.NoError(t, db.AutoMigrateStrict(&planV2{}))
+	plan, err = db.PlanMigration(&planV2{})
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Statements)
+
+	plan, err = db.PlanMigration(&planV3{})
+	assert.NoError(t, err)
+	assert.Empty(t, plan.Statements)
+	assert.Equal(t, []SchemaDrift{
+		{Kind: TypeChange, Table: "plan", Name: "score", Detail: "integer -> varchar(255)"},
+		{Kind: ExtraColumn, Table: "plan", Name: "name"},
+		{Kind: ExtraColumn, Table: "plan", Name: "email"},
+	}, plan.Destructive)
+	err = db.AutoMigrateStrict(&planV3{})
+	assert.EqualError(t, err, "orm: destructive schema changes: type change plan.score (integer -> varchar(255)), extra column plan.name, extra column plan.email")
+}
+
+func Test_typeFamily(t *testing.T) {
+	assert.Equal(t, "integer", typeFamily("postgres", "INT8"))
+	assert.Equal(t, "integer", typeFamily("postgres", "bigserial"))
+	assert.Equal(t, "text", typeFamily("postgres", "character varying(255)"))
+	assert.Equal(t, "time", typeFamily("postgres", "timestamp with time zone"))
+	assert.Equal(t, "array", typeFamily("postgres", "_TEXT"))
+	assert.Equal(t, "array", typeFamily("postgres", "text[]"))
+	assert.Equal(t, "bool", typeFamily("postgres", "boolean"))
+	assert.Equal(t, "integer", typeFamily("mysql", "boolean"))
+	assert.Equal(t, "float", typeFamily("mysql", "double precision"))
+	assert.Equal(t, "text", typeFamily("clickhouse", "Nullable(String)"))
+	assert.Equal(t, "geometry", typeFamily("postgres", "GEOMETRY"))
+}
@@ -0,0 +1,33 @@
+package orm
+
+import "context"
+
+type txContextKey struct{}
+
+// ContextWithTX returns ctx carrying tx, for FromContext to pick up
+func ContextWithTX(ctx context.Context, tx *TX) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TXFromContext returns the transaction ctx carries, nil when there is none
+func TXFromContext(ctx context.Context) *TX {
+	tx, _ := ctx.Value(txContextKey{}).(*TX)
+	return tx
+}
+
+// FromContext returns the DB to use under ctx: the transaction it carries when there is one, Singleton otherwise,
+// bound to ctx either way. Transactions begun from the DB of a transaction are nested in it.
+func FromContext(ctx context.Context) *DB {
+	if tx := TXFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx).asDB()
+	}
+	if Singleton == nil {
+		return nil
+	}
+	return Singleton.WithContext(ctx)
+}
+
+// DB running its statements in tx
+func (tx *TX) asDB() *DB {
+	return &DB{DB: tx.DB, instrument: tx.instrument, ctx: tx.ctx, tx: tx}
+}
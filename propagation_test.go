@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	assert.Nil(t, FromContext(context.Background()))
+
+	Singleton = openTransactionDB(t)
+	defer Singleton.Close(context.Background())
+
+	// A repository function unaware of transactions
+	create := func(ctx context.Context, name string) error {
+		return FromContext(ctx).Create(&transactionDB{Name: name}).Error
+	}
+	count := func(ctx context.Context) (count int) {
+		assert.NoError(t, FromContext(ctx).Model(&transactionDB{}).Count(&count).Error)
+		return
+	}
+
+	assert.NoError(t, create(context.Background(), "outside"))
+
+	tx := Singleton.Begin()
+	defer tx.End()
+	ctx := ContextWithTX(context.Background(), tx)
+	assert.Equal(t, tx, TXFromContext(ctx))
+	assert.NoError(t, create(ctx, "inside"))
+	assert.Equal(t, 2, count(ctx))
+	assert.Equal(t, 1, count(context.Background()))
+
+	// Transactions of the repository nest in the ambient one
+	assert.Error(t, FromContext(ctx).Transaction(ctx, func(tx *TX) error {
+		assert.NoError(t, tx.Create(&transactionDB{Name: "nested"}).Error)
+		return assert.AnError
+	}))
+	assert.Equal(t, 2, count(ctx))
+
+	assert.NoError(t, tx.CommitE())
+	assert.Equal(t, 2, count(context.Background()))
+}
@@ -0,0 +1,125 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/jinzhu/gorm"
+)
+
+const readOnlyKey = "orm:read_only"
+
+// Raw statements a read-only handle runs
+var readOnlyStatement = regexp.MustCompile(`(?i)^\s*(SELECT|WITH|SHOW|EXPLAIN|DESCRIBE|DESC)\b`)
+
+// ReadOnlyError is the error of a write attempted through a ReadOnly handle
+type ReadOnlyError struct {
+	Operation string // insert, update, delete, bulk or raw
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("orm: %s rejected by a read-only handle", e.Operation)
+}
+
+// IsReadOnly reports whether err is, or contains, a ReadOnlyError
+func IsReadOnly(err error) bool {
+	if errs, ok := err.(gorm.Errors); ok {
+		for _, err := range errs {
+			if IsReadOnly(err) {
+				return true
+			}
+		}
+	}
+	var readOnly *ReadOnlyError
+	return errors.As(err, &readOnly)
+}
+
+// ReadOnly returns db rejecting inserts, updates, deletes, bulk operations and raw writes with a ReadOnlyError
+// before they reach the database. The handles and transactions derived from it are read-only as well.
+// Raw statements are told apart by their first keyword, SELECT and the like being the only ones allowed.
+func (db *DB) ReadOnly() *DB {
+	derived := *db
+	derived.DB = db.DB.Set(readOnlyKey, true)
+	return &derived
+}
+
+// Exec runs a raw statement like gorm's Exec, which fails with a ReadOnlyError on a ReadOnly handle
+// unless the statement only reads. gorm runs no callback for Exec, so that the Exec of handles chained from db,
+// like db.Table("t").Exec(...), is not checked.
+func (db *DB) Exec(sql string, values ...interface{}) *gorm.DB {
+	release, ok := db.acquire()
+	if !ok {
+		closed := db.DB.New()
+		closed.AddError(ErrClosed)
+		return closed
+	}
+	defer release()
+	return exec(db.DB, sql, values...)
+}
+
+// Exec runs a raw statement in the transaction, checked like DB.Exec
+func (tx *TX) Exec(sql string, values ...interface{}) *gorm.DB {
+	return exec(tx.DB, sql, values...)
+}
+
+func exec(db *gorm.DB, sql string, values ...interface{}) *gorm.DB {
+	if isReadOnly(db) && !readOnlyStatement.MatchString(sql) {
+		rejected := db.New()
+		rejected.AddError(&ReadOnlyError{Operation: "raw"})
+		return rejected
+	}
+	return db.Exec(sql, values...)
+}
+
+func isReadOnly(db *gorm.DB) bool {
+	readOnly, ok := db.Get(readOnlyKey)
+	return ok && readOnly == true
+}
+
+// Register the callbacks rejecting the writes of read-only handles
+func registerReadOnly(db *gorm.DB) {
+	reject := func(operation string) func(scope *gorm.Scope) {
+		return func(scope *gorm.Scope) {
+			if readOnly, ok := scope.Get(readOnlyKey); ok && readOnly == true {
+				scope.Err(&ReadOnlyError{Operation: operation})
+			}
+		}
+	}
+	// Statements built by gorm are plain selects, raw ones are checked
+	rejectRaw := func(scope *gorm.Scope) {
+		if readOnly, ok := scope.Get(readOnlyKey); !ok || readOnly != true {
+			return
+		}
+		if sql, ok := rawSQL(scope); ok && !readOnlyStatement.MatchString(sql) {
+			scope.Err(&ReadOnlyError{Operation: "raw"})
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:begin_transaction").Register("orm:read_only", reject("insert"))
+	callback.Update().Before("gorm:assign_updating_attributes").Register("orm:read_only", reject("update"))
+	callback.Delete().Before("gorm:begin_transaction").Register("orm:read_only", reject("delete"))
+	callback.Query().Before("gorm:query").Register("orm:read_only", rejectRaw)
+	callback.RowQuery().Before("gorm:row_query").Register("orm:read_only", rejectRaw)
+}
+
+// SQL of the raw query of scope, before gorm builds it
+func rawSQL(scope *gorm.Scope) (string, bool) {
+	if scope.SQL != "" {
+		return scope.SQL, true
+	}
+	if !isRaw(scope) {
+		return "", false
+	}
+	vars := scope.SQLVars
+	defer func() { scope.SQLVars = vars }()
+	return scope.CombinedConditionSql(), true
+}
+
+// Whether scope runs a raw query.
+// gorm keeps it unexported, it is read through reflection.
+func isRaw(scope *gorm.Scope) bool {
+	return scope.Search != nil && reflect.ValueOf(scope.Search).Elem().FieldByName("raw").Bool()
+}
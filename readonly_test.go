@@ -0,0 +1,49 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDB_ReadOnly(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.Create(&transactionDB{ID: 1, Name: "a"}).Error)
+
+	readOnly := db.ReadOnly()
+	var rows []transactionDB
+	assert.NoError(t, readOnly.Find(&rows).Error)
+	assert.Len(t, rows, 1)
+	var count int
+	assert.NoError(t, readOnly.Raw("SELECT count(*) FROM transaction_db").Row().Scan(&count))
+	assert.Equal(t, 1, count)
+
+	for name, err := range map[string]error{
+		"insert":   readOnly.Create(&transactionDB{ID: 2, Name: "b"}).Error,
+		"update":   readOnly.Model(&rows[0]).Update("name", "b").Error,
+		"delete":   readOnly.Delete(&rows[0]).Error,
+		"exec":     readOnly.Exec("DELETE FROM transaction_db").Error,
+		"raw scan": readOnly.Raw("DELETE FROM transaction_db").Scan(&rows).Error,
+		"bulk":     readOnly.BulkCreate([]*transactionDB{{ID: 3, Name: "c"}}),
+		"primary":  readOnly.Primary().Create(&transactionDB{ID: 2, Name: "b"}).Error,
+		"chained":  readOnly.Where("id = ?", 1).Delete(&transactionDB{}).Error,
+	} {
+		assert.True(t, IsReadOnly(err), name)
+	}
+	assert.NoError(t, readOnly.Exec("SELECT 1").Error)
+
+	tx := readOnly.Begin()
+	assert.True(t, IsReadOnly(tx.Create(&transactionDB{ID: 2, Name: "b"}).Error))
+	assert.True(t, IsReadOnly(tx.BulkCreate([]*transactionDB{{ID: 3, Name: "c"}})))
+	assert.True(t, IsReadOnly(tx.Exec("DELETE FROM transaction_db").Error))
+	assert.NoError(t, tx.Exec("SELECT 1").Error)
+	tx.End()
+
+	assert.NoError(t, db.Find(&rows).Error)
+	assert.Equal(t, []transactionDB{{ID: 1, Name: "a"}}, rows)
+	assert.NoError(t, db.Model(&rows[0]).Update("name", "b").Error)
+}
@@ -0,0 +1,268 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+var modelRegistry struct {
+	sync.RWMutex
+	models []interface{}
+	types  map[reflect.Type]bool
+}
+
+// RegisterModel adds models, pointers to structs, to the models migrated by AutoMigrateAll and checked by VerifySchema.
+// Registering a type twice keeps the first registration.
+func RegisterModel(models ...interface{}) {
+	modelRegistry.Lock()
+	defer modelRegistry.Unlock()
+	if modelRegistry.types == nil {
+		modelRegistry.types = make(map[reflect.Type]bool)
+	}
+	for _, model := range models {
+		typ := modelType(reflect.TypeOf(model))
+		if modelRegistry.types[typ] {
+			continue
+		}
+		modelRegistry.types[typ] = true
+		modelRegistry.models = append(modelRegistry.models, model)
+	}
+}
+
+// Struct type of the records of typ, a struct, a slice of them or pointers to them
+func modelType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// Registered models, the ones others refer to first, registration order being kept otherwise and within cycles
+func registeredModels(db *gorm.DB) []interface{} {
+	modelRegistry.RLock()
+	models := append([]interface{}(nil), modelRegistry.models...)
+	modelRegistry.RUnlock()
+
+	index := make(map[reflect.Type]int, len(models))
+	for i, model := range models {
+		index[modelType(reflect.TypeOf(model))] = i
+	}
+	// dependencies[i] are the models i refers to
+	dependencies := make([][]int, len(models))
+	for i, model := range models {
+		for _, field := range db.NewScope(model).GetModelStruct().StructFields {
+			if field.Relationship == nil {
+				continue
+			}
+			other, ok := index[modelType(field.Struct.Type)]
+			if !ok || other == i {
+				continue
+			}
+			switch field.Relationship.Kind {
+			case "belongs_to":
+				dependencies[i] = append(dependencies[i], other)
+			case "has_one", "has_many":
+				dependencies[other] = append(dependencies[other], i)
+			}
+		}
+	}
+
+	ordered := make([]interface{}, 0, len(models))
+	state := make([]int, len(models)) // 0 not visited, 1 in progress, 2 done
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+		state[i] = 1
+		sort.Ints(dependencies[i])
+		for _, dependency := range dependencies[i] {
+			visit(dependency)
+		}
+		state[i] = 2
+		ordered = append(ordered, models[i])
+	}
+	for i := range models {
+		visit(i)
+	}
+	return ordered
+}
+
+// AutoMigrateAll migrates the registered models, see RegisterModel, those referred to by others first,
+// and enforces their enums, see MigrateEnums
+func (db *DB) AutoMigrateAll() error {
+	models := registeredModels(db.DB)
+	for _, model := range models {
+		if err := db.AutoMigrate(model).Error; err != nil {
+			return fmt.Errorf("orm: migrating %s: %v", db.NewScope(model).TableName(), err)
+		}
+	}
+	return db.MigrateEnums(models...)
+}
+
+// Kinds of SchemaDrift
+const (
+	MissingTable  = "missing table"
+	MissingColumn = "missing column"
+	ExtraColumn   = "extra column"
+	MissingIndex  = "missing index"
+	TypeChange    = "type change"
+)
+
+// SchemaDrift is a difference between a registered model and its table
+type SchemaDrift struct {
+	Kind   string
+	Table  string
+	Name   string // Column or index, empty for a missing table
+	Detail string // Column types of a type change, like "text -> bigint"
+}
+
+func (d SchemaDrift) String() string {
+	s := d.Kind + " " + d.Table
+	if d.Name != "" {
+		s += "." + d.Name
+	}
+	if d.Detail != "" {
+		s += " (" + d.Detail + ")"
+	}
+	return s
+}
+
+// VerifySchema compares the registered models, see RegisterModel, with their tables and reports the differences:
+// missing tables, columns and indexes, columns no field maps to and columns whose type changed. Nothing is modified.
+// Types are compared by family, integer, text, time and so on, as drivers name them differently.
+func (db *DB) VerifySchema() ([]SchemaDrift, error) {
+	return db.schemaDrifts(registeredModels(db.DB))
+}
+
+// Differences between models and their tables
+func (db *DB) schemaDrifts(models []interface{}) ([]SchemaDrift, error) {
+	var drifts []SchemaDrift
+	for _, model := range models {
+		scope := db.NewScope(model)
+		table := scope.TableName()
+		if !scope.Dialect().HasTable(table) {
+			drifts = append(drifts, SchemaDrift{Kind: MissingTable, Table: table})
+			continue
+		}
+
+		rows, err := db.DB.Table(table).Limit(0).Rows()
+		if err != nil {
+			return nil, err
+		}
+		columns, err := rows.ColumnTypes()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		existing := make(map[string]*sql.ColumnType, len(columns))
+		for _, column := range columns {
+			existing[strings.ToLower(column.Name())] = column
+		}
+
+		dialect := scope.Dialect().GetName()
+		mapped := make(map[string]bool)
+		for _, field := range scope.GetModelStruct().StructFields {
+			if field.IsIgnored || !field.IsNormal {
+				continue
+			}
+			mapped[strings.ToLower(field.DBName)] = true
+			column, ok := existing[strings.ToLower(field.DBName)]
+			if !ok {
+				drifts = append(drifts, SchemaDrift{Kind: MissingColumn, Table: table, Name: field.DBName})
+				continue
+			}
+			declared := scope.Dialect().DataTypeOf(field)
+			if actual := column.DatabaseTypeName(); actual != "" && typeFamily(dialect, declared) != typeFamily(dialect, actual) {
+				drifts = append(drifts, SchemaDrift{Kind: TypeChange, Table: table, Name: field.DBName,
+					Detail: strings.ToLower(actual) + " -> " + strings.ToLower(strings.Fields(declared)[0])})
+			}
+		}
+		for _, column := range columns {
+			if !mapped[strings.ToLower(column.Name())] {
+				drifts = append(drifts, SchemaDrift{Kind: ExtraColumn, Table: table, Name: column.Name()})
+			}
+		}
+		for _, index := range modelIndexes(scope) {
+			if !scope.Dialect().HasIndex(table, index) {
+				drifts = append(drifts, SchemaDrift{Kind: MissingIndex, Table: table, Name: index})
+			}
+		}
+	}
+	return drifts, nil
+}
+
+// Families of column types, the names drivers and dialects give them mapped to what they store
+var typeFamilies = map[string]string{
+	"int": "integer", "integer": "integer", "int2": "integer", "int4": "integer", "int8": "integer",
+	"smallint": "integer", "mediumint": "integer", "bigint": "integer", "tinyint": "integer",
+	"serial": "integer", "bigserial": "integer", "smallserial": "integer",
+	"uint8": "integer", "uint16": "integer", "uint32": "integer", "uint64": "integer",
+	"int16": "integer", "int32": "integer", "int64": "integer",
+	"varchar": "text", "character": "text", "char": "text", "text": "text", "nvarchar": "text", "nchar": "text",
+	"ntext": "text", "bpchar": "text", "string": "text", "tinytext": "text", "mediumtext": "text", "longtext": "text",
+	"bool": "bool", "boolean": "bool", "bit": "bool",
+	"real": "float", "float": "float", "float4": "float", "float8": "float", "double": "float",
+	"float32": "float", "float64": "float",
+	"numeric": "decimal", "decimal": "decimal", "money": "decimal",
+	"timestamp": "time", "timestamptz": "time", "datetime": "time", "datetime2": "time", "datetimeoffset": "time",
+	"date": "date",
+	"blob": "bytes", "bytea": "bytes", "binary": "bytes", "varbinary": "bytes", "longblob": "bytes",
+	"mediumblob": "bytes", "tinyblob": "bytes",
+	"json": "json", "jsonb": "json",
+}
+
+// Family of the column type typ, its lowercased first word when unknown
+func typeFamily(dialect, typ string) string {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	switch {
+	case strings.HasPrefix(typ, "_"), strings.HasSuffix(typ, "[]"):
+		return "array"
+	case strings.HasPrefix(typ, "nullable("):
+		return typeFamily(dialect, strings.TrimSuffix(strings.TrimPrefix(typ, "nullable("), ")"))
+	}
+	if i := strings.IndexAny(typ, " ("); i >= 0 {
+		typ = typ[:i]
+	}
+	family, ok := typeFamilies[typ]
+	if !ok {
+		return typ
+	}
+	// Booleans are tinyint(1) on mysql
+	if family == "bool" && dialect == "mysql" {
+		return "integer"
+	}
+	return family
+}
+
+// Names of the indexes AutoMigrate creates for the model of scope, named as gorm does
+func modelIndexes(scope *gorm.Scope) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, field := range scope.GetStructFields() {
+		for setting, prefix := range map[string]string{"INDEX": "idx", "UNIQUE_INDEX": "uix"} {
+			value, ok := field.TagSettingsGet(setting)
+			if !ok {
+				continue
+			}
+			for _, name := range strings.Split(value, ",") {
+				if name == setting || name == "" {
+					name = scope.Dialect().BuildKeyName(prefix, scope.TableName(), field.DBName)
+				}
+				name, _ = scope.Dialect().NormalizeIndexAndColumn(name, field.DBName)
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
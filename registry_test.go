@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type registryUser struct {
+	ID     int
+	Email  string          `gorm:"unique_index"`
+	Orders []registryOrder `gorm:"foreignkey:UserID"`
+}
+
+type registryOrder struct {
+	ID     int
+	UserID int
+	User   registryUser
+	Items  []registryItem `gorm:"foreignkey:OrderID"`
+}
+
+type registryItem struct {
+	ID      int
+	OrderID int `gorm:"index"`
+}
+
+func TestDB_AutoMigrateAll(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	defer func() { modelRegistry.models, modelRegistry.types = nil, nil }()
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	RegisterModel(&registryItem{}, &registryOrder{})
+	RegisterModel(&registryUser{}, &registryOrder{})
+	var tables []string
+	for _, model := range registeredModels(db.DB) {
+		tables = append(tables, db.NewScope(model).TableName())
+	}
+	assert.Equal(t, []string{"registry_user", "registry_order", "registry_item"}, tables)
+
+	drifts, err := db.VerifySchema()
+	assert.NoError(t, err)
+	assert.Len(t, drifts, 3)
+	assert.Equal(t, SchemaDrift{Kind: MissingTable, Table: "registry_user"}, drifts[0])
+
+	assert.NoError(t, db.AutoMigrateAll())
+	drifts, err = db.VerifySchema()
+	assert.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	assert.NoError(t, db.Exec("ALTER TABLE registry_user ADD COLUMN legacy text").Error)
+	assert.NoError(t, db.Exec("DROP INDEX idx_registry_item_order_id").Error)
+	assert.NoError(t, db.Exec("ALTER TABLE registry_order DROP COLUMN user_id").Error)
+	drifts, err = db.VerifySchema()
+	assert.NoError(t, err)
+	var reported []string
+	for _, drift := range drifts {
+		reported = append(reported, drift.String())
+	}
+	assert.Equal(t, []string{
+		"extra column registry_user.legacy",
+		"missing column registry_order.user_id",
+		"missing index registry_item.idx_registry_item_order_id",
+	}, reported)
+}
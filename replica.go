@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"sync/atomic"
+)
+
+// Statements that only read, locking reads are sent to the primary
+var (
+	readStatement    = regexp.MustCompile(`(?i)^\s*SELECT\b`)
+	lockingStatement = regexp.MustCompile(`(?i)\bFOR\s+(UPDATE|SHARE|NO\s+KEY\s+UPDATE|KEY\s+SHARE)\b|\bLOCK\s+IN\s+SHARE\s+MODE\b`)
+)
+
+func isRead(query string) bool {
+	return readStatement.MatchString(query) && !lockingStatement.MatchString(query)
+}
+
+// resolver is the connection gorm uses when replicas are configured.
+// Reads go to the replicas in turn, everything else including transactions goes to the primary.
+type resolver struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+	next     uint32
+}
+
+func (r *resolver) nextReplica() int {
+	return int(atomic.AddUint32(&r.next, 1) % uint32(len(r.replicas)))
+}
+
+func (r *resolver) route(query string) *sql.DB {
+	if isRead(query) {
+		return r.replicas[r.nextReplica()]
+	}
+	return r.primary
+}
+
+func (r *resolver) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.Exec(query, args...)
+}
+
+func (r *resolver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+func (r *resolver) Prepare(query string) (*sql.Stmt, error) {
+	return r.primary.Prepare(query)
+}
+
+func (r *resolver) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.primary.PrepareContext(ctx, query)
+}
+
+func (r *resolver) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return r.route(query).Query(query, args...)
+}
+
+func (r *resolver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.route(query).QueryContext(ctx, query, args...)
+}
+
+func (r *resolver) QueryRow(query string, args ...interface{}) *sql.Row {
+	return r.route(query).QueryRow(query, args...)
+}
+
+func (r *resolver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.route(query).QueryRowContext(ctx, query, args...)
+}
+
+func (r *resolver) Begin() (*sql.Tx, error) {
+	return r.primary.Begin()
+}
+
+func (r *resolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// Close closes the primary and every replica
+func (r *resolver) Close() error {
+	err := r.primary.Close()
+	for _, replica := range r.replicas {
+		if replicaErr := replica.Close(); err == nil {
+			err = replicaErr
+		}
+	}
+	return err
+}
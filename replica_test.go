@@ -0,0 +1,57 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type replicaDB struct {
+	ID   int
+	Name string
+}
+
+func TestInstantiate_replicas(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+	Instantiate("sqlite3://"+primary, ReplicasOpt("sqlite3://"+replica), NamingStrategyOpt(&gorm.NamingStrategy{}))
+	defer Singleton.Close(context.Background())
+
+	assert.NoError(t, Singleton.Primary().AutoMigrate(&replicaDB{}).Error)
+	assert.NoError(t, Singleton.Replica().AutoMigrate(&replicaDB{}).Error)
+	assert.NoError(t, Singleton.Replica().Create(&replicaDB{Name: "stale"}).Error)
+
+	// Writes reach the primary, reads are served by the replica
+	assert.NoError(t, Singleton.Create(&replicaDB{Name: "fresh"}).Error)
+	var stored []replicaDB
+	assert.NoError(t, Singleton.Find(&stored).Error)
+	assert.Equal(t, []replicaDB{{ID: 1, Name: "stale"}}, stored)
+
+	assert.NoError(t, Singleton.Primary().Find(&stored).Error)
+	assert.Equal(t, []replicaDB{{ID: 1, Name: "fresh"}}, stored)
+
+	// Transactions stay on the primary
+	tx := Singleton.Begin()
+	defer tx.End()
+	assert.NoError(t, tx.Find(&stored).Error)
+	assert.Equal(t, []replicaDB{{ID: 1, Name: "fresh"}}, stored)
+}
+
+func Test_isRead(t *testing.T) {
+	for query, read := range map[string]bool{
+		"SELECT * FROM users":                           true,
+		"  select count(*) from users":                  true,
+		"SELECT * FROM users FOR UPDATE":                false,
+		"SELECT * FROM users LOCK IN SHARE MODE":        false,
+		"INSERT INTO users (name) VALUES (?)":           false,
+		"INSERT INTO users DEFAULT VALUES RETURNING id": false,
+	} {
+		assert.Equal(t, read, isRead(query), query)
+	}
+}
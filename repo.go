@@ -0,0 +1,99 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+// Repository gives typed access to the records of the model T, see Repo
+type Repository[T any] struct {
+	db *DB
+}
+
+// Page selects a page of List, see CursorPaginate
+type Page struct {
+	After string // Cursor returned with the previous page, empty for the first one
+	Limit int
+	Opts  []CursorOpt
+}
+
+// Repo returns the repository of the model T on db.
+// Its methods run in the transaction their context carries, see ContextWithTX, and on db otherwise.
+//
+//	users := orm.Repo[User](db)
+//	user, err := users.Get(ctx, id)
+func Repo[T any](db *DB) *Repository[T] {
+	return &Repository[T]{db: db}
+}
+
+// WithTX returns the repository running its methods in tx, whatever their context carries
+func (r *Repository[T]) WithTX(tx *TX) *Repository[T] {
+	return &Repository[T]{db: tx.asDB()}
+}
+
+// DB to run the statements of ctx with
+func (r *Repository[T]) with(ctx context.Context) *DB {
+	if r.db.tx == nil {
+		if tx := TXFromContext(ctx); tx != nil {
+			return tx.WithContext(ctx).asDB()
+		}
+	}
+	return r.db.WithContext(ctx)
+}
+
+// Get finds the record of primary key id, failing with gorm.ErrRecordNotFound when there is none
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	db := r.with(ctx)
+	record := new(T)
+	scope := db.NewScope(record)
+	primary := scope.PrimaryField()
+	if primary == nil {
+		return nil, fmt.Errorf("orm: getting %s needs a primary key", scope.TableName())
+	}
+	if err := db.Where(scope.Quote(primary.DBName)+" = ?", id).First(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// List finds the page of the records matching filter, a filter struct as taken by WhereFilter or nil for every record.
+// It returns the cursor of the next page along with the records, empty after the last page.
+func (r *Repository[T]) List(ctx context.Context, filter interface{}, page Page) ([]T, string, error) {
+	db := r.with(ctx)
+	if filter != nil {
+		db = db.WhereFilter(filter)
+	}
+	var records []T
+	next, err := db.CursorPaginate(db.DB, page.After, page.Limit, &records, page.Opts...)
+	if err != nil {
+		return nil, "", err
+	}
+	return records, next, nil
+}
+
+// Create inserts record
+func (r *Repository[T]) Create(ctx context.Context, record *T) error {
+	return r.with(ctx).Create(record).Error
+}
+
+// Update saves every field of record
+func (r *Repository[T]) Update(ctx context.Context, record *T) error {
+	return r.with(ctx).Save(record).Error
+}
+
+// Delete deletes record, whose primary key is set, soft deleting it when T has a DeletedAt field
+func (r *Repository[T]) Delete(ctx context.Context, record *T) error {
+	db := r.with(ctx)
+	if db.NewScope(record).PrimaryKeyZero() {
+		return errors.New("orm: deleting a record needs its primary key")
+	}
+	return db.Delete(record).Error
+}
+
+// BulkCreate inserts records, see DB.BulkCreate
+func (r *Repository[T]) BulkCreate(ctx context.Context, records []T, opts ...bulk_insert.BuilderOpt) error {
+	return r.with(ctx).BulkCreate(records, opts...)
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepo(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&filterDB{}).Error)
+	ctx := context.Background()
+	repo := Repo[filterDB](db)
+
+	assert.NoError(t, repo.Create(ctx, &filterDB{ID: 1, Name: "alice", Age: 30, Status: "active"}))
+	assert.NoError(t, repo.BulkCreate(ctx, []filterDB{
+		{ID: 2, Name: "bob", Age: 40, Status: "active"},
+		{ID: 3, Name: "carol", Age: 50, Status: "banned"},
+	}))
+
+	user, err := repo.Get(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", user.Name)
+	_, err = repo.Get(ctx, 4)
+	assert.True(t, IsRecordNotFound(err))
+
+	user.Age = 41
+	assert.NoError(t, repo.Update(ctx, user))
+	user, err = repo.Get(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 41, user.Age)
+
+	page, next, err := repo.List(ctx, userFilter{Status: []string{"active"}}, Page{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, page, 1)
+	assert.Equal(t, 1, page[0].ID)
+	page, next, err = repo.List(ctx, userFilter{Status: []string{"active"}}, Page{After: next, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page[0].ID)
+	assert.Empty(t, next)
+	page, _, err = repo.List(ctx, nil, Page{Limit: 10, Opts: []CursorOpt{DescOpt()}})
+	assert.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.Equal(t, 3, page[0].ID)
+
+	assert.Error(t, repo.Delete(ctx, &filterDB{}))
+	assert.NoError(t, db.Transaction(ctx, func(tx *TX) error {
+		assert.NoError(t, repo.Delete(ContextWithTX(ctx, tx), &filterDB{ID: 3}))
+		_, err := repo.WithTX(tx).Get(ctx, 3)
+		assert.True(t, IsRecordNotFound(err))
+		return nil
+	}))
+	_, err = repo.Get(ctx, 3)
+	assert.True(t, IsRecordNotFound(err))
+}
@@ -0,0 +1,65 @@
+package orm
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cochainio/orm/internal/dberr"
+)
+
+// RetryPolicy tells how TransactionWithRetry retries, zero fields take the defaults
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts including the first one, 3 by default
+	Backoff     time.Duration // Delay before the first retry, 10ms by default, doubled on each retry with jitter
+	MaxBackoff  time.Duration // Upper bound of the delay, 1s by default
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 10 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = time.Second
+	}
+	return p
+}
+
+// TransactionWithRetry runs fn like Transaction, and runs the whole transaction again when it fails
+// with a deadlock or serialization failure, see IsRetryable. fn must not have effects outside of tx.
+// It returns the number of attempts made along with the error of the last one.
+func (db *DB) TransactionWithRetry(ctx context.Context, policy RetryPolicy, fn func(tx *TX) error) (int, error) {
+	return db.retryTransaction(ctx, policy, IsRetryable, fn)
+}
+
+func (db *DB) retryTransaction(ctx context.Context, policy RetryPolicy, retryable func(error) bool, fn func(tx *TX) error) (int, error) {
+	policy = policy.withDefaults()
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := db.Transaction(ctx, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return attempt, err
+		}
+
+		// Wait between half and all of the backoff, so that conflicting transactions do not retry in lockstep
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return attempt, err
+		}
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+// IsRetryable checks whether err, or an error it wraps, aborted a transaction that may succeed when run again:
+// MySQL deadlocks and lock wait timeouts, Postgres serialization failures and deadlocks, MSSQL deadlock victims
+// and a locked SQLite database
+func IsRetryable(err error) bool {
+	return dberr.IsRetryable(err)
+}
@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, IsRetryable(&mysql.MySQLError{Number: 1205}))
+	assert.True(t, IsRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, IsRetryable(gorm.Errors{errors.New("failure"), &pq.Error{Code: "40P01"}}))
+	assert.False(t, IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, IsRetryable(errors.New("failure")))
+	assert.True(t, IsRetryable(fmt.Errorf("transfer: %w", &pq.Error{Code: "40001"})))
+}
+
+func TestDB_TransactionWithRetry(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	deadlock := &mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}
+	policy := RetryPolicy{MaxAttempts: 4, Backoff: time.Millisecond}
+
+	calls := 0
+	attempts, err := db.TransactionWithRetry(context.Background(), policy, func(tx *TX) error {
+		calls++
+		if err := tx.Create(&transactionDB{Name: "retried"}).Error; err != nil {
+			return err
+		}
+		if calls < 3 {
+			return deadlock
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	var count int
+	assert.NoError(t, db.Model(&transactionDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+
+	attempts, err = db.TransactionWithRetry(context.Background(), policy, func(tx *TX) error {
+		return deadlock
+	})
+	assert.Equal(t, deadlock, err)
+	assert.Equal(t, 4, attempts)
+
+	failure := errors.New("failure")
+	attempts, err = db.TransactionWithRetry(context.Background(), policy, func(tx *TX) error {
+		return failure
+	})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 1, attempts)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts, err = db.TransactionWithRetry(canceled, policy, func(tx *TX) error {
+		return deadlock
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -0,0 +1,73 @@
+package orm
+
+import (
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Begin starts a transaction nested in tx at a savepoint, so that transactional code composes:
+// its Commit releases the savepoint and its Rollback undoes only what it did, tx goes on either way.
+// Nothing is committed until tx is.
+func (tx *TX) Begin() *TX {
+	nested := &TX{
+		DB:         tx.DB,
+		txState:    &txState{stats: tx.stats, lifetime: tx.lifetime},
+		instrument: tx.instrument,
+		ctx:        tx.ctx,
+		depth:      tx.depth + 1,
+		savepoint:  "orm_savepoint_" + strconv.Itoa(tx.depth+1),
+	}
+	if err := tx.createSavepoint(nested.savepoint).Error; err != nil {
+		nested.DB = tx.DB.New()
+		nested.DB.AddError(err)
+		nested.ended = true
+	}
+	return nested
+}
+
+// SavePoint marks the current state of tx under name, to roll back to it later on
+func (tx *TX) SavePoint(name string) error {
+	return tx.createSavepoint(name).Error
+}
+
+// RollbackTo undoes what tx did since the savepoint name, which is kept to roll back to it again
+func (tx *TX) RollbackTo(name string) error {
+	return tx.rollbackToSavepoint(name).Error
+}
+
+// ReleaseSavePoint forgets the savepoint name, keeping what tx did since.
+// mssql has no such statement, its savepoints last until the end of the transaction.
+func (tx *TX) ReleaseSavePoint(name string) error {
+	return tx.releaseSavepoint(name).Error
+}
+
+func (tx *TX) createSavepoint(name string) *gorm.DB {
+	if tx.Dialect().GetName() == "mssql" {
+		return tx.DB.Exec("SAVE TRANSACTION " + tx.Dialect().Quote(name))
+	}
+	return tx.DB.Exec("SAVEPOINT " + tx.Dialect().Quote(name))
+}
+
+func (tx *TX) releaseSavepoint(name string) *gorm.DB {
+	if tx.Dialect().GetName() == "mssql" {
+		return tx.DB.New()
+	}
+	return tx.DB.Exec("RELEASE SAVEPOINT " + tx.Dialect().Quote(name))
+}
+
+func (tx *TX) rollbackToSavepoint(name string) *gorm.DB {
+	if tx.Dialect().GetName() == "mssql" {
+		return tx.DB.Exec("ROLLBACK TRANSACTION " + tx.Dialect().Quote(name))
+	}
+	return tx.DB.Exec("ROLLBACK TO SAVEPOINT " + tx.Dialect().Quote(name))
+}
+
+// Undo what the nested transaction tx did and drop its savepoint
+func (tx *TX) rollbackNested() *gorm.DB {
+	rolledBack := tx.rollbackToSavepoint(tx.savepoint)
+	if rolledBack.Error != nil {
+		return rolledBack
+	}
+	return tx.releaseSavepoint(tx.savepoint)
+}
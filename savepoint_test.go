@@ -0,0 +1,80 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTX_Begin(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	names := func(conn *gorm.DB) (names []string) {
+		assert.NoError(t, conn.Model(&transactionDB{}).Order("id").Pluck("name", &names).Error)
+		return
+	}
+
+	tx := db.Begin()
+	defer tx.End()
+	assert.NoError(t, tx.Create(&transactionDB{Name: "outer"}).Error)
+
+	// A nested rollback undoes the nested work only
+	nested := tx.Begin()
+	assert.NoError(t, nested.Error)
+	assert.NoError(t, nested.Create(&transactionDB{Name: "rolled back"}).Error)
+	deeper := nested.Begin()
+	assert.NoError(t, deeper.Create(&transactionDB{Name: "rolled back too"}).Error)
+	assert.NoError(t, deeper.Commit(true))
+	assert.NoError(t, nested.Rollback().Error)
+	assert.Equal(t, []string{"outer"}, names(tx.DB))
+
+	// A nested commit leaves its work to the outer transaction
+	nested = tx.Begin()
+	assert.NoError(t, nested.Create(&transactionDB{Name: "nested"}).Error)
+	assert.NoError(t, nested.Commit(true))
+	nested.End()
+	assert.Equal(t, []string{"outer", "nested"}, names(tx.DB))
+	assert.Empty(t, names(db.DB))
+
+	assert.NoError(t, tx.Commit(true))
+	assert.Equal(t, []string{"outer", "nested"}, names(db.DB))
+
+	// A nested transaction ended without commit is rolled back
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		nested := tx.Begin()
+		defer nested.End()
+		return nested.Create(&transactionDB{Name: "abandoned"}).Error
+	}))
+	assert.Equal(t, []string{"outer", "nested"}, names(db.DB))
+}
+
+func TestTX_SavePoint(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	// Records failing to import are skipped, the others are kept
+	tx := db.Begin()
+	defer tx.End()
+	for _, name := range []string{"a", "invalid", "b"} {
+		assert.NoError(t, tx.SavePoint("record"))
+		assert.NoError(t, tx.Create(&transactionDB{Name: name}).Error)
+		if name == "invalid" {
+			assert.NoError(t, tx.RollbackTo("record"))
+		}
+		assert.NoError(t, tx.ReleaseSavePoint("record"))
+	}
+	assert.NoError(t, tx.CommitE())
+
+	var names []string
+	assert.NoError(t, db.Model(&transactionDB{}).Order("id").Pluck("name", &names).Error)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	tx = db.Begin()
+	defer tx.End()
+	assert.Error(t, tx.RollbackTo("unknown"))
+}
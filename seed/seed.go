@@ -0,0 +1,148 @@
+// Package seed runs named seed functions once per database, recording them in a schema_seeds table,
+// for reference data and the fixtures of development environments.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Seed inserts data, run in a transaction once per database unless forced
+type Seed struct {
+	Name string
+	Run  func(ctx context.Context, tx *gorm.DB) error
+}
+
+var registered struct {
+	sync.Mutex
+	seeds []Seed
+}
+
+// Register adds seeds to the ones a Seeder runs by default, in order
+func Register(seeds ...Seed) {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.seeds = append(registered.seeds, seeds...)
+}
+
+// Record of a seed that ran
+type record struct {
+	Name  string    `gorm:"column:name;primary_key;size:255"`
+	RanAt time.Time `gorm:"column:ran_at"`
+}
+
+// Seeder runs the seeds that did not run yet on its database
+type Seeder struct {
+	db    *gorm.DB
+	table string
+	seeds []Seed
+	force map[string]bool
+	all   bool
+}
+
+type Opt func(*Seeder)
+
+// TableOpt sets the table recording the seeds that ran, schema_seeds by default
+func TableOpt(table string) Opt {
+	return func(s *Seeder) {
+		s.table = table
+	}
+}
+
+// SeedsOpt runs seeds instead of the registered ones
+func SeedsOpt(seeds ...Seed) Opt {
+	return func(s *Seeder) {
+		s.seeds = seeds
+	}
+}
+
+// ForceOpt runs the seeds named again even though they ran already, all of them when no name is given.
+// Meant for development databases, the seeds have to cope with the data they inserted before.
+func ForceOpt(names ...string) Opt {
+	return func(s *Seeder) {
+		if len(names) == 0 {
+			s.all = true
+		}
+		for _, name := range names {
+			s.force[name] = true
+		}
+	}
+}
+
+func New(db *gorm.DB, opts ...Opt) *Seeder {
+	registered.Lock()
+	s := &Seeder{
+		db:    db,
+		table: "schema_seeds",
+		seeds: append([]Seed(nil), registered.seeds...),
+		force: make(map[string]bool),
+	}
+	registered.Unlock()
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run runs the seeds in order, skipping those that ran already unless forced, each in its own transaction.
+// It returns the names of the seeds run.
+func (s *Seeder) Run(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool, len(s.seeds))
+	for _, seed := range s.seeds {
+		if seed.Name == "" || seen[seed.Name] {
+			return nil, fmt.Errorf("seed: names must be unique and not empty, got %q", seed.Name)
+		}
+		seen[seed.Name] = true
+	}
+	if err := s.db.Table(s.table).AutoMigrate(&record{}).Error; err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := s.db.Table(s.table).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	ran := make(map[string]bool, len(records))
+	for _, r := range records {
+		ran[r.Name] = true
+	}
+
+	var names []string
+	for _, seed := range s.seeds {
+		if ran[seed.Name] && !s.all && !s.force[seed.Name] {
+			continue
+		}
+		if err := s.run(ctx, seed, ran[seed.Name]); err != nil {
+			return names, fmt.Errorf("seed: %s: %v", seed.Name, err)
+		}
+		names = append(names, seed.Name)
+	}
+	return names, nil
+}
+
+// Run seed and record it in a transaction
+func (s *Seeder) run(ctx context.Context, seed Seed, ran bool) error {
+	tx := s.db.BeginTx(ctx, nil)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if err := seed.Run(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	r := record{Name: seed.Name, RanAt: time.Now()}
+	var err error
+	if ran {
+		err = tx.Table(s.table).Where("name = ?", seed.Name).Update("ran_at", r.RanAt).Error
+	} else {
+		err = tx.Table(s.table).Create(&r).Error
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
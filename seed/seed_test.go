@@ -0,0 +1,76 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	db, err := gorm.Open("sqlite3", filepath.Join(t.TempDir(), "seed.db"))
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("CREATE TABLE currencies (id integer primary key, code text, name text)").Error)
+	return db
+}
+
+func TestSeeder_Run(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	runs := 0
+	counter := Seed{Name: "counter", Run: func(ctx context.Context, tx *gorm.DB) error {
+		runs++
+		return nil
+	}}
+	currencies, err := SeedFromYAML(filepath.Join("testdata", "currencies.yaml"))
+	assert.NoError(t, err)
+
+	names, err := New(db, SeedsOpt(currencies, counter)).Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("testdata", "currencies.yaml"), "counter"}, names)
+	names, err = New(db, SeedsOpt(currencies, counter)).Run(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+	assert.Equal(t, 1, runs)
+
+	names, err = New(db, SeedsOpt(currencies, counter), ForceOpt("counter")).Run(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"counter"}, names)
+	names, err = New(db, SeedsOpt(currencies, counter), ForceOpt()).Run(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+	assert.Equal(t, 3, runs)
+
+	var codes []string
+	assert.NoError(t, db.Table("currencies").Order("id").Pluck("code", &codes).Error)
+	assert.Equal(t, []string{"EUR", "USD"}, codes)
+}
+
+func TestSeeder_Run_failure(t *testing.T) {
+	db := openSQLite(t)
+	defer db.Close()
+	ctx := context.Background()
+
+	failing := Seed{Name: "failing", Run: func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO currencies (id, code) VALUES (3, 'GBP')").Error; err != nil {
+			return err
+		}
+		return errors.New("boom")
+	}}
+	_, err := New(db, SeedsOpt(failing)).Run(ctx)
+	assert.EqualError(t, err, "seed: failing: boom")
+	var count int
+	assert.NoError(t, db.Table("currencies").Count(&count).Error)
+	assert.Zero(t, count)
+
+	_, err = New(db, SeedsOpt(failing, failing)).Run(ctx)
+	assert.Error(t, err)
+	_, err = SeedFromYAML(filepath.Join("testdata", "missing.yaml"))
+	assert.Error(t, err)
+}
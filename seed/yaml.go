@@ -0,0 +1,83 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"gopkg.in/yaml.v3"
+)
+
+// Rows of a table in a YAML seed file
+type tableRows struct {
+	Table string                   `yaml:"table"`
+	Rows  []map[string]interface{} `yaml:"rows"`
+}
+
+// SeedFromYAML reads the reference data of the file at path and returns the seed inserting it, named after path.
+// The file lists tables, filled in order, with their rows as column: value maps:
+//
+//	# currencies.yaml
+//	- table: currencies
+//	  rows:
+//	    - {id: 1, code: EUR, name: Euro}
+//	    - {id: 2, code: USD, name: US Dollar}
+//
+// A row with an id replaces the row of the same id, so that forced runs update the data.
+func SeedFromYAML(path string) (Seed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Seed{}, err
+	}
+	var tables []tableRows
+	if err := yaml.Unmarshal(data, &tables); err != nil {
+		return Seed{}, fmt.Errorf("seed: %s: %v", path, err)
+	}
+	for _, table := range tables {
+		if table.Table == "" {
+			return Seed{}, fmt.Errorf("seed: %s: a table has no name", path)
+		}
+	}
+
+	return Seed{
+		Name: path,
+		Run: func(ctx context.Context, tx *gorm.DB) error {
+			for _, table := range tables {
+				for _, row := range table.Rows {
+					if err := insertRow(tx, table.Table, row); err != nil {
+						return fmt.Errorf("%s: %v", table.Table, err)
+					}
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+// Insert row into table, replacing the row of the same id
+func insertRow(tx *gorm.DB, table string, row map[string]interface{}) error {
+	scope := tx.NewScope(nil)
+	if id, ok := row["id"]; ok {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", scope.Quote(table), scope.Quote("id")), id).Error; err != nil {
+			return err
+		}
+	}
+
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	quoted := make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		quoted[i] = scope.Quote(column)
+		values[i] = row[column]
+	}
+	statement := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", scope.Quote(table), strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	return tx.Exec(statement, values...).Error
+}
@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SetLogMode turns gorm's statement logging on or off, like LogModeOpt.
+// DBs derived from db before the call keep their log mode.
+func (db *DB) SetLogMode(enable bool) {
+	for _, conn := range db.conns() {
+		conn.LogMode(enable)
+	}
+}
+
+// SetSlowQueryThreshold changes the threshold of SlowQueryOpt, zero stops reporting slow queries
+func (db *DB) SetSlowQueryThreshold(threshold time.Duration) {
+	in := db.instrumented()
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.slowThreshold = threshold
+}
+
+// SetPool changes the pool settings of the primary and of every replica, like MaxOpenConnsOpt,
+// MaxIdleConnsOpt and ConnMaxLifetimeOpt. Connections in use are not interrupted.
+func (db *DB) SetPool(maxOpen, maxIdle int, maxLifetime time.Duration) {
+	primary, replicas := db.pools()
+	if primary == nil {
+		return
+	}
+	for _, pool := range append([]*sql.DB{primary}, replicas...) {
+		pool.SetMaxOpenConns(maxOpen)
+		pool.SetMaxIdleConns(maxIdle)
+		pool.SetConnMaxLifetime(maxLifetime)
+	}
+}
+
+// Every gorm DB making up db
+func (db *DB) conns() []*gorm.DB {
+	conns := []*gorm.DB{db.DB}
+	if db.primary != nil {
+		conns = append(conns, db.primary)
+	}
+	return append(conns, db.replicas...)
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type settingsDB struct {
+	ID   int
+	Name string
+}
+
+type printRecorder struct {
+	lines int
+}
+
+func (r *printRecorder) Println(v ...interface{}) {
+	r.lines++
+}
+
+func TestDB_settings(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	dir := t.TempDir()
+	var slow []SlowQuery
+	db, err := New("sqlite3://"+filepath.Join(dir, "primary.db"),
+		ReplicasOpt("sqlite3://"+filepath.Join(dir, "replica.db")),
+		NamingStrategyOpt(&gorm.NamingStrategy{}),
+		SlowQueryOpt(time.Hour, func(q SlowQuery) { slow = append(slow, q) }))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+
+	recorder := &printRecorder{}
+	db.SetLogger(gorm.Logger{LogWriter: recorder})
+	assert.NoError(t, db.Primary().AutoMigrate(&settingsDB{}).Error)
+	assert.NoError(t, db.Create(&settingsDB{Name: "a"}).Error)
+	assert.Equal(t, 0, recorder.lines)
+
+	db.SetLogMode(true)
+	assert.NoError(t, db.Create(&settingsDB{Name: "b"}).Error)
+	assert.NotZero(t, recorder.lines)
+	db.SetLogMode(false)
+
+	assert.Empty(t, slow)
+	db.SetSlowQueryThreshold(time.Nanosecond)
+	assert.NoError(t, db.Create(&settingsDB{Name: "c"}).Error)
+	assert.Len(t, slow, 1)
+
+	db.SetPool(7, 3, time.Minute)
+	stats := db.PoolStats()
+	for _, name := range []string{"primary", "replica0"} {
+		assert.Equal(t, 7, stats[name].MaxOpenConnections, name)
+	}
+}
@@ -0,0 +1,191 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrClosed is reported by queries and transactions started once the DB is closing
+var ErrClosed = errors.New("orm: database is closed")
+
+// lifecycle counts the queries and transactions in flight so that Close can wait for them
+type lifecycle struct {
+	mu      sync.Mutex
+	closing bool
+	active  int
+	idle    chan struct{} // Closed once active drops to zero while closing
+	hooks   []func(ctx context.Context) error
+}
+
+// Start a unit of work, false once the DB is closing
+func (l *lifecycle) acquire() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closing {
+		return false
+	}
+	l.active++
+	return true
+}
+
+func (l *lifecycle) release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active--
+	if l.active == 0 && l.idle != nil {
+		close(l.idle)
+		l.idle = nil
+	}
+}
+
+// Refuse new work and wait until the work in flight is done or ctx is
+func (l *lifecycle) drain(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	l.closing = true
+	if l.active == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	if l.idle == nil {
+		l.idle = make(chan struct{})
+	}
+	idle := l.idle
+	l.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+const lifecycleKey = "orm:lifecycle_acquired"
+
+// Start a unit of work of db which runs no callback, like bulk operations and Exec, false once the DB is closing.
+// The work of a transaction is covered by the transaction itself.
+func (db *DB) acquire() (release func(), ok bool) {
+	if inTransaction(db.DB) {
+		return func() {}, true
+	}
+	if !db.lifecycle.acquire() {
+		return nil, false
+	}
+	return db.lifecycle.release, true
+}
+
+// Register the callbacks tracking every query of db, queries of a transaction are covered by the transaction itself
+func (l *lifecycle) register(db *gorm.DB) {
+	acquire := func(scope *gorm.Scope) {
+		switch unwrapCommon(scope.SQLDB()).(type) {
+		case *sql.Tx, *xaConn:
+			return
+		}
+		if !l.acquire() {
+			scope.Err(ErrClosed)
+			return
+		}
+		scope.InstanceSet(lifecycleKey, true)
+	}
+	release := func(scope *gorm.Scope) {
+		if _, ok := scope.InstanceGet(lifecycleKey); ok {
+			l.release()
+		}
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:begin_transaction").Register("orm:acquire", acquire)
+	callback.Create().After("gorm:commit_or_rollback_transaction").Register("orm:release", release)
+	callback.Update().Before("gorm:assign_updating_attributes").Register("orm:acquire", acquire)
+	callback.Update().After("gorm:commit_or_rollback_transaction").Register("orm:release", release)
+	callback.Delete().Before("gorm:begin_transaction").Register("orm:acquire", acquire)
+	callback.Delete().After("gorm:commit_or_rollback_transaction").Register("orm:release", release)
+	callback.Query().Before("gorm:query").Register("orm:acquire", acquire)
+	callback.Query().After("gorm:after_query").Register("orm:release", release)
+	callback.RowQuery().Before("gorm:row_query").Register("orm:acquire", acquire)
+	callback.RowQuery().After("gorm:row_query").Register("orm:release", release)
+}
+
+// OnShutdown registers hook to run by Close once the pool is closed, hooks run in reverse order of registration
+func (db *DB) OnShutdown(hook func(ctx context.Context) error) {
+	if db.lifecycle == nil {
+		db.lifecycle = &lifecycle{}
+	}
+	db.lifecycle.mu.Lock()
+	defer db.lifecycle.mu.Unlock()
+	db.lifecycle.hooks = append(db.lifecycle.hooks, hook)
+}
+
+// Close stops accepting queries and transactions, waits until those in flight are done or ctx is,
+// then closes the connection pool and runs the shutdown hooks. The first error encountered is returned.
+func (db *DB) Close(ctx context.Context) error {
+	err := db.lifecycle.drain(ctx)
+	if closeErr := db.DB.Close(); err == nil {
+		err = closeErr
+	}
+	unregister(db)
+
+	if db.lifecycle != nil {
+		db.lifecycle.mu.Lock()
+		hooks := db.lifecycle.hooks
+		db.lifecycle.hooks = nil
+		db.lifecycle.mu.Unlock()
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if hookErr := hooks[i](ctx); err == nil {
+				err = hookErr
+			}
+		}
+	}
+	return err
+}
+
+// Every DB opened by this package and not closed yet
+var registry struct {
+	sync.Mutex
+	dbs []*DB
+}
+
+func register(db *DB) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.dbs = append(registry.dbs, db)
+}
+
+func unregister(db *DB) {
+	registry.Lock()
+	defer registry.Unlock()
+	for i, registered := range registry.dbs {
+		if registered == db {
+			registry.dbs = append(registry.dbs[:i], registry.dbs[i+1:]...)
+			return
+		}
+	}
+}
+
+// CloseAll closes every DB opened by this package, see DB.Close
+func CloseAll(ctx context.Context) error {
+	registry.Lock()
+	dbs := append([]*DB(nil), registry.dbs...)
+	registry.Unlock()
+
+	var err error
+	for _, db := range dbs {
+		if closeErr := db.Close(ctx); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
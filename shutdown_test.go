@@ -0,0 +1,116 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+type shutdownDB struct {
+	ID   int
+	Name string
+}
+
+func TestDB_Close(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	Instantiate("sqlite3://"+filepath.Join(t.TempDir(), "shutdown.db"), NamingStrategyOpt(&gorm.NamingStrategy{}))
+	db := Singleton
+	assert.NoError(t, db.AutoMigrate(&shutdownDB{}).Error)
+
+	var hooks []string
+	db.OnShutdown(func(ctx context.Context) error {
+		hooks = append(hooks, "first")
+		return nil
+	})
+	db.OnShutdown(func(ctx context.Context) error {
+		hooks = append(hooks, "second")
+		return nil
+	})
+
+	tx := db.Begin()
+	assert.NoError(t, tx.Create(&shutdownDB{Name: "in flight"}).Error)
+
+	closed := make(chan error, 1)
+	go func() { closed <- db.Close(context.Background()) }()
+
+	// Wait for Close to refuse new work
+	for deadline := time.Now().Add(time.Second); db.Find(&[]shutdownDB{}).Error != ErrClosed; {
+		if time.Now().After(deadline) {
+			t.Fatal("queries are still accepted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, ErrClosed, db.Begin().Error)
+	assert.Equal(t, ErrClosed, db.Exec("SELECT 1").Error)
+	assert.Equal(t, ErrClosed, db.BulkCreate([]shutdownDB{{Name: "late"}}))
+
+	// The transaction in flight is still served and lets Close proceed once committed
+	assert.NoError(t, tx.Create(&shutdownDB{Name: "still in flight"}).Error)
+	select {
+	case <-closed:
+		t.Fatal("closed before the transaction ended")
+	default:
+	}
+	assert.NoError(t, tx.Commit(true))
+	assert.NoError(t, <-closed)
+	assert.Equal(t, []string{"second", "first"}, hooks)
+}
+
+func TestCloseAll(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	Instantiate("sqlite3://"+filepath.Join(t.TempDir(), "shutdown.db"), NamingStrategyOpt(&gorm.NamingStrategy{}))
+	tx := Singleton.Begin()
+	defer tx.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, CloseAll(ctx))
+	assert.Empty(t, registry.dbs)
+}
+
+func TestDB_Close_bulk(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+
+	// The transform holds the bulk insert in flight
+	started, proceed := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	transform := func(int, map[string]interface{}) error {
+		once.Do(func() { close(started) })
+		<-proceed
+		return nil
+	}
+	inserted := make(chan error, 1)
+	go func() {
+		inserted <- db.BulkCreate([]transactionDB{{ID: 1, Name: "in flight"}}, bulk_insert.TransformOpt(transform))
+	}()
+	<-started
+
+	closed := make(chan error, 1)
+	go func() { closed <- db.Close(context.Background()) }()
+	for deadline := time.Now().Add(time.Second); db.BulkCreate([]transactionDB{{ID: 2}}) != ErrClosed; {
+		if time.Now().After(deadline) {
+			t.Fatal("bulk operations are still accepted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-closed:
+		t.Fatal("closed before the bulk insert ended")
+	default:
+	}
+	close(proceed)
+	assert.NoError(t, <-inserted)
+	assert.NoError(t, <-closed)
+}
@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"log"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlowQuery describes a statement that took longer than the slow query threshold
+type SlowQuery struct {
+	SQL      string
+	Args     []interface{} // Every argument reads "[redacted]" when redaction is enabled
+	Duration time.Duration
+	Rows     int64
+	Err      error
+	Caller   string // file:line of the code that ran the statement
+}
+
+// LogSlowQuery is the default slow query handler, writing to the standard logger
+func LogSlowQuery(q SlowQuery) {
+	log.Printf("slow query %s (%s): %s %v", q.Caller, q.Duration, q.SQL, q.Args)
+}
+
+// SlowQueryOpt reports statements taking longer than threshold to handler, LogSlowQuery when nil,
+// whatever the log mode
+func SlowQueryOpt(threshold time.Duration, handler func(SlowQuery)) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+		o.slowHandler = handler
+	}
+}
+
+// RedactArgsOpt leaves bind arguments out of slow query reports
+func RedactArgsOpt(redact bool) Option {
+	return func(o *options) {
+		o.redactArgs = redact
+	}
+}
+
+const redacted = "[redacted]"
+
+func (in *instrument) reportSlow(sql string, args []interface{}, duration time.Duration, rows int64, err error) {
+	in.mu.RLock()
+	threshold, handler, redact := in.slowThreshold, in.slowHandler, in.redactArgs
+	in.mu.RUnlock()
+
+	if threshold <= 0 || duration < threshold {
+		return
+	}
+	if handler == nil {
+		handler = LogSlowQuery
+	}
+	if redact {
+		args = redactArgs(args)
+	}
+	handler(SlowQuery{SQL: sql, Args: args, Duration: duration, Rows: rows, Err: err, Caller: caller()})
+}
+
+func redactArgs(args []interface{}) []interface{} {
+	hidden := make([]interface{}, len(args))
+	for i := range hidden {
+		hidden[i] = redacted
+	}
+	return hidden
+}
+
+// Directory of this package, its frames and gorm's are skipped when looking for the caller
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// Location of the first frame outside gorm, database/sql and this module, tests excepted
+func caller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		internal := strings.Contains(frame.File, "github.com/jinzhu/gorm") ||
+			strings.HasPrefix(frame.Function, "runtime.") ||
+			strings.HasPrefix(frame.Function, "database/sql.") ||
+			strings.HasPrefix(frame.File, packageDir+"/") && !strings.HasSuffix(frame.File, "_test.go")
+		if !internal {
+			return frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowQueryOpt(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	var slow []SlowQuery
+	Instantiate("sqlite3://"+filepath.Join(t.TempDir(), "slow.db"),
+		NamingStrategyOpt(&gorm.NamingStrategy{}),
+		SlowQueryOpt(time.Nanosecond, func(q SlowQuery) { slow = append(slow, q) }),
+		RedactArgsOpt(true),
+	)
+	defer Singleton.Close(context.Background())
+
+	assert.NoError(t, Singleton.AutoMigrate(&instrumentDB{}).Error)
+	assert.NoError(t, Singleton.Create(&instrumentDB{Name: "secret"}).Error)
+
+	assert.Len(t, slow, 1)
+	assert.Contains(t, slow[0].SQL, "INSERT")
+	assert.Equal(t, []interface{}{redacted}, slow[0].Args)
+	assert.Contains(t, slow[0].Caller, "slow_query_test.go:")
+}
+
+func Test_instrument_reportSlow(t *testing.T) {
+	var slow []SlowQuery
+	in := &instrument{slowThreshold: time.Second, slowHandler: func(q SlowQuery) { slow = append(slow, q) }}
+
+	in.reportSlow("SELECT 1", nil, time.Millisecond, 1, nil)
+	assert.Empty(t, slow)
+
+	in.reportSlow("SELECT 2", []interface{}{1}, 2*time.Second, 1, nil)
+	assert.Len(t, slow, 1)
+	assert.Equal(t, []interface{}{1}, slow[0].Args)
+	assert.Equal(t, 2*time.Second, slow[0].Duration)
+}
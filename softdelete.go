@@ -0,0 +1,93 @@
+package orm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SoftDeleteModel makes Delete set DeletedAt instead of removing the row, and queries skip the rows it is set on.
+// Unscoped reaches them, and deletes for good.
+type SoftDeleteModel struct {
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+// ArchiveDeletesOpt copies the rows removed by a delete into the archive table of their table when there is one,
+// see MigrateArchive.
+// The copy runs in the transaction of the delete. Soft deletes keep the row, so they are not archived.
+func ArchiveDeletesOpt() Option {
+	return func(o *options) {
+		o.archiveDeletes = true
+	}
+}
+
+// Unscoped returns db reaching the rows soft deleted, whose Delete removes rows for good
+func (db *DB) Unscoped() *DB {
+	derived := *db
+	derived.DB = db.DB.Unscoped()
+	return &derived
+}
+
+// Name of the archive table of table
+func archiveTable(table string) string {
+	return table + "_deleted"
+}
+
+// Register the callback copying the rows a delete removes into their archive table
+func registerArchiveDeletes(db *gorm.DB) {
+	db.Callback().Delete().Before("gorm:delete").Register("orm:archive_deletes", func(scope *gorm.Scope) {
+		if scope.HasError() || strings.HasSuffix(scope.TableName(), "deleted") {
+			return
+		}
+		if _, archived := scope.Get(archivedKey); archived {
+			return
+		}
+		if _, soft := scope.FieldByName("DeletedAt"); soft && !scope.Search.Unscoped {
+			return
+		}
+		if !scope.Dialect().HasTable(archiveTable(scope.TableName())) {
+			return
+		}
+		if _, err := archiveRows(scope); err != nil {
+			scope.Err(err)
+		}
+	})
+}
+
+// Copy the rows matching the conditions of scope into the archive table, with At set to now,
+// and report how many were copied
+func archiveRows(scope *gorm.Scope) (int64, error) {
+	columns := archiveColumns(scope)
+
+	// The statement of scope binds its own variables, those of the copy are kept apart
+	vars := scope.SQLVars
+	scope.SQLVars = nil
+	at := scope.AddToVars(gorm.NowFunc())
+	conditions := scope.CombinedConditionSql()
+	args := scope.SQLVars
+	scope.SQLVars = vars
+
+	list := strings.Join(columns, ", ")
+	statement := fmt.Sprintf("INSERT INTO %s (%s, %s) SELECT %s, %s FROM %s %s",
+		scope.Quote(archiveTable(scope.TableName())), list, scope.Quote(gorm.ToColumnName("At")),
+		list, at, scope.QuotedTableName(), conditions)
+	statement = strings.Replace(statement, "$$$", "?", -1) // Bind variables of the dialects without numbered ones
+	result, err := scope.SQLDB().Exec(statement, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Quoted columns of the model of scope, which its archive table holds as well
+func archiveColumns(scope *gorm.Scope) []string {
+	var columns []string
+	for _, field := range scope.Fields() {
+		if field.IsNormal && !field.IsIgnored {
+			columns = append(columns, scope.Quote(field.DBName))
+		}
+	}
+	return columns
+}
@@ -0,0 +1,61 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type softDeleteDB struct {
+	ID   int
+	Name string
+	SoftDeleteModel
+}
+
+type softDeleteDBDeleted struct {
+	ID        int
+	Name      string
+	DeletedAt *time.Time
+	At        time.Time
+}
+
+func (softDeleteDBDeleted) TableName() string {
+	return "soft_delete_db_deleted"
+}
+
+func TestSoftDeleteModel(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "soft_delete.db"), NamingStrategyOpt(&gorm.NamingStrategy{}), ArchiveDeletesOpt())
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&softDeleteDB{}, &softDeleteDBDeleted{}).Error)
+
+	assert.NoError(t, db.Create(&softDeleteDB{ID: 1, Name: "a"}).Error)
+	assert.NoError(t, db.Create(&softDeleteDB{ID: 2, Name: "b"}).Error)
+	assert.NoError(t, db.Delete(&softDeleteDB{ID: 1}).Error)
+
+	var rows []softDeleteDB
+	assert.NoError(t, db.Find(&rows).Error)
+	assert.Len(t, rows, 1)
+	assert.NoError(t, db.Unscoped().Order("id").Find(&rows).Error)
+	assert.Len(t, rows, 2)
+	assert.NotNil(t, rows[0].DeletedAt)
+
+	var archived []softDeleteDBDeleted
+	assert.NoError(t, db.Unscoped().Find(&archived).Error)
+	assert.Empty(t, archived)
+
+	assert.NoError(t, db.Unscoped().Delete(&softDeleteDB{ID: 1}).Error)
+	assert.NoError(t, db.Unscoped().Find(&rows).Error)
+	assert.Len(t, rows, 1)
+	assert.NoError(t, db.Unscoped().Find(&archived).Error)
+	if assert.Len(t, archived, 1) {
+		assert.Equal(t, "a", archived[0].Name)
+		assert.NotNil(t, archived[0].DeletedAt)
+		assert.False(t, archived[0].At.IsZero())
+	}
+}
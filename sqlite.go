@@ -0,0 +1,87 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Pragma run on every new sqlite connection
+type pragma struct {
+	name  string
+	value string
+}
+
+// SQLitePragmaOpt runs PRAGMA name = value on every connection to a sqlite3 database, ignored for other dialects
+func SQLitePragmaOpt(name, value string) Option {
+	return func(o *options) {
+		o.pragmas = append(o.pragmas, pragma{name: name, value: value})
+	}
+}
+
+// SQLiteWALOpt switches sqlite3 databases to write-ahead logging, so that reads no longer wait for writes
+func SQLiteWALOpt() Option {
+	return SQLitePragmaOpt("journal_mode", "WAL")
+}
+
+// SQLiteBusyTimeoutOpt makes sqlite3 statements wait up to d for a lock instead of failing with "database is locked"
+func SQLiteBusyTimeoutOpt(d time.Duration) Option {
+	return SQLitePragmaOpt("busy_timeout", strconv.FormatInt(d.Milliseconds(), 10))
+}
+
+// SQLiteForeignKeysOpt enforces foreign key constraints, which sqlite3 ignores by default
+func SQLiteForeignKeysOpt() Option {
+	return SQLitePragmaOpt("foreign_keys", "ON")
+}
+
+// SQLiteSingleConnOpt serializes statements through a single connection to sqlite3 databases,
+// overriding MaxOpenConnsOpt. A statement run outside of a transaction in progress waits for it to end.
+func SQLiteSingleConnOpt() Option {
+	return func(o *options) {
+		o.sqliteSingleConn = true
+	}
+}
+
+// Open the connection pool of source, running the pragmas on every connection to sqlite3
+func (o *options) openPool(driverName, source string) (*sql.DB, error) {
+	pool, err := sql.Open(driverName, source)
+	if err != nil || driverName != "sqlite3" || len(o.pragmas) == 0 {
+		return pool, err
+	}
+	drv := pool.Driver()
+	pool.Close()
+	return sql.OpenDB(&pragmaConnector{driver: drv, source: source, pragmas: o.pragmas}), nil
+}
+
+// pragmaConnector opens connections with the driver and runs the pragmas on them
+type pragmaConnector struct {
+	driver  driver.Driver
+	source  string
+	pragmas []pragma
+}
+
+func (c *pragmaConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.source)
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("orm: connection can not run pragmas")
+	}
+	for _, p := range c.pragmas {
+		if _, err := execer.ExecContext(ctx, "PRAGMA "+p.name+" = "+p.value, nil); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *pragmaConnector) Driver() driver.Driver {
+	return c.driver
+}
@@ -0,0 +1,53 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLitePragmaOpt(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "pragma.db"),
+		NamingStrategyOpt(&gorm.NamingStrategy{}),
+		SQLiteWALOpt(),
+		SQLiteBusyTimeoutOpt(5*time.Second),
+		SQLiteForeignKeysOpt(),
+		SQLitePragmaOpt("cache_size", "-4096"),
+		MaxOpenConnsOpt(4))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+
+	pragma := func(name string) (value string) {
+		assert.NoError(t, db.Raw("PRAGMA "+name).Row().Scan(&value))
+		return
+	}
+	// Every connection of the pool gets the pragmas, the transaction holds one while the queries open another
+	tx := db.Begin()
+	defer tx.End()
+	var mode string
+	assert.NoError(t, tx.Raw("PRAGMA journal_mode").Row().Scan(&mode))
+	assert.Equal(t, "wal", mode)
+	assert.Equal(t, "wal", pragma("journal_mode"))
+	assert.Equal(t, "5000", pragma("busy_timeout"))
+	assert.Equal(t, "1", pragma("foreign_keys"))
+	assert.Equal(t, "-4096", pragma("cache_size"))
+	assert.Equal(t, 2, db.PoolStats()["primary"].OpenConnections)
+
+	_, err = New("sqlite3://"+filepath.Join(t.TempDir(), "invalid.db"), SQLitePragmaOpt("journal_mode", "'"))
+	assert.Error(t, err)
+}
+
+func TestSQLiteSingleConnOpt(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "single.db"), NamingStrategyOpt(&gorm.NamingStrategy{}), MaxOpenConnsOpt(4), SQLiteSingleConnOpt())
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.Equal(t, 1, db.PoolStats()["primary"].MaxOpenConnections)
+}
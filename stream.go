@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Iterator walks the rows of a query one at a time, see Stream
+type Iterator struct {
+	db   *gorm.DB
+	rows *sql.Rows
+	err  error
+}
+
+// Stream runs query, nil for every record, on the table of model and returns the iterator of its rows,
+// so that large results are processed without being loaded at once. The iterator must be closed.
+//
+//	it, err := db.Stream(db.Where("created_at > ?", since), &User{})
+//	defer it.Close()
+//	for it.Next(ctx) {
+//		var user User
+//		if err := it.Scan(&user); err != nil { ... }
+//	}
+//	err = it.Err()
+func (db *DB) Stream(query *gorm.DB, model interface{}) (*Iterator, error) {
+	if query == nil {
+		query = db.DB
+	}
+	query = query.Model(model)
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator{db: query, rows: rows}, nil
+}
+
+// Next moves to the next row, returning false at the end of the rows, on error or once ctx is done, see Err
+func (it *Iterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if err := ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+	return it.rows.Next()
+}
+
+// Scan reads the current row into dst, a pointer to a struct mapped as gorm maps models
+func (it *Iterator) Scan(dst interface{}) error {
+	return it.db.ScanRows(it.rows, dst)
+}
+
+// Err returns the error that stopped Next, nil at the end of the rows
+func (it *Iterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+// Close releases the connection of the rows, it can be called several times
+func (it *Iterator) Close() error {
+	return it.rows.Close()
+}
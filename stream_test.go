@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type streamDB struct {
+	ID   int
+	Name string
+}
+
+func TestDB_Stream(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&streamDB{}).Error)
+	assert.NoError(t, db.BulkCreate([]streamDB{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}))
+
+	ctx := context.Background()
+	it, err := db.Stream(db.Where("id > ?", 1).Order("id"), &streamDB{})
+	assert.NoError(t, err)
+	var streamed []streamDB
+	for it.Next(ctx) {
+		var record streamDB
+		assert.NoError(t, it.Scan(&record))
+		streamed = append(streamed, record)
+	}
+	assert.NoError(t, it.Err())
+	assert.NoError(t, it.Close())
+	assert.Equal(t, []streamDB{{ID: 2, Name: "b"}, {ID: 3, Name: "c"}}, streamed)
+
+	canceled, cancel := context.WithCancel(ctx)
+	it, err = db.Stream(nil, &streamDB{})
+	assert.NoError(t, err)
+	defer it.Close()
+	assert.True(t, it.Next(canceled))
+	cancel()
+	assert.False(t, it.Next(canceled))
+	assert.Equal(t, context.Canceled, it.Err())
+
+	_, err = db.Stream(nil, &struct{ Missing int }{})
+	assert.Error(t, err)
+}
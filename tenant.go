@@ -0,0 +1,124 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrCrossTenant is the error of a write through a ForTenant handle of a record of another tenant
+var ErrCrossTenant = errors.New("orm: record of another tenant")
+
+// ErrTenantBulk is the error of a bulk operation through a ForTenant handle, which the tenant callbacks do not scope
+var ErrTenantBulk = errors.New("orm: bulk operations are not scoped to tenants")
+
+// TenantModel scopes rows to a tenant, see ForTenant
+type TenantModel struct {
+	TenantID string `gorm:"size:64;index"`
+}
+
+const tenantKey = "orm:tenant"
+
+// ForTenant returns db bound to ctx, see WithContext, and scoped to the tenant tenantID:
+// creates set the TenantID of TenantModel records, queries, updates and deletes only reach the rows of the tenant,
+// and writes of records of another tenant fail with ErrCrossTenant. Bulk operations fail with ErrTenantBulk,
+// raw statements are left as they are.
+// The handles and transactions derived from it are scoped as well.
+func (db *DB) ForTenant(ctx context.Context, tenantID string) *DB {
+	derived := db.WithContext(ctx)
+	derived.DB = derived.DB.Set(tenantKey, tenantID)
+	return derived
+}
+
+// Whether db is scoped to a tenant by ForTenant
+func isTenantScoped(db *gorm.DB) bool {
+	_, ok := db.Get(tenantKey)
+	return ok
+}
+
+// Register the callbacks scoping the statements of ForTenant handles
+func registerTenant(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("orm:tenant", assignTenant)
+	callback.Update().Before("gorm:update").Register("orm:tenant", scopeTenantUpdate)
+	callback.Delete().Before("gorm:delete").Register("orm:tenant", scopeTenantDelete)
+	callback.Query().Before("gorm:query").Register("orm:tenant", scopeTenant)
+	callback.RowQuery().Before("gorm:row_query").Register("orm:tenant", scopeTenant)
+}
+
+// Tenant of the handle of scope and the TenantID field of its model, if both are there
+func tenantOf(scope *gorm.Scope) (string, *gorm.Field, bool) {
+	tenant, ok := scope.Get(tenantKey)
+	if !ok || scope.HasError() {
+		return "", nil, false
+	}
+	field, ok := scope.FieldByName("TenantID")
+	return tenant.(string), field, ok
+}
+
+// TenantID of the record of scope, empty when scope is not about a single record
+func recordTenant(scope *gorm.Scope, field *gorm.Field) string {
+	if scope.IndirectValue().Kind() != reflect.Struct || !field.Field.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(field.Field.Interface())
+}
+
+func assignTenant(scope *gorm.Scope) {
+	tenant, field, ok := tenantOf(scope)
+	if !ok {
+		return
+	}
+	if field.IsBlank {
+		scope.SetColumn(field, tenant)
+	} else if recordTenant(scope, field) != tenant {
+		scope.Err(ErrCrossTenant)
+	}
+}
+
+// Refuse to update a record of another tenant or to move rows to another tenant, then scope the statement.
+// A record without TenantID gets the one of the tenant, so that saving it keeps the row in the tenant.
+func scopeTenantUpdate(scope *gorm.Scope) {
+	tenant, field, ok := tenantOf(scope)
+	if !ok {
+		return
+	}
+	if recorded := recordTenant(scope, field); recorded != "" && recorded != tenant {
+		scope.Err(ErrCrossTenant)
+		return
+	}
+	if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+		if updated, ok := attrs.(map[string]interface{})[field.DBName]; ok && fmt.Sprint(updated) != tenant {
+			scope.Err(ErrCrossTenant)
+			return
+		}
+	} else if scope.IndirectValue().Kind() == reflect.Struct {
+		scope.SetColumn(field, tenant)
+	}
+	scopeTenant(scope)
+}
+
+// Refuse to delete a record of another tenant, then scope the statement
+func scopeTenantDelete(scope *gorm.Scope) {
+	tenant, field, ok := tenantOf(scope)
+	if !ok {
+		return
+	}
+	if recorded := recordTenant(scope, field); recorded != "" && recorded != tenant {
+		scope.Err(ErrCrossTenant)
+		return
+	}
+	scopeTenant(scope)
+}
+
+// Restrict the statement to the rows of the tenant
+func scopeTenant(scope *gorm.Scope) {
+	tenant, field, ok := tenantOf(scope)
+	if !ok || isRaw(scope) {
+		return
+	}
+	scope.Search.Where(fmt.Sprintf("%s.%s = ?", scope.QuotedTableName(), scope.Quote(field.DBName)), tenant)
+}
@@ -0,0 +1,77 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantDB struct {
+	ID   int
+	Name string
+	TenantModel
+}
+
+func TestDB_ForTenant(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&tenantDB{}).Error)
+
+	acme := db.ForTenant(context.Background(), "acme")
+	globex := db.ForTenant(context.Background(), "globex")
+	record := &tenantDB{ID: 1, Name: "a"}
+	assert.NoError(t, acme.Create(record).Error)
+	assert.Equal(t, "acme", record.TenantID)
+	assert.NoError(t, globex.Create(&tenantDB{ID: 2, Name: "b"}).Error)
+	assert.Equal(t, ErrCrossTenant, acme.Create(&tenantDB{ID: 3, TenantModel: TenantModel{TenantID: "globex"}}).Error)
+
+	var rows []tenantDB
+	assert.NoError(t, acme.Find(&rows).Error)
+	assert.Equal(t, []tenantDB{{ID: 1, Name: "a", TenantModel: TenantModel{TenantID: "acme"}}}, rows)
+	var count int
+	assert.NoError(t, globex.Model(&tenantDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+	assert.True(t, gorm.IsRecordNotFoundError(globex.First(&tenantDB{}, 1).Error))
+
+	// Writes stay within the tenant
+	assert.Equal(t, ErrCrossTenant, globex.Model(record).Update("name", "stolen").Error)
+	assert.Equal(t, ErrCrossTenant, globex.Delete(record).Error)
+	assert.Equal(t, ErrCrossTenant, acme.Model(record).Update("tenant_id", "globex").Error)
+	assert.NoError(t, globex.Model(&tenantDB{}).Update("name", "renamed").Error)
+	assert.NoError(t, acme.Save(&tenantDB{ID: 1, Name: "saved"}).Error)
+	assert.NoError(t, globex.Where("id = ?", 1).Delete(&tenantDB{}).Error)
+
+	assert.NoError(t, db.Order("id").Find(&rows).Error)
+	assert.Equal(t, []tenantDB{
+		{ID: 1, Name: "saved", TenantModel: TenantModel{TenantID: "acme"}},
+		{ID: 2, Name: "renamed", TenantModel: TenantModel{TenantID: "globex"}},
+	}, rows)
+
+	tx := acme.Begin()
+	defer tx.End()
+	assert.NoError(t, tx.Find(&rows).Error)
+	assert.Len(t, rows, 1)
+
+	// Bulk operations bypass the callbacks
+	for _, bulk := range []func() error{
+		func() error { return acme.BulkCreate([]tenantDB{{ID: 3}}) },
+		func() error { return acme.BulkCreateMaps("tenant_dbs", []map[string]interface{}{{"id": 3}}) },
+		func() error { return acme.BulkUpdate([]tenantDB{{ID: 2, Name: "stolen"}}, "name") },
+		func() error { return acme.BulkSave([]tenantDB{{ID: 2, Name: "stolen"}}) },
+		func() error { return acme.BulkDelete(&tenantDB{}, []int{2}) },
+		func() error { return tx.BulkCreate([]tenantDB{{ID: 3}}) },
+		func() error { return tx.BulkCreateMaps("tenant_dbs", []map[string]interface{}{{"id": 3}}) },
+		func() error { return tx.BulkUpdate([]tenantDB{{ID: 2, Name: "stolen"}}, "name") },
+		func() error { return tx.BulkSave([]tenantDB{{ID: 2, Name: "stolen"}}) },
+		func() error { return tx.BulkDelete(&tenantDB{}, []int{2}) },
+	} {
+		assert.Equal(t, ErrTenantBulk, bulk())
+	}
+	assert.NoError(t, tx.Find(&rows).Error)
+	assert.Len(t, rows, 1)
+	tx.End()
+	assert.NoError(t, db.BulkCreate([]tenantDB{{ID: 3, TenantModel: TenantModel{TenantID: "acme"}}}))
+}
@@ -0,0 +1,118 @@
+package orm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/ClickHouse/clickhouse-go"
+	"github.com/go-sql-driver/mysql"
+)
+
+// TLSConfig describes a verified TLS connection, registered under a name by RegisterTLS
+type TLSConfig struct {
+	CAFile   string // PEM bundle of the certificate authorities trusted for the server
+	CertFile string // PEM client certificate, along with KeyFile
+	KeyFile  string
+	// Name expected in the server certificate, the host by default.
+	// lib/pq always verifies the host, so it does not apply to postgres.
+	ServerName string
+	// Encrypt without verifying the server certificate
+	InsecureSkipVerify bool
+}
+
+var tlsConfigs struct {
+	sync.RWMutex
+	byName map[string]TLSConfig
+}
+
+// RegisterTLS registers cfg under name, so that a Config with that TLS name connects with it.
+// The certificates are loaded once here for mysql and clickhouse and read by the driver on connection for postgres and mssql.
+func RegisterTLS(name string, cfg TLSConfig) error {
+	config, err := cfg.load()
+	if err != nil {
+		return fmt.Errorf("orm: tls config %q: %v", name, err)
+	}
+	if err := mysql.RegisterTLSConfig(name, config); err != nil {
+		return fmt.Errorf("orm: tls config %q: %v", name, err)
+	}
+	if err := clickhouse.RegisterTLSConfig(name, config); err != nil {
+		return fmt.Errorf("orm: tls config %q: %v", name, err)
+	}
+
+	tlsConfigs.Lock()
+	defer tlsConfigs.Unlock()
+	if tlsConfigs.byName == nil {
+		tlsConfigs.byName = make(map[string]TLSConfig)
+	}
+	tlsConfigs.byName[name] = cfg
+	return nil
+}
+
+// DeregisterTLS removes the config registered under name
+func DeregisterTLS(name string) {
+	mysql.DeregisterTLSConfig(name)
+	clickhouse.DeregisterTLSConfig(name)
+	tlsConfigs.Lock()
+	defer tlsConfigs.Unlock()
+	delete(tlsConfigs.byName, name)
+}
+
+func registeredTLS(name string) (TLSConfig, bool) {
+	tlsConfigs.RLock()
+	defer tlsConfigs.RUnlock()
+	cfg, ok := tlsConfigs.byName[name]
+	return cfg, ok
+}
+
+// Build the crypto/tls config from the files of cfg
+func (cfg TLSConfig) load() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// Postgres connection parameters of cfg
+func (cfg TLSConfig) postgresParams() map[string]string {
+	mode := "verify-full"
+	if cfg.InsecureSkipVerify {
+		mode = "require"
+	}
+	return map[string]string{
+		"sslmode":     mode,
+		"sslrootcert": cfg.CAFile,
+		"sslcert":     cfg.CertFile,
+		"sslkey":      cfg.KeyFile,
+	}
+}
+
+// MSSQL connection parameters of cfg, the driver takes no client certificate
+func (cfg TLSConfig) mssqlParams() map[string]string {
+	return map[string]string{
+		"encrypt":                "true",
+		"TrustServerCertificate": fmt.Sprint(cfg.InsecureSkipVerify),
+		"certificate":            cfg.CAFile,
+		"hostNameInCertificate":  cfg.ServerName,
+	}
+}
@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Write a self signed certificate and its key, returns their files
+func writeCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "db.internal"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestRegisterTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+	assert.NoError(t, RegisterTLS("managed", TLSConfig{CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: "db.internal"}))
+	defer DeregisterTLS("managed")
+
+	dsn, err := Config{Dialect: "mysql", Host: "db", User: "app", DBName: "shop", TLS: "managed"}.DSN()
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql://app@tcp(db:3306)/shop?tls=managed", dsn)
+
+	dsn, err = Config{Dialect: "postgres", Host: "db", User: "app", DBName: "shop", TLS: "managed"}.DSN()
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://dbname=shop host=db port=5432 sslcert="+certFile+" sslkey="+keyFile+" sslmode=verify-full sslrootcert="+certFile+" user=app", dsn)
+
+	dsn, err = Config{Dialect: "mssql", Host: "db", User: "sa", DBName: "shop", TLS: "managed"}.DSN()
+	assert.NoError(t, err)
+	assert.Contains(t, dsn, "encrypt=true")
+	assert.Contains(t, dsn, "hostNameInCertificate=db.internal")
+
+	assert.Error(t, RegisterTLS("true", TLSConfig{}))
+	assert.Error(t, RegisterTLS("missing", TLSConfig{CAFile: filepath.Join(t.TempDir(), "ca.pem")}))
+	assert.Error(t, RegisterTLS("key", TLSConfig{CAFile: keyFile}))
+}
@@ -0,0 +1,132 @@
+package orm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/cochainio/orm"
+
+// tracing creates a span per statement, transaction boundary and bulk operation
+type tracing struct {
+	tracer       trace.Tracer
+	maxStatement int
+	redact       func(sql string) string
+}
+
+// TracingOpt traces statements, bulk operations and Begin/Commit/Rollback with provider
+func TracingOpt(provider trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = provider
+	}
+}
+
+// TracingStatementOpt rewrites the db.statement attribute of spans with redact when set,
+// then truncates it to maxLength bytes when positive
+func TracingStatementOpt(maxLength int, redact func(sql string) string) Option {
+	return func(o *options) {
+		o.maxStatement = maxLength
+		o.redactStatement = redact
+	}
+}
+
+func newTracing(o *options) *tracing {
+	if o.tracerProvider == nil {
+		return nil
+	}
+	return &tracing{
+		tracer:       o.tracerProvider.Tracer(instrumentationName),
+		maxStatement: o.maxStatement,
+		redact:       o.redactStatement,
+	}
+}
+
+const (
+	contextKey = "orm:context"
+	spanKey    = "orm:span"
+)
+
+// Context of the statements of scope, see DB.WithContext
+func scopeContext(scope *gorm.Scope) context.Context {
+	if ctx, ok := scope.Get(contextKey); ok {
+		return ctx.(context.Context)
+	}
+	return context.Background()
+}
+
+// Name of the database system as OpenTelemetry's semantic conventions spell it
+func dbSystem(dialect string) string {
+	switch dialect {
+	case "postgres":
+		return "postgresql"
+	case "sqlite3":
+		return "sqlite"
+	}
+	return dialect
+}
+
+func (in *instrument) startSpan(scope *gorm.Scope, operation string) {
+	if in.tracing == nil {
+		return
+	}
+	name := strings.ToUpper(operation)
+	if table := scope.TableName(); table != "" {
+		name += " " + table
+	}
+	_, span := in.tracing.tracer.Start(scopeContext(scope), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", dbSystem(scope.Dialect().GetName())),
+			attribute.String("db.operation", operation),
+		),
+	)
+	scope.InstanceSet(spanKey, span)
+}
+
+func (in *instrument) endSpan(scope *gorm.Scope) {
+	value, ok := scope.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span := value.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.statement", in.tracing.statement(scope.SQL)),
+		attribute.Int64("db.rows_affected", scope.DB().RowsAffected),
+	)
+	setSpanError(span, scope.DB().Error)
+	span.End()
+}
+
+// Run fn in a span named name, like transaction boundaries
+func (in *instrument) trace(ctx context.Context, name string, fn func() error) error {
+	if in == nil || in.tracing == nil {
+		return fn()
+	}
+	_, span := in.tracing.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	err := fn()
+	setSpanError(span, err)
+	span.End()
+	return err
+}
+
+func (t *tracing) statement(sql string) string {
+	if t.redact != nil {
+		sql = t.redact(sql)
+	}
+	if t.maxStatement > 0 && len(sql) > t.maxStatement {
+		sql = sql[:t.maxStatement]
+	}
+	return sql
+}
+
+func setSpanError(span trace.Span, err error) {
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type tracingDB struct {
+	ID   int
+	Name string
+}
+
+func TestTracingOpt(t *testing.T) {
+	defer func() { Singleton = nil }()
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+
+	recorder := tracetest.NewSpanRecorder()
+	Instantiate("sqlite3://"+filepath.Join(t.TempDir(), "tracing.db"),
+		NamingStrategyOpt(&gorm.NamingStrategy{}),
+		TracingOpt(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		TracingStatementOpt(6, nil),
+	)
+	defer Singleton.Close(context.Background())
+	assert.NoError(t, Singleton.AutoMigrate(&tracingDB{}).Error)
+
+	tx := Singleton.Begin()
+	assert.NoError(t, tx.Create(&tracingDB{Name: "a"}).Error)
+	assert.NoError(t, tx.Commit(true))
+	assert.Error(t, Singleton.Table("missing").Find(&[]tracingDB{}).Error)
+
+	spans := recorder.Ended()
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name())
+	}
+	assert.Equal(t, []string{"orm.Begin", "INSERT tracing_db", "orm.Commit", "SELECT missing"}, names)
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range spans[1].Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	assert.Equal(t, "sqlite", attrs["db.system"].AsString())
+	assert.Equal(t, "INSERT", attrs["db.statement"].AsString())
+	assert.Equal(t, int64(1), attrs["db.rows_affected"].AsInt64())
+
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+}
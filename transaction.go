@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Transaction runs fn in a transaction bound to ctx, committed when fn returns nil.
+// It is rolled back when fn returns an error, which is returned, or panics, in which case the panic goes on.
+// The transaction is started with opts when given, see BeginTx.
+func (db *DB) Transaction(ctx context.Context, fn func(tx *TX) error, opts ...sql.TxOptions) error {
+	var txOpts sql.TxOptions
+	if len(opts) > 0 {
+		txOpts = opts[0]
+	}
+	tx := db.BeginTx(ctx, txOpts)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer tx.End()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.CommitE()
+}
+
+// Map opts to what the dialect supports, failing on levels it can not provide
+func txOptions(dialect string, opts sql.TxOptions) (sql.TxOptions, error) {
+	unsupported := fmt.Errorf("orm: isolation level %s is not supported by %s", opts.Isolation, dialect)
+	switch dialect {
+	case "postgres", "mysql":
+		switch opts.Isolation {
+		case sql.LevelSnapshot:
+			opts.Isolation = sql.LevelRepeatableRead
+		case sql.LevelWriteCommitted, sql.LevelLinearizable:
+			return opts, unsupported
+		}
+	case "mssql":
+		opts.ReadOnly = false
+		switch opts.Isolation {
+		case sql.LevelWriteCommitted, sql.LevelLinearizable:
+			return opts, unsupported
+		}
+	case "sqlite3":
+		if opts.Isolation == sql.LevelLinearizable {
+			return opts, unsupported
+		}
+		opts = sql.TxOptions{}
+	case "clickhouse":
+		opts = sql.TxOptions{}
+	}
+	return opts, nil
+}
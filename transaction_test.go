@@ -0,0 +1,163 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cochainio/orm/outbox"
+)
+
+type transactionDB struct {
+	ID   int
+	Name string
+}
+
+func openTransactionDB(t *testing.T, opts ...Option) *DB {
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "transaction.db"), append([]Option{NamingStrategyOpt(&gorm.NamingStrategy{})}, opts...)...)
+	assert.NoError(t, err)
+	assert.NoError(t, db.AutoMigrate(&transactionDB{}).Error)
+	return db
+}
+
+func TestDB_Transaction(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	count := func() (count int) {
+		assert.NoError(t, db.Model(&transactionDB{}).Count(&count).Error)
+		return
+	}
+
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		return tx.Create(&transactionDB{Name: "committed"}).Error
+	}))
+	assert.Equal(t, 1, count())
+
+	failure := errors.New("failure")
+	assert.Equal(t, failure, db.Transaction(context.Background(), func(tx *TX) error {
+		assert.NoError(t, tx.Create(&transactionDB{Name: "rolled back"}).Error)
+		return failure
+	}))
+	assert.Equal(t, 1, count())
+
+	assert.PanicsWithValue(t, "failure", func() {
+		db.Transaction(context.Background(), func(tx *TX) error {
+			tx.Create(&transactionDB{Name: "rolled back"})
+			panic("failure")
+		})
+	})
+	assert.Equal(t, 1, count())
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, db.Transaction(canceled, func(tx *TX) error {
+		t.Error("fn must not run without a transaction")
+		return nil
+	}))
+}
+
+func Test_txOptions(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		opts     sql.TxOptions
+		expected sql.TxOptions
+	}{
+		{"postgres", sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}},
+		{"postgres", sql.TxOptions{Isolation: sql.LevelSnapshot}, sql.TxOptions{Isolation: sql.LevelRepeatableRead}},
+		{"mysql", sql.TxOptions{Isolation: sql.LevelReadCommitted}, sql.TxOptions{Isolation: sql.LevelReadCommitted}},
+		{"mssql", sql.TxOptions{Isolation: sql.LevelSnapshot, ReadOnly: true}, sql.TxOptions{Isolation: sql.LevelSnapshot}},
+		{"sqlite3", sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, sql.TxOptions{}},
+	}
+	for _, test := range tests {
+		opts, err := txOptions(test.dialect, test.opts)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, opts, test.dialect)
+	}
+
+	_, err := txOptions("mysql", sql.TxOptions{Isolation: sql.LevelLinearizable})
+	assert.Error(t, err)
+}
+
+func TestDB_BeginTx(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		return tx.Create(&transactionDB{Name: "serializable"}).Error
+	}, sql.TxOptions{Isolation: sql.LevelSerializable}))
+
+	tx := db.BeginTx(context.Background(), sql.TxOptions{Isolation: sql.LevelLinearizable})
+	assert.Error(t, tx.Error)
+	tx.End()
+}
+
+func TestTX_EndE(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	tx := db.Begin()
+	assert.NoError(t, tx.Create(&transactionDB{Name: "committed"}).Error)
+	assert.NoError(t, tx.CommitE())
+	assert.NoError(t, tx.EndE())
+
+	tx = db.Begin()
+	assert.NoError(t, tx.EndE())
+	assert.NoError(t, tx.EndE())
+
+	// Commit failures are returned, the transaction is over
+	tx = db.Begin()
+	assert.NoError(t, tx.DB.Rollback().Error)
+	assert.Equal(t, sql.ErrTxDone, tx.CommitE())
+	assert.NoError(t, tx.EndE())
+
+	// The failure of the rollback is reported
+	sql.Register("orm_rollback", rollbackDriver{})
+	failing, err := New("orm_rollback://")
+	assert.NoError(t, err)
+	defer failing.Close(context.Background())
+	assert.EqualError(t, failing.Begin().EndE(), "connection lost")
+}
+
+// rollbackDriver fails to roll back its transactions
+type rollbackDriver struct{}
+
+func (rollbackDriver) Open(name string) (driver.Conn, error) { return rollbackConn{}, nil }
+
+type rollbackConn struct{}
+
+func (rollbackConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (rollbackConn) Close() error              { return nil }
+func (rollbackConn) Begin() (driver.Tx, error) { return rollbackConn{}, nil }
+func (rollbackConn) Commit() error             { return nil }
+func (rollbackConn) Rollback() error           { return errors.New("connection lost") }
+
+func TestTX_Publish(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, outbox.Migrate(db.DB))
+
+	assert.NoError(t, db.Transaction(context.Background(), func(tx *TX) error {
+		if err := tx.Create(&transactionDB{Name: "order"}).Error; err != nil {
+			return err
+		}
+		return tx.Publish("orders", map[string]string{"name": "order"})
+	}))
+
+	var messages []outbox.Message
+	assert.NoError(t, db.Find(&messages).Error)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, `{"name":"order"}`, string(messages[0].Payload))
+}
@@ -0,0 +1,156 @@
+package orm
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// TxStats describes a transaction, as it stands or once it ended
+type TxStats struct {
+	StartedAt time.Time
+	// Statements run through gorm's callbacks, a bulk operation counting once
+	Statements   int
+	RowsAffected int64
+	// Time the transaction has been open, until it ended
+	Duration  time.Duration
+	Ended     bool
+	Committed bool
+}
+
+// TxObserver is notified of the stats of every transaction once it ends.
+// Loggers and query observers implementing it are notified along with the statements.
+type TxObserver interface {
+	ObserveTx(stats TxStats)
+}
+
+// LogLongTransaction is the default long transaction handler, writing to the standard logger
+func LogLongTransaction(stats TxStats) {
+	log.Printf("transaction open for %s since %s: %d statements, %d rows affected",
+		stats.Duration, stats.StartedAt.Format(time.RFC3339), stats.Statements, stats.RowsAffected)
+}
+
+// LongTransactionOpt reports transactions still open after threshold to handler, LogLongTransaction when nil
+func LongTransactionOpt(threshold time.Duration, handler func(TxStats)) Option {
+	return func(o *options) {
+		o.longTxThreshold = threshold
+		o.longTxHandler = handler
+	}
+}
+
+const txStatsKey = "orm:tx_stats"
+
+// txStats accumulates the stats of a transaction and of those nested in it
+type txStats struct {
+	mu    sync.Mutex
+	stats TxStats
+	timer *time.Timer // Reports the transaction once it is open for too long
+}
+
+// Stats returns the stats of the transaction, those of the outermost one for a nested transaction
+func (tx *TX) Stats() TxStats {
+	return tx.stats.snapshot()
+}
+
+// Start the stats of tx, counting the statements run with it from now on
+func (tx *TX) startStats() {
+	tx.stats = &txStats{stats: TxStats{StartedAt: time.Now()}}
+	tx.DB = tx.DB.Set(txStatsKey, tx.stats)
+
+	if in := tx.instrument; in != nil {
+		in.mu.RLock()
+		threshold, handler := in.longTxThreshold, in.longTxHandler
+		in.mu.RUnlock()
+		if threshold > 0 {
+			if handler == nil {
+				handler = LogLongTransaction
+			}
+			stats := tx.stats
+			stats.timer = time.AfterFunc(threshold, func() {
+				if snapshot := stats.snapshot(); !snapshot.Ended {
+					handler(snapshot)
+				}
+			})
+		}
+	}
+}
+
+// Count a statement of the transaction of scope, if any
+func recordTxStatement(scope *gorm.Scope, rows int64) {
+	if stats, ok := scope.Get(txStatsKey); ok {
+		stats.(*txStats).record(rows)
+	}
+}
+
+func (s *txStats) record(rows int64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Statements++
+	s.stats.RowsAffected += rows
+}
+
+func (s *txStats) snapshot() TxStats {
+	if s == nil {
+		return TxStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	if !stats.Ended {
+		stats.Duration = time.Since(stats.StartedAt)
+	}
+	return stats
+}
+
+// End the stats, false when they were not started
+func (s *txStats) finish(committed bool) (TxStats, bool) {
+	if s == nil {
+		return TxStats{}, false
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.Duration = time.Since(s.stats.StartedAt)
+	s.stats.Ended = true
+	s.stats.Committed = committed
+	return s.stats, true
+}
+
+// Notify the loggers and observers of the stats of an ended transaction
+func (in *instrument) reportTx(stats TxStats) {
+	if in == nil {
+		return
+	}
+	in.mu.RLock()
+	logger, observers := in.logger, in.observers
+	in.mu.RUnlock()
+
+	if observer, ok := logger.(TxObserver); ok {
+		observer.ObserveTx(stats)
+	}
+	for _, observer := range observers {
+		if observer, ok := observer.(TxObserver); ok {
+			observer.ObserveTx(stats)
+		}
+	}
+}
+
+// Observe a bulk operation of the transaction, refused when it is read-only or scoped to a tenant
+func (tx *TX) observeBulk(fn func() error) error {
+	if isReadOnly(tx.DB) {
+		return &ReadOnlyError{Operation: "bulk"}
+	}
+	if isTenantScoped(tx.DB) {
+		return ErrTenantBulk
+	}
+	err := tx.instrument.observeBulk(tx.context(), fn)
+	tx.stats.record(0)
+	return err
+}
@@ -0,0 +1,63 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type txRecorder struct {
+	stats []TxStats
+}
+
+func (r *txRecorder) ObserveQuery(e QueryEvent) {}
+
+func (r *txRecorder) ObserveTx(stats TxStats) {
+	r.stats = append(r.stats, stats)
+}
+
+func TestTX_Stats(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	long := make(chan TxStats, 1)
+	db := openTransactionDB(t, LongTransactionOpt(20*time.Millisecond, func(stats TxStats) { long <- stats }))
+	defer db.Close(context.Background())
+	recorder := &txRecorder{}
+	db.AddQueryObserver(recorder)
+
+	tx := db.Begin()
+	assert.NoError(t, tx.Create(&transactionDB{Name: "a"}).Error)
+	assert.NoError(t, tx.BulkCreate([]transactionDB{{Name: "b"}, {Name: "c"}}))
+	nested := tx.Begin()
+	assert.NoError(t, nested.Model(&transactionDB{}).Where("name <> ?", "a").Update("name", "d").Error)
+	assert.NoError(t, nested.CommitE())
+
+	stats := tx.Stats()
+	assert.Equal(t, 3, stats.Statements)
+	assert.Equal(t, int64(3), stats.RowsAffected)
+	assert.False(t, stats.Ended)
+	assert.Equal(t, stats.Statements, nested.Stats().Statements)
+
+	// Transactions open for too long are reported while still open
+	select {
+	case stats := <-long:
+		assert.False(t, stats.Ended)
+		assert.True(t, stats.Duration >= 20*time.Millisecond)
+	case <-time.After(5 * time.Second):
+		t.Fatal("long transaction not reported")
+	}
+
+	assert.NoError(t, tx.CommitE())
+	stats = tx.Stats()
+	assert.True(t, stats.Ended)
+	assert.True(t, stats.Committed)
+	assert.Equal(t, []TxStats{stats}, recorder.stats)
+
+	db.Begin().End()
+	assert.Len(t, recorder.stats, 2)
+	assert.False(t, recorder.stats[1].Committed)
+	assert.Zero(t, recorder.stats[1].Statements)
+	assert.Empty(t, long)
+}
@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TxOpt configures a transaction started by BeginTx
+type TxOpt func(*txConfig)
+
+type txConfig struct {
+	timeout time.Duration
+}
+
+// TxTimeoutOpt rolls the transaction back once it has been open for timeout, busy or idle.
+// Its statements fail from then on and Commit returns a *TxTimeoutError.
+// On postgres the transaction also gets an idle_in_transaction_session_timeout of timeout,
+// so that the server ends it should the client stop responding.
+func TxTimeoutOpt(timeout time.Duration) TxOpt {
+	return func(c *txConfig) {
+		c.timeout = timeout
+	}
+}
+
+// TxTimeoutError is returned by Commit once the transaction outlived its TxTimeoutOpt and was rolled back
+type TxTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TxTimeoutError) Error() string {
+	return fmt.Sprintf("orm: transaction rolled back after its timeout of %s", e.Timeout)
+}
+
+// Is makes the error match context.DeadlineExceeded, like the statements failing on the deadline
+func (e *TxTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// IsTxTimeout reports whether err is the one of a transaction that outlived its TxTimeoutOpt
+func IsTxTimeout(err error) bool {
+	var timeout *TxTimeoutError
+	return errors.As(err, &timeout)
+}
+
+// Bind tx to a context rolling it back once timeout elapses, canceled when tx ends
+func (tx *TX) startTimeout(timeout time.Duration) {
+	tx.lifetime, tx.cancel = context.WithTimeoutCause(tx.context(), timeout, &TxTimeoutError{Timeout: timeout})
+	tx.ctx = tx.lifetime
+}
+
+// Have postgres end tx when it stays idle for timeout, the session setting lasting as long as tx
+func (tx *TX) limitIdle(timeout time.Duration) error {
+	if tx.Dialect().GetName() != "postgres" {
+		return nil
+	}
+	ms := max(timeout.Milliseconds(), 1) // Zero disables the timeout
+	return tx.DB.Exec(fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", ms)).Error
+}
+
+// Error of tx once its timeout rolled it back, nil otherwise
+func (tx *TX) timeoutErr() error {
+	if tx.lifetime == nil {
+		return nil
+	}
+	var timeout *TxTimeoutError
+	if errors.As(context.Cause(tx.lifetime), &timeout) {
+		return timeout
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxTimeoutOpt(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+
+	tx := db.BeginTx(context.Background(), sql.TxOptions{}, TxTimeoutOpt(time.Hour))
+	assert.NoError(t, tx.Create(&transactionDB{Name: "committed"}).Error)
+	assert.NoError(t, tx.CommitE())
+
+	tx = db.BeginTx(context.Background(), sql.TxOptions{}, TxTimeoutOpt(20*time.Millisecond))
+	defer tx.End()
+	assert.NoError(t, tx.Create(&transactionDB{Name: "timed out"}).Error)
+	time.Sleep(50 * time.Millisecond)
+	assert.Error(t, tx.Create(&transactionDB{Name: "late"}).Error)
+
+	err := tx.CommitE()
+	assert.True(t, IsTxTimeout(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 20*time.Millisecond, err.(*TxTimeoutError).Timeout)
+	assert.NoError(t, tx.EndE())
+
+	var names []string
+	assert.NoError(t, db.Model(&transactionDB{}).Pluck("name", &names).Error)
+	assert.Equal(t, []string{"committed"}, names)
+}
+
+func Test_IsTxTimeout(t *testing.T) {
+	assert.True(t, IsTxTimeout(&TxTimeoutError{Timeout: time.Second}))
+	assert.False(t, IsTxTimeout(context.DeadlineExceeded))
+	assert.False(t, IsTxTimeout(nil))
+}
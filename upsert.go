@@ -0,0 +1,99 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/cochainio/orm/bulk_insert"
+)
+
+// Upsert inserts record, a pointer to a struct, or updates the row it conflicts with on conflictColumns,
+// the primary key when empty, in a single statement, ON CONFLICT on postgres and sqlite and ON DUPLICATE KEY on mysql,
+// which considers every unique key. updateColumns are overwritten on conflict; when empty, every inserted column
+// is but the primary key and created_at.
+// record is then reloaded with the stored row, by RETURNING on postgres and by its conflict columns elsewhere.
+// The statement runs no callback and is refused like bulk operations, see BulkCreate.
+func (db *DB) Upsert(record interface{}, conflictColumns, updateColumns []string) error {
+	return db.observeBulk(func() error {
+		return upsert(db.DB, record, conflictColumns, updateColumns)
+	})
+}
+
+// Upsert inserts or updates record in the transaction, see DB.Upsert
+func (tx *TX) Upsert(record interface{}, conflictColumns, updateColumns []string) error {
+	return tx.observeBulk(func() error {
+		return upsert(tx.DB, record, conflictColumns, updateColumns)
+	})
+}
+
+func upsert(db *gorm.DB, record interface{}, conflictColumns, updateColumns []string) error {
+	value := reflect.ValueOf(record)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("orm: upserting needs a pointer to a struct, got %T", record)
+	}
+	scope := db.NewScope(record)
+	if len(conflictColumns) == 0 {
+		if scope.PrimaryKeyZero() {
+			return errors.New("orm: upserting without conflict columns needs the primary key")
+		}
+		for _, field := range scope.PrimaryFields() {
+			conflictColumns = append(conflictColumns, field.DBName)
+		}
+	}
+	var conditions []string
+	var args []interface{}
+	for _, column := range conflictColumns {
+		field, ok := scope.FieldByName(column)
+		if !ok {
+			return fmt.Errorf("orm: unknown conflict column %s", column)
+		}
+		conditions = append(conditions, scope.Quote(field.DBName)+" = ?")
+		args = append(args, field.Field.Interface())
+	}
+
+	opts := []bulk_insert.BuilderOpt{bulk_insert.UpsertOpt(conflictColumns, updateColumns)}
+	if len(updateColumns) == 0 {
+		preserved := []string{"created_at"}
+		for _, field := range scope.PrimaryFields() {
+			preserved = append(preserved, field.DBName)
+		}
+		opts = append(opts, bulk_insert.ConflictIgnoreColumnsOpt(preserved))
+	}
+	statements, err := bulk_insert.NewBuilder(opts...).BuildSQL(db, []interface{}{record})
+	if err != nil {
+		return err
+	}
+	if len(statements) != 1 {
+		return fmt.Errorf("orm: upserting built %d statements", len(statements))
+	}
+	statement := statements[0]
+
+	if scope.Dialect().GetName() == "postgres" {
+		rows, err := db.CommonDB().Query(statement.SQL+" RETURNING *", statement.Vars...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			return gorm.ErrRecordNotFound
+		}
+		return db.ScanRows(rows, record)
+	}
+
+	if _, err := db.CommonDB().Exec(statement.SQL, statement.Vars...); err != nil {
+		return err
+	}
+	stored := reflect.New(value.Elem().Type())
+	if err := db.Unscoped().Where(strings.Join(conditions, " AND "), args...).First(stored.Interface()).Error; err != nil {
+		return err
+	}
+	value.Elem().Set(stored.Elem())
+	return nil
+}
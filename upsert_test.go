@@ -0,0 +1,54 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type upsertDB struct {
+	ID        int
+	Email     string `gorm:"unique_index"`
+	Name      string
+	Visits    int
+	CreatedAt time.Time
+}
+
+func TestDB_Upsert(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&upsertDB{}).Error)
+
+	created := upsertDB{ID: 1, Email: "a@example.com", Name: "a", Visits: 1}
+	assert.NoError(t, db.Upsert(&created, []string{"email"}, nil))
+	assert.Equal(t, 1, created.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	updated := upsertDB{ID: 2, Email: "a@example.com", Name: "b", Visits: 2, CreatedAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, db.Upsert(&updated, []string{"email"}, nil))
+	assert.Equal(t, 1, updated.ID)
+	assert.Equal(t, "b", updated.Name)
+	assert.Equal(t, 2, updated.Visits)
+	assert.WithinDuration(t, created.CreatedAt, updated.CreatedAt, time.Second)
+
+	partial := upsertDB{ID: 3, Email: "a@example.com", Name: "c", Visits: 3}
+	assert.NoError(t, db.Upsert(&partial, []string{"email"}, []string{"visits"}))
+	assert.Equal(t, upsertDB{ID: 1, Email: "a@example.com", Name: "b", Visits: 3}, upsertDB{
+		ID: partial.ID, Email: partial.Email, Name: partial.Name, Visits: partial.Visits})
+
+	byKey := upsertDB{ID: 1, Email: "a@example.com", Name: "d"}
+	assert.NoError(t, db.Upsert(&byKey, nil, []string{"name"}))
+	assert.Equal(t, 3, byKey.Visits)
+	var count int
+	assert.NoError(t, db.Model(&upsertDB{}).Count(&count).Error)
+	assert.Equal(t, 1, count)
+
+	assert.Error(t, db.Upsert(&upsertDB{Email: "b@example.com"}, nil, nil))
+	assert.Error(t, db.Upsert(upsertDB{ID: 4}, nil, nil))
+	assert.True(t, IsReadOnly(db.ReadOnly().Upsert(&upsertDB{ID: 1, Name: "e"}, nil, nil)))
+	assert.Equal(t, ErrTenantBulk, db.ForTenant(context.Background(), "acme").Upsert(&upsertDB{ID: 1, Name: "e"}, nil, nil))
+}
@@ -0,0 +1,127 @@
+package orm
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jinzhu/gorm"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// UTCOpt stores times in UTC and reads them back in loc, UTC when nil, so that they compare the same
+// whatever the timezone of the servers.
+// The time fields of records, CreatedAt and UpdatedAt included, are converted to UTC when written and to loc when read,
+// and sessions are opened in UTC: parseTime and loc=UTC on mysql, timezone=UTC on postgres and _loc=UTC on sqlite3.
+// Times passed as arguments of conditions are sent as they are, pass them in UTC.
+func UTCOpt(loc *time.Location) Option {
+	return func(o *options) {
+		if loc == nil {
+			loc = time.UTC
+		}
+		o.location = loc
+	}
+}
+
+// Source of driverName with the parameters opening sessions in UTC
+func utcSource(driverName, source string) (string, error) {
+	switch driverName {
+	case "mysql":
+		cfg, err := mysql.ParseDSN(source)
+		if err != nil {
+			return "", err
+		}
+		cfg.ParseTime = true
+		cfg.Loc = time.UTC
+		return cfg.FormatDSN(), nil
+	case "postgres":
+		if strings.Contains(source, "://") {
+			return withQueryParam(source, "timezone", "UTC")
+		}
+		if strings.Contains(source, "timezone=") {
+			return source, nil
+		}
+		return strings.TrimSpace(source + " timezone=UTC"), nil
+	case "sqlite3":
+		return withQueryParam(source, "_loc", "UTC")
+	}
+	return source, nil
+}
+
+// source with the query parameter key set to value unless it is set already
+func withQueryParam(source, key, value string) (string, error) {
+	path, query, _ := strings.Cut(source, "?")
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	if values.Get(key) != "" {
+		return source, nil
+	}
+	values.Set(key, value)
+	return path + "?" + values.Encode(), nil
+}
+
+// Register the callbacks converting the times written to UTC and the times read to loc
+func registerUTC(db *gorm.DB, loc *time.Location) {
+	toUTC := func(t time.Time) time.Time { return t.UTC() }
+	toLocation := func(t time.Time) time.Time { return t.In(loc) }
+
+	write := func(scope *gorm.Scope) {
+		convertTimes(reflect.ValueOf(scope.Value), toUTC)
+		if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+			for column, value := range attrs.(map[string]interface{}) {
+				switch value := value.(type) {
+				case time.Time:
+					attrs.(map[string]interface{})[column] = value.UTC()
+				case *time.Time:
+					if value != nil {
+						attrs.(map[string]interface{})[column] = value.UTC()
+					}
+				}
+			}
+		}
+	}
+	read := func(scope *gorm.Scope) {
+		convertTimes(reflect.ValueOf(scope.Value), toLocation)
+	}
+
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("orm:utc", write)
+	callback.Create().After("gorm:create").Register("orm:utc_location", read)
+	callback.Update().Before("gorm:update").Register("orm:utc", write)
+	callback.Update().After("gorm:update").Register("orm:utc_location", read)
+	callback.Query().After("gorm:query").Register("orm:utc_location", read)
+}
+
+// Apply convert to the time fields of value, a record, a slice of records or pointers to them.
+// Embedded structs are walked, associations are converted by their own statements.
+func convertTimes(value reflect.Value, convert func(time.Time) time.Time) {
+	value = reflect.Indirect(value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			convertTimes(value.Index(i), convert)
+		}
+	case reflect.Struct:
+		if value.Type() == timeType {
+			if value.CanSet() {
+				value.Set(reflect.ValueOf(convert(value.Interface().(time.Time))))
+			}
+			return
+		}
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Field(i)
+			structField := value.Type().Field(i)
+			switch {
+			case structField.Type == timeType, structField.Type == reflect.PtrTo(timeType):
+				convertTimes(field, convert)
+			case structField.Anonymous:
+				convertTimes(field, convert)
+			}
+		}
+	}
+}
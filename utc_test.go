@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type utcDB struct {
+	ID int
+	TimeModel
+	DueAt *time.Time
+}
+
+func TestUTCOpt(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	db, err := New("sqlite3://"+filepath.Join(t.TempDir(), "utc.db"), NamingStrategyOpt(&gorm.NamingStrategy{}), UTCOpt(paris))
+	assert.NoError(t, err)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&utcDB{}).Error)
+
+	due := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("UTC+5", 5*3600))
+	record := utcDB{ID: 1, DueAt: &due}
+	assert.NoError(t, db.Create(&record).Error)
+	assert.Equal(t, paris, record.CreatedAt.Location())
+
+	var stored string
+	assert.NoError(t, db.DB.Raw("SELECT due_at FROM utc_db").Row().Scan(&stored))
+	assert.Contains(t, stored, "07:00:00")
+
+	var read []utcDB
+	assert.NoError(t, db.Find(&read).Error)
+	assert.Len(t, read, 1)
+	assert.Equal(t, paris, read[0].DueAt.Location())
+	assert.True(t, read[0].DueAt.Equal(due))
+	assert.Equal(t, paris, read[0].UpdatedAt.Location())
+
+	later := due.Add(time.Hour)
+	assert.NoError(t, db.Model(&record).Updates(map[string]interface{}{"due_at": later}).Error)
+	assert.NoError(t, db.DB.Raw("SELECT due_at FROM utc_db").Row().Scan(&stored))
+	assert.Contains(t, stored, "08:00:00")
+}
+
+func Test_utcSource(t *testing.T) {
+	for _, c := range []struct{ driver, source, expected string }{
+		{"mysql", "app:pass@tcp(db:3306)/shop", "app:pass@tcp(db:3306)/shop?parseTime=true"},
+		{"postgres", "host=db dbname=shop", "host=db dbname=shop timezone=UTC"},
+		{"postgres", "host=db timezone=Europe/Paris", "host=db timezone=Europe/Paris"},
+		{"postgres", "postgres://db/shop?sslmode=disable", "postgres://db/shop?sslmode=disable&timezone=UTC"},
+		{"sqlite3", "file.db", "file.db?_loc=UTC"},
+		{"sqlite3", "file.db?_loc=auto", "file.db?_loc=auto"},
+		{"mssql", "sqlserver://db", "sqlserver://db"},
+	} {
+		source, err := utcSource(c.driver, c.source)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, source, c.source)
+	}
+}
@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ErrStaleObject is the error of an update of a VersionModel whose row was updated or deleted since it was read
+var ErrStaleObject = errors.New("orm: stale object, the row changed since it was read")
+
+// VersionModel locks rows optimistically: updates of a record by its primary key only apply when Version
+// is still the one read, and increment it. Otherwise they fail with ErrStaleObject and the record is left as is.
+type VersionModel struct {
+	Version int
+}
+
+func (m *VersionModel) lockVersion() *int {
+	return &m.Version
+}
+
+// versioned is implemented by the models embedding VersionModel
+type versioned interface {
+	lockVersion() *int
+}
+
+// IsStaleObject reports whether err is, or contains, ErrStaleObject
+func IsStaleObject(err error) bool {
+	if errs, ok := err.(gorm.Errors); ok {
+		for _, err := range errs {
+			if err == ErrStaleObject {
+				return true
+			}
+		}
+	}
+	return err == ErrStaleObject
+}
+
+const versionKey = "orm:version"
+
+// Register the callbacks checking and incrementing the version of the records updated
+func registerVersion(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Update().Before("gorm:update").Register("orm:check_version", checkVersion)
+	callback.Update().After("gorm:update").Register("orm:detect_stale", detectStale)
+}
+
+// Update the row only at the version read, to the next one
+func checkVersion(scope *gorm.Scope) {
+	model, ok := scope.Value.(versioned)
+	if !ok || scope.HasError() || scope.PrimaryKeyZero() {
+		return
+	}
+	field, ok := scope.FieldByName("Version")
+	if !ok {
+		return
+	}
+	version := model.lockVersion()
+	read := *version
+	scope.Search.Where(fmt.Sprintf("%s.%s = ?", scope.QuotedTableName(), scope.Quote(field.DBName)), read)
+	if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+		attrs.(map[string]interface{})[field.DBName] = read + 1
+	}
+	*version = read + 1
+	scope.InstanceSet(versionKey, read)
+}
+
+// Fail the update when no row was at the version read, restoring it in the record
+func detectStale(scope *gorm.Scope) {
+	read, ok := scope.InstanceGet(versionKey)
+	if !ok {
+		return
+	}
+	if !scope.HasError() && scope.DB().RowsAffected == 0 {
+		scope.Err(ErrStaleObject)
+	}
+	if scope.HasError() {
+		*scope.Value.(versioned).lockVersion() = read.(int)
+	}
+}
@@ -0,0 +1,45 @@
+package orm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type versionDB struct {
+	ID   int
+	Name string
+	VersionModel
+}
+
+func TestVersionModel(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	db := openTransactionDB(t)
+	defer db.Close(context.Background())
+	assert.NoError(t, db.AutoMigrate(&versionDB{}).Error)
+
+	assert.NoError(t, db.Create(&versionDB{ID: 1, Name: "a"}).Error)
+	var first, second versionDB
+	assert.NoError(t, db.First(&first, 1).Error)
+	assert.NoError(t, db.First(&second, 1).Error)
+
+	first.Name = "b"
+	assert.NoError(t, db.Save(&first).Error)
+	assert.Equal(t, 1, first.Version)
+	assert.NoError(t, db.Model(&first).Update("name", "c").Error)
+	assert.Equal(t, 2, first.Version)
+
+	second.Name = "stale"
+	assert.True(t, IsStaleObject(db.Save(&second).Error))
+	assert.Equal(t, 0, second.Version)
+	assert.True(t, IsStaleObject(db.Model(&second).Update("name", "stale").Error))
+
+	var stored versionDB
+	assert.NoError(t, db.First(&stored, 1).Error)
+	assert.Equal(t, versionDB{ID: 1, Name: "c", VersionModel: VersionModel{Version: 2}}, stored)
+
+	// Updates by condition are not versioned
+	assert.NoError(t, db.Model(&versionDB{}).Where("id = ?", 1).Update("name", "d").Error)
+}
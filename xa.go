@@ -0,0 +1,236 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// XA coordinates a transaction spanning several databases with a two-phase commit, on postgres and mysql.
+// It is experimental: should the process stop between both phases, the prepared branches keep their locks
+// until resolved by hand on each database, with COMMIT PREPARED or XA COMMIT and their rollback counterparts.
+// Postgres needs max_prepared_transactions above zero.
+type XA struct {
+	id       string
+	ctx      context.Context
+	branches []*xaBranch
+	done     bool
+}
+
+// xaBranch is the part of an XA transaction running on one database, on a connection of its own
+type xaBranch struct {
+	tx       *TX
+	conn     *xaConn
+	protocol xaProtocol
+	xid      string
+	prepared bool
+}
+
+// xaProtocol holds the statements driving a branch, {xid} standing for its quoted id
+type xaProtocol struct {
+	start, prepare, commit, rollback, rollbackPrepared []string
+}
+
+var xaProtocols = map[string]xaProtocol{
+	"postgres": {
+		start:            []string{"BEGIN"},
+		prepare:          []string{"PREPARE TRANSACTION {xid}"},
+		commit:           []string{"COMMIT PREPARED {xid}"},
+		rollback:         []string{"ROLLBACK"},
+		rollbackPrepared: []string{"ROLLBACK PREPARED {xid}"},
+	},
+	"mysql": {
+		start:            []string{"XA START {xid}"},
+		prepare:          []string{"XA END {xid}", "XA PREPARE {xid}"},
+		commit:           []string{"XA COMMIT {xid}"},
+		rollback:         []string{"XA END {xid}", "XA ROLLBACK {xid}"},
+		rollbackPrepared: []string{"XA ROLLBACK {xid}"},
+	},
+}
+
+// Ids are written in the statements, mysql allows 64 bytes
+var xaID = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,48}$`)
+
+// NewXA creates the coordinator of the transaction id, which must be unique across the participating databases.
+// The branches are bound to ctx.
+func NewXA(ctx context.Context, id string) (*XA, error) {
+	if !xaID.MatchString(id) {
+		return nil, fmt.Errorf("orm: invalid XA transaction id %q", id)
+	}
+	return &XA{id: id, ctx: ctx}, nil
+}
+
+func (xa *XA) context() context.Context {
+	if xa.ctx == nil {
+		return context.Background()
+	}
+	return xa.ctx
+}
+
+// Begin starts the branch of xa on a dedicated connection of the primary of db and returns the transaction to write with.
+// It fails on dialects without two-phase commit. The branch is committed or rolled back along with the others by xa,
+// not by the Commit or Rollback of the returned transaction.
+func (xa *XA) Begin(db *DB) *TX {
+	protocol, ok := xaProtocols[db.Dialect().GetName()]
+	var err error
+	switch {
+	case xa.done:
+		err = errors.New("orm: XA transaction already ended")
+	case !ok:
+		err = fmt.Errorf("orm: %s has no two-phase commit", db.Dialect().GetName())
+	case !db.lifecycle.acquire():
+		err = ErrClosed
+	}
+	if err != nil {
+		failed := db.DB.New()
+		failed.AddError(err)
+		return &TX{DB: failed, txState: &txState{ended: true}}
+	}
+
+	tx := &TX{
+		txState:    &txState{},
+		lifecycle:  db.lifecycle,
+		instrument: db.instrument,
+		ctx:        xa.ctx,
+	}
+	branch := &xaBranch{tx: tx, protocol: protocol, xid: fmt.Sprintf("%s_%d", xa.id, len(xa.branches))}
+	db.instrument.trace(xa.context(), "orm.XA.Begin", func() error {
+		err = branch.start(xa.context(), db.Primary().DB)
+		return err
+	})
+	if err != nil {
+		tx.end()
+		tx.DB = db.DB.New()
+		tx.DB.AddError(err)
+		return tx
+	}
+	xa.branches = append(xa.branches, branch)
+	tx.startStats()
+	tx.DB = withContext(tx.DB, xa.ctx)
+	return tx
+}
+
+// Commit prepares every branch then, once they all are, commits them.
+// Should a branch fail to prepare, every branch is rolled back and the error returned.
+// A branch failing to commit is left prepared, its error is returned once the other branches are committed.
+func (xa *XA) Commit() error {
+	if xa.done {
+		return errors.New("orm: XA transaction already ended")
+	}
+	xa.done = true
+	ctx := xa.context()
+
+	for _, branch := range xa.branches {
+		if err := branch.exec(ctx, branch.protocol.prepare); err != nil {
+			xa.rollback()
+			return fmt.Errorf("orm: XA branch %s failed to prepare: %w", branch.xid, err)
+		}
+		branch.prepared = true
+	}
+
+	var err error
+	for _, branch := range xa.branches {
+		commitErr := branch.exec(ctx, branch.protocol.commit)
+		branch.end(commitErr == nil)
+		if commitErr != nil && err == nil {
+			err = fmt.Errorf("orm: XA branch %s is prepared but failed to commit: %w", branch.xid, commitErr)
+		}
+	}
+	return err
+}
+
+// Rollback rolls back every branch, returning the first error. It does nothing once xa ended.
+func (xa *XA) Rollback() error {
+	if xa.done {
+		return nil
+	}
+	xa.done = true
+	return xa.rollback()
+}
+
+// End rolls back xa unless it was committed, meant to be deferred right after NewXA
+func (xa *XA) End() {
+	xa.Rollback()
+}
+
+func (xa *XA) rollback() error {
+	var err error
+	for _, branch := range xa.branches {
+		statements := branch.protocol.rollback
+		if branch.prepared {
+			statements = branch.protocol.rollbackPrepared
+		}
+		rollbackErr := branch.exec(xa.context(), statements)
+		branch.end(false)
+		if rollbackErr != nil && err == nil {
+			err = fmt.Errorf("orm: XA branch %s failed to roll back: %w", branch.xid, rollbackErr)
+		}
+	}
+	return err
+}
+
+// Take a connection of db and start the branch on it
+func (b *xaBranch) start(ctx context.Context, db *gorm.DB) error {
+	pool, ok := unwrapCommon(db.CommonDB()).(*sql.DB)
+	if !ok {
+		return errors.New("orm: XA branches start from a connection pool, not a transaction")
+	}
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	b.conn = &xaConn{conn}
+	if err := b.exec(ctx, b.protocol.start); err != nil {
+		conn.Close()
+		return err
+	}
+	b.tx.DB = db.New()
+	setCommon(b.tx.DB, b.conn)
+	return nil
+}
+
+// Run every statement, going on after a failure so that rollbacks do as much as they can, and return the first error
+func (b *xaBranch) exec(ctx context.Context, statements []string) error {
+	var err error
+	for _, statement := range statements {
+		statement = strings.ReplaceAll(statement, "{xid}", "'"+b.xid+"'")
+		if _, execErr := b.conn.ExecContext(ctx, statement); execErr != nil && err == nil {
+			err = execErr
+		}
+	}
+	return err
+}
+
+// Give the connection back to the pool and let Close know the branch is no longer in flight
+func (b *xaBranch) end(committed bool) {
+	b.tx.committed = committed
+	b.tx.end()
+	b.conn.Close()
+}
+
+// xaConn is the connection of an XA branch, which gorm sees as neither a pool nor a transaction
+// so that it runs the statements as they are
+type xaConn struct {
+	*sql.Conn
+}
+
+func (c *xaConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+func (c *xaConn) Prepare(query string) (*sql.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *xaConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.QueryContext(context.Background(), query, args...)
+}
+
+func (c *xaConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.QueryRowContext(context.Background(), query, args...)
+}
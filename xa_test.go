@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXA(t *testing.T) {
+	defer gorm.AddNamingStrategy(gorm.TheNamingStrategy)
+	// sqlite has no two-phase commit, plain transactions stand in for the branches
+	xaProtocols["sqlite3"] = xaProtocol{
+		start:            []string{"BEGIN"},
+		prepare:          []string{"SELECT {xid}"},
+		commit:           []string{"COMMIT"},
+		rollback:         []string{"ROLLBACK"},
+		rollbackPrepared: []string{"ROLLBACK"},
+	}
+	defer delete(xaProtocols, "sqlite3")
+
+	dir := t.TempDir()
+	var dbs []*DB
+	for _, name := range []string{"a.db", "b.db"} {
+		db, err := New("sqlite3://"+filepath.Join(dir, name), NamingStrategyOpt(&gorm.NamingStrategy{}))
+		assert.NoError(t, err)
+		defer db.Close(context.Background())
+		assert.NoError(t, db.AutoMigrate(&transactionDB{}).Error)
+		dbs = append(dbs, db)
+	}
+	count := func(db *DB) (count int) {
+		assert.NoError(t, db.Model(&transactionDB{}).Count(&count).Error)
+		return
+	}
+
+	_, err := NewXA(context.Background(), "it's")
+	assert.Error(t, err)
+
+	xa, err := NewXA(context.Background(), "transfer")
+	assert.NoError(t, err)
+	for _, db := range dbs {
+		tx := xa.Begin(db)
+		assert.NoError(t, tx.Error)
+		assert.NoError(t, tx.Create(&transactionDB{Name: "committed"}).Error)
+	}
+	assert.Equal(t, "transfer_1", xa.branches[1].xid)
+	assert.NoError(t, xa.Commit())
+	assert.Error(t, xa.Commit())
+	assert.Error(t, xa.Begin(dbs[0]).Error)
+	assert.Equal(t, 1, count(dbs[0]))
+	assert.Equal(t, 1, count(dbs[1]))
+
+	xa, err = NewXA(context.Background(), "canceled")
+	assert.NoError(t, err)
+	for _, db := range dbs {
+		assert.NoError(t, xa.Begin(db).Create(&transactionDB{Name: "rolled back"}).Error)
+	}
+	xa.End()
+	assert.Equal(t, 1, count(dbs[0]))
+	assert.Equal(t, 1, count(dbs[1]))
+
+	delete(xaProtocols, "sqlite3")
+	xa, err = NewXA(context.Background(), "unsupported")
+	assert.NoError(t, err)
+	assert.EqualError(t, xa.Begin(dbs[0]).Error, "orm: sqlite3 has no two-phase commit")
+}